@@ -3,7 +3,10 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net"
 	"os"
+	"strings"
+	"time"
 
 	yaml "gopkg.in/yaml.v2"
 )
@@ -11,6 +14,7 @@ import (
 // MQTTUserClientConfig 定义了MQTT配置结构体
 type MQTTUserClientConfig struct {
 	MqttUserConfig MQTTUserConfig `yaml:"MQTTUserClient"`
+	BleUserConfig  BLEUserConfig  `yaml:"BLEUserClient"`
 }
 
 type MQTTUserConfig struct {
@@ -23,6 +27,127 @@ type MQTTUserConfig struct {
 	Password string `yaml:"password"`
 }
 
+// BLEUserConfig 定义了BLE代理服务的自定义配置
+type BLEUserConfig struct {
+	ConnectionEventTopic           string `yaml:"connectionEventTopic"`           // 连接生命周期事件发布主题
+	FirmwareProgressTopic          string `yaml:"firmwareProgressTopic"`          // 模块固件升级进度发布主题
+	FirmwareUpgrade                bool   `yaml:"firmwareUpgrade"`                // 是否允许串口固件升级，升级协议未经模块文档确认，默认关闭
+	AdvertisingEventTopic          string `yaml:"advertisingEventTopic"`          // 按时间表或手动控制开始/停止广播的事件发布主题
+	RestartAdvertisingOnDisconnect bool   `yaml:"restartAdvertisingOnDisconnect"` // 断开连接后是否自动重新广播
+	DefaultMTU                     int    `yaml:"defaultMTU"`                     // 未获知协商 MTU 时使用的默认值，0 表示 247
+	ReconcileInterval              string `yaml:"reconcileInterval"`              // 模块配置周期校验间隔，如 60s，0 表示只在启动和复位后校验
+	DesiredStateDir                string `yaml:"desiredStateDir"`                // 各设备期望配置的持久化目录
+	AccessListFile                 string `yaml:"accessListFile"`                 // 中心设备访问控制名单文件
+	AdvertisingScheduleFile        string `yaml:"advertisingScheduleFile"`        // 广播时间表文件
+	IndicateCommandResponses       bool   `yaml:"indicateCommandResponses"`       // 运维命令响应使用 Indication 发送并等待手机确认
+	AutoConnParams                 bool   `yaml:"autoConnParams"`                 // 多包传输前后自动切换大数据量/空闲连接参数
+	LinkQualityInterval            string `yaml:"linkQualityInterval"`            // 各连接信号强度查询间隔，如 10s，0 表示不查询
+	NameTemplate                   string `yaml:"nameTemplate"`                   // 广播名称模板，如 GW-{site}-{mac}，为空时使用期望配置中的名称
+	SiteLabel                      string `yaml:"siteLabel"`                      // 站点标签，名称模板中 {site} 的取值
+
+	Security BLESecurityConfig `yaml:"security"` // 配对与链路安全配置
+	Power    BLEPowerConfig    `yaml:"power"`    // 空闲功耗策略
+	Scan     BLEScanConfig     `yaml:"scan"`     // 标签扫描
+	Presence BLEPresenceConfig `yaml:"presence"` // 标签在场判定
+	Readable BLEReadableConfig `yaml:"readable"` // 由 EdgeX 读数维护内容的可读特征值
+}
+
+// BLEReadableConfig 定义了可读特征值配置：订阅 EdgeX 事件，将指定设备资源的最新读数写入对应特征值，
+// 中心设备可直接读取特征值获得最新数据
+type BLEReadableConfig struct {
+	EventTopic      string               `yaml:"eventTopic"`      // EdgeX 事件主题，支持通配符
+	Characteristics []BLEReadableMapping `yaml:"characteristics"` // 设备资源到特征值的映射
+}
+
+// BLEReadableMapping 一个设备资源到可读特征值的映射
+type BLEReadableMapping struct {
+	Device         string `yaml:"device"`         // EdgeX 设备名称
+	Resource       string `yaml:"resource"`       // 设备资源名称
+	Service        string `yaml:"service"`        // 特征值所属的 GATT 服务，为空时使用默认服务
+	Characteristic string `yaml:"characteristic"` // 特征值 UUID，GATT 服务表中没有时自动添加
+}
+
+// BLEPresenceConfig 定义了标签在场判定配置，依据扫描结果为每个标签维护一个虚拟设备并推送在场事件读数
+type BLEPresenceConfig struct {
+	Enabled          bool     `yaml:"enabled"`          // 是否开启，需同时开启扫描
+	Smoothing        string   `yaml:"smoothing"`        // 信号平滑方式 average / kalman
+	Window           int      `yaml:"window"`           // 滑动平均的样本数
+	ProcessNoise     float64  `yaml:"processNoise"`     // 卡尔曼滤波过程噪声
+	MeasurementNoise float64  `yaml:"measurementNoise"` // 卡尔曼滤波测量噪声
+	NearRSSI         int      `yaml:"nearRssi"`         // 近距离阈值（dBm）
+	FarRSSI          int      `yaml:"farRssi"`          // 远距离阈值（dBm）
+	Hysteresis       int      `yaml:"hysteresis"`       // 离开区域的回差（dB）
+	Timeout          string   `yaml:"timeout"`          // 超过该时间未扫描到标签视为离开，如 30s
+	ProfileName      string   `yaml:"profileName"`      // 标签虚拟设备使用的设备配置文件
+	DevicePrefix     string   `yaml:"devicePrefix"`     // 标签虚拟设备名称前缀，后接去掉冒号的 MAC 地址
+	Tags             []string `yaml:"tags"`             // 只为这些 MAC 地址的标签创建虚拟设备；为空时必须配置扫描过滤条件
+}
+
+// BLEScanConfig 定义了标签扫描配置，开启后模块以多角色初始化，扫描与外围设备功能同时运行
+type BLEScanConfig struct {
+	Enabled         bool     `yaml:"enabled"`         // 是否持续扫描
+	Topic           string   `yaml:"topic"`           // 扫描结果发布主题
+	Active          bool     `yaml:"active"`          // 主动扫描，可获取扫描响应包中的名称
+	Interval        int      `yaml:"interval"`        // 扫描间隔（ms）
+	Window          int      `yaml:"window"`          // 扫描窗口（ms），不大于扫描间隔
+	BatchSize       int      `yaml:"batchSize"`       // 攒够多少条立即发布
+	BatchInterval   string   `yaml:"batchInterval"`   // 最长攒批时间，如 1s
+	TagInterval     string   `yaml:"tagInterval"`     // 同一标签两次发布的最小间隔，如 5s，0 表示不限制
+	NamePrefixes    []string `yaml:"namePrefixes"`    // 按名称前缀过滤
+	ServiceUUIDs    []string `yaml:"serviceUUIDs"`    // 按广播的服务 UUID 过滤
+	ManufacturerIDs []uint16 `yaml:"manufacturerIds"` // 按厂商标识过滤
+	MinRSSI         int      `yaml:"minRssi"`         // 信号强度下限（dBm），0 表示不限
+}
+
+// BLEPowerConfig 定义了无连接、无数据往来时的功耗策略
+type BLEPowerConfig struct {
+	LowPowerAfter       string `yaml:"lowPowerAfter"`       // 无活动多久后放慢广播，如 5m，0 表示不放慢
+	LowPowerAdvInterval int    `yaml:"lowPowerAdvInterval"` // 放慢后的广播间隔（ms）
+	SleepAfter          string `yaml:"sleepAfter"`          // 无活动多久后使模块休眠，如 30m，0 表示不休眠
+}
+
+// BLESecurityConfig 定义了BLE配对与链路安全配置
+type BLESecurityConfig struct {
+	Mode              string `yaml:"mode"`              // none / just-works / passkey-display / passkey-entry / static-passkey，为空时不修改模块配置
+	Bonding           bool   `yaml:"bonding"`           // 是否保存绑定信息
+	RequireEncryption bool   `yaml:"requireEncryption"` // 是否拒绝未加密的访问
+	SecretName        string `yaml:"secretName"`        // 配对码在密钥存储中的名称，键为 passkey
+}
+
+// 自定义BLE配置的默认值
+const (
+	DefaultConnectionEventTopic  = "edgex/service/data/device_ble/connection"
+	DefaultFirmwareProgressTopic = "edgex/service/data/device_ble/firmware"
+	DefaultAdvertisingEventTopic = "edgex/service/data/device_ble/advertising"
+	DefaultSecuritySecretName    = "ble-security"
+	MaxMTU                       = 517 // BLE 规范允许的最大 ATT MTU
+	DefaultReconcileInterval     = "60s"
+	DefaultDesiredStateDir       = "./res/desired"
+	DefaultAccessListFile        = "./res/access-list.json"
+	DefaultAdvScheduleFile       = "./res/adv-schedule.json"
+	DefaultLinkQualityInterval   = "10s"
+	DefaultLowPowerAfter         = "5m"
+	DefaultLowPowerAdvInterval   = 1000
+	DefaultSleepAfter            = "0"
+	DefaultScanTopic             = "edgex/service/data/device_ble/sightings"
+	DefaultScanInterval          = 100
+	DefaultScanWindow            = 50
+	DefaultScanBatchSize         = 50
+	DefaultScanBatchInterval     = "1s"
+	DefaultScanTagInterval       = "5s"
+	DefaultPresenceSmoothing     = "average"
+	DefaultPresenceWindow        = 5
+	DefaultPresenceProcessNoise  = 0.05
+	DefaultPresenceMeasureNoise  = 4.0
+	DefaultPresenceNearRSSI      = -65
+	DefaultPresenceFarRSSI       = -85
+	DefaultPresenceHysteresis    = 5
+	DefaultPresenceTimeout       = "30s"
+	DefaultPresenceProfileName   = "device-ble-tag"
+	DefaultPresenceDevicePrefix  = "ble-tag-"
+	DefaultReadableEventTopic    = "edgex/events/device/#"
+)
+
 // LoadConfig 从指定的文件加载配置
 func LoadConfig(filePath string) (*MQTTUserClientConfig, error) {
 	file, err := os.Open(filePath)
@@ -37,6 +162,9 @@ func LoadConfig(filePath string) (*MQTTUserClientConfig, error) {
 		return nil, fmt.Errorf("unable to decode yaml into config: %v", err)
 	}
 
+	// 填充未配置项的默认值
+	config.applyDefaults()
+
 	// 验证配置
 	if err := config.Validate(); err != nil {
 		return nil, err
@@ -45,6 +173,99 @@ func LoadConfig(filePath string) (*MQTTUserClientConfig, error) {
 	return &config, nil
 }
 
+// applyDefaults 为未配置的可选项填充默认值
+func (config *MQTTUserClientConfig) applyDefaults() {
+	if config.BleUserConfig.ConnectionEventTopic == "" {
+		config.BleUserConfig.ConnectionEventTopic = DefaultConnectionEventTopic
+	}
+	if config.BleUserConfig.FirmwareProgressTopic == "" {
+		config.BleUserConfig.FirmwareProgressTopic = DefaultFirmwareProgressTopic
+	}
+	if config.BleUserConfig.AdvertisingEventTopic == "" {
+		config.BleUserConfig.AdvertisingEventTopic = DefaultAdvertisingEventTopic
+	}
+	if config.BleUserConfig.ReconcileInterval == "" {
+		config.BleUserConfig.ReconcileInterval = DefaultReconcileInterval
+	}
+	if config.BleUserConfig.DesiredStateDir == "" {
+		config.BleUserConfig.DesiredStateDir = DefaultDesiredStateDir
+	}
+	if config.BleUserConfig.LinkQualityInterval == "" {
+		config.BleUserConfig.LinkQualityInterval = DefaultLinkQualityInterval
+	}
+	if config.BleUserConfig.AccessListFile == "" {
+		config.BleUserConfig.AccessListFile = DefaultAccessListFile
+	}
+	if config.BleUserConfig.AdvertisingScheduleFile == "" {
+		config.BleUserConfig.AdvertisingScheduleFile = DefaultAdvScheduleFile
+	}
+	if config.BleUserConfig.Power.LowPowerAfter == "" {
+		config.BleUserConfig.Power.LowPowerAfter = DefaultLowPowerAfter
+	}
+	if config.BleUserConfig.Power.LowPowerAdvInterval == 0 {
+		config.BleUserConfig.Power.LowPowerAdvInterval = DefaultLowPowerAdvInterval
+	}
+	if config.BleUserConfig.Power.SleepAfter == "" {
+		config.BleUserConfig.Power.SleepAfter = DefaultSleepAfter
+	}
+	scan := &config.BleUserConfig.Scan
+	if scan.Topic == "" {
+		scan.Topic = DefaultScanTopic
+	}
+	if scan.Interval == 0 {
+		scan.Interval = DefaultScanInterval
+	}
+	if scan.Window == 0 {
+		scan.Window = DefaultScanWindow
+	}
+	if scan.BatchSize == 0 {
+		scan.BatchSize = DefaultScanBatchSize
+	}
+	if scan.BatchInterval == "" {
+		scan.BatchInterval = DefaultScanBatchInterval
+	}
+	if scan.TagInterval == "" {
+		scan.TagInterval = DefaultScanTagInterval
+	}
+	presence := &config.BleUserConfig.Presence
+	if presence.Smoothing == "" {
+		presence.Smoothing = DefaultPresenceSmoothing
+	}
+	if presence.Window == 0 {
+		presence.Window = DefaultPresenceWindow
+	}
+	if presence.ProcessNoise == 0 {
+		presence.ProcessNoise = DefaultPresenceProcessNoise
+	}
+	if presence.MeasurementNoise == 0 {
+		presence.MeasurementNoise = DefaultPresenceMeasureNoise
+	}
+	if presence.NearRSSI == 0 {
+		presence.NearRSSI = DefaultPresenceNearRSSI
+	}
+	if presence.FarRSSI == 0 {
+		presence.FarRSSI = DefaultPresenceFarRSSI
+	}
+	if presence.Hysteresis == 0 {
+		presence.Hysteresis = DefaultPresenceHysteresis
+	}
+	if presence.Timeout == "" {
+		presence.Timeout = DefaultPresenceTimeout
+	}
+	if presence.ProfileName == "" {
+		presence.ProfileName = DefaultPresenceProfileName
+	}
+	if presence.DevicePrefix == "" {
+		presence.DevicePrefix = DefaultPresenceDevicePrefix
+	}
+	if config.BleUserConfig.Readable.EventTopic == "" {
+		config.BleUserConfig.Readable.EventTopic = DefaultReadableEventTopic
+	}
+	if config.BleUserConfig.Security.SecretName == "" {
+		config.BleUserConfig.Security.SecretName = DefaultSecuritySecretName
+	}
+}
+
 // Validate 验证配置是否有效
 func (config *MQTTUserClientConfig) Validate() error {
 	if len(config.MqttUserConfig.Host) == 0 {
@@ -62,5 +283,112 @@ func (config *MQTTUserClientConfig) Validate() error {
 	if config.MqttUserConfig.QoS < 0 || config.MqttUserConfig.QoS > 2 {
 		return errors.New("MQTTUserClientConfig.QoS must be 0, 1, or 2")
 	}
+	if config.BleUserConfig.DefaultMTU < 0 || config.BleUserConfig.DefaultMTU > MaxMTU {
+		return fmt.Errorf("BLEUserClient.DefaultMTU must be between 0 (module default) and %d", MaxMTU)
+	}
+	if d, err := time.ParseDuration(config.BleUserConfig.ReconcileInterval); err != nil || d < 0 {
+		return fmt.Errorf("BLEUserClient.ReconcileInterval must be a non-negative duration, got %q", config.BleUserConfig.ReconcileInterval)
+	}
+	if d, err := time.ParseDuration(config.BleUserConfig.LinkQualityInterval); err != nil || d < 0 {
+		return fmt.Errorf("BLEUserClient.LinkQualityInterval must be a non-negative duration, got %q", config.BleUserConfig.LinkQualityInterval)
+	}
+	power := config.BleUserConfig.Power
+	if d, err := time.ParseDuration(power.LowPowerAfter); err != nil || d < 0 {
+		return fmt.Errorf("BLEUserClient.Power.LowPowerAfter must be a non-negative duration, got %q", power.LowPowerAfter)
+	}
+	if power.LowPowerAdvInterval < 20 || power.LowPowerAdvInterval > 10240 {
+		return fmt.Errorf("BLEUserClient.Power.LowPowerAdvInterval must be between 20 and 10240 ms, got %d", power.LowPowerAdvInterval)
+	}
+	if d, err := time.ParseDuration(power.SleepAfter); err != nil || d < 0 {
+		return fmt.Errorf("BLEUserClient.Power.SleepAfter must be a non-negative duration, got %q", power.SleepAfter)
+	}
+	scan := config.BleUserConfig.Scan
+	if scan.Window < 3 || scan.Interval > 10240 || scan.Window > scan.Interval {
+		return fmt.Errorf("BLEUserClient.Scan requires 3 <= window <= interval <= 10240 ms, got window %d, interval %d", scan.Window, scan.Interval)
+	}
+	if scan.BatchSize < 1 {
+		return fmt.Errorf("BLEUserClient.Scan.BatchSize must be positive, got %d", scan.BatchSize)
+	}
+	if d, err := time.ParseDuration(scan.BatchInterval); err != nil || d <= 0 {
+		return fmt.Errorf("BLEUserClient.Scan.BatchInterval must be a positive duration, got %q", scan.BatchInterval)
+	}
+	if d, err := time.ParseDuration(scan.TagInterval); err != nil || d < 0 {
+		return fmt.Errorf("BLEUserClient.Scan.TagInterval must be a non-negative duration, got %q", scan.TagInterval)
+	}
+	presence := config.BleUserConfig.Presence
+	if presence.Smoothing != "average" && presence.Smoothing != "kalman" {
+		return fmt.Errorf("BLEUserClient.Presence.Smoothing must be average or kalman, got %q", presence.Smoothing)
+	}
+	if presence.NearRSSI <= presence.FarRSSI {
+		return fmt.Errorf("BLEUserClient.Presence.NearRssi (%d) must be above FarRssi (%d)", presence.NearRSSI, presence.FarRSSI)
+	}
+	if d, err := time.ParseDuration(presence.Timeout); err != nil || d <= 0 {
+		return fmt.Errorf("BLEUserClient.Presence.Timeout must be a positive duration, got %q", presence.Timeout)
+	}
+	if presence.Enabled && !scan.Enabled {
+		return fmt.Errorf("BLEUserClient.Presence requires BLEUserClient.Scan to be enabled")
+	}
+	// 不加限制时附近任何 BLE 设备都会被创建为 EdgeX 设备
+	if presence.Enabled && len(presence.Tags) == 0 && len(scan.NamePrefixes) == 0 && len(scan.ServiceUUIDs) == 0 && len(scan.ManufacturerIDs) == 0 {
+		return fmt.Errorf("BLEUserClient.Presence requires Tags or a Scan filter (namePrefixes, serviceUUIDs, manufacturerIds) before tag devices are created")
+	}
+	for i, tag := range presence.Tags {
+		if mac, err := net.ParseMAC(tag); err != nil || len(mac) != 6 {
+			return fmt.Errorf("BLEUserClient.Presence.Tags[%d] must be a MAC address, got %q", i, tag)
+		}
+	}
+	mapped := make(map[string]bool)
+	for i, m := range config.BleUserConfig.Readable.Characteristics {
+		if m.Device == "" || m.Resource == "" || m.Characteristic == "" {
+			return fmt.Errorf("BLEUserClient.Readable.Characteristics[%d] requires device, resource and characteristic", i)
+		}
+		uuid := strings.ToLower(m.Characteristic)
+		if mapped[uuid] {
+			return fmt.Errorf("BLEUserClient.Readable.Characteristics[%d]: characteristic %s is mapped more than once", i, m.Characteristic)
+		}
+		mapped[uuid] = true
+	}
 	return nil
 }
+
+// ReconcileEvery 返回模块配置周期校验间隔，配置为 0 时返回 0
+func (c BLEUserConfig) ReconcileEvery() time.Duration {
+	d, _ := time.ParseDuration(c.ReconcileInterval)
+	return d
+}
+
+// LinkQualityEvery 返回信号强度查询间隔，配置为 0 时返回 0
+func (c BLEUserConfig) LinkQualityEvery() time.Duration {
+	d, _ := time.ParseDuration(c.LinkQualityInterval)
+	return d
+}
+
+// LowPowerAfterDuration 返回放慢广播前的空闲时间，配置为 0 时返回 0
+func (c BLEPowerConfig) LowPowerAfterDuration() time.Duration {
+	d, _ := time.ParseDuration(c.LowPowerAfter)
+	return d
+}
+
+// BatchIntervalDuration 返回扫描结果的最长攒批时间
+func (c BLEScanConfig) BatchIntervalDuration() time.Duration {
+	d, _ := time.ParseDuration(c.BatchInterval)
+	return d
+}
+
+// TagIntervalDuration 返回同一标签两次发布的最小间隔，配置为 0 时返回 0
+func (c BLEScanConfig) TagIntervalDuration() time.Duration {
+	d, _ := time.ParseDuration(c.TagInterval)
+	return d
+}
+
+// TimeoutDuration 返回标签超时未扫描到视为离开的时间
+func (c BLEPresenceConfig) TimeoutDuration() time.Duration {
+	d, _ := time.ParseDuration(c.Timeout)
+	return d
+}
+
+// SleepAfterDuration 返回模块休眠前的空闲时间，配置为 0 时返回 0
+func (c BLEPowerConfig) SleepAfterDuration() time.Duration {
+	d, _ := time.ParseDuration(c.SleepAfter)
+	return d
+}
@@ -0,0 +1,70 @@
+package driver
+
+import (
+	"device-ble/cmd/config"
+	"device-ble/pkg/ble"
+	"fmt"
+)
+
+// accessController 支持中心设备访问控制的 BLE 控制器
+type accessController interface {
+	ApplyAccessList(list ble.AccessList) error
+	AccessControl() (ble.AccessList, bool)
+}
+
+// accessListPath 返回访问控制名单文件路径
+func (d *Driver) accessListPath() string {
+	if d.serviceConfig != nil {
+		return d.serviceConfig.BleUserConfig.AccessListFile
+	}
+	return config.DefaultAccessListFile
+}
+
+// loadAccessList 从名单文件加载访问控制名单并下发，文件不存在时不限制
+func (d *Driver) loadAccessList(ac accessController, path string) error {
+	list, ok, err := ble.LoadAccessList(path)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		d.logger.Debugf("访问控制名单文件 %s 不存在，不限制中心设备连接", path)
+		return nil
+	}
+	d.logger.Infof("已加载访问控制名单: %s", path)
+	return ac.ApplyAccessList(list)
+}
+
+// handleSetAccessList 下发访问控制名单并写入名单文件
+func (d *Driver) handleSetAccessList(objValue interface{}, ac accessController) error {
+	var list ble.AccessList
+	if err := decodeObject(objValue, &list); err != nil {
+		return fmt.Errorf("访问控制名单格式错误: %v", err)
+	}
+	if err := ac.ApplyAccessList(list); err != nil {
+		return err
+	}
+	// 保存规范化后的名单
+	current, _ := ac.AccessControl()
+	if err := ble.SaveAccessList(d.accessListPath(), current); err != nil {
+		return fmt.Errorf("保存访问控制名单失败: %w", err)
+	}
+	return nil
+}
+
+// accessListToObject 将访问控制名单转换为 Object 类型读数所需的结构
+func accessListToObject(ac accessController) map[string]interface{} {
+	list, filtered := ac.AccessControl()
+	enforcement := "service"
+	if filtered {
+		enforcement = "module"
+	}
+	addresses := make([]interface{}, 0, len(list.Addresses))
+	for _, addr := range list.Addresses {
+		addresses = append(addresses, addr)
+	}
+	return map[string]interface{}{
+		"mode":        list.Mode,
+		"addresses":   addresses,
+		"enforcement": enforcement,
+	}
+}
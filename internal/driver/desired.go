@@ -0,0 +1,69 @@
+package driver
+
+import (
+	"device-ble/pkg/ble"
+	"fmt"
+	"path/filepath"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/models"
+	"github.com/spf13/cast"
+)
+
+// 设备协议属性中可选的期望配置项，仅在期望配置文件不存在时使用
+const (
+	protocolBleName     = "bleName"     // 设备名称
+	protocolTxPower     = "txPower"     // 发射功率（dBm）
+	protocolAdvInterval = "advInterval" // 广播间隔（ms）
+)
+
+// desiredConfigPath 返回设备期望配置的持久化文件路径
+func desiredConfigPath(dir, deviceName string) string {
+	return filepath.Join(dir, deviceName+".json")
+}
+
+// loadDesiredConfig 读取设备的期望配置：优先使用持久化文件，其次使用协议属性，最后使用默认配置。
+func (d *Driver) loadDesiredConfig(path string, protocols map[string]models.ProtocolProperties) (ble.DesiredConfig, error) {
+	cfg, ok, err := ble.LoadDesiredConfig(path)
+	if err != nil {
+		return ble.DesiredConfig{}, err
+	}
+	if ok {
+		d.logger.Infof("已加载期望配置: %s", path)
+		return cfg, nil
+	}
+
+	cfg = ble.DefaultDesiredConfig()
+	for _, protocol := range protocols {
+		if name, ok := protocol[protocolBleName]; ok {
+			cfg.Name = fmt.Sprintf("%v", name)
+		}
+		if v, ok := protocol[protocolTxPower]; ok {
+			txPower, err := cast.ToInt8E(v)
+			if err != nil {
+				return ble.DesiredConfig{}, fmt.Errorf("invalid %s: %v", protocolTxPower, err)
+			}
+			cfg.TxPower = &txPower
+		}
+		if v, ok := protocol[protocolAdvInterval]; ok {
+			interval, err := cast.ToIntE(v)
+			if err != nil {
+				return ble.DesiredConfig{}, fmt.Errorf("invalid %s: %v", protocolAdvInterval, err)
+			}
+			cfg.AdvParams = &ble.AdvParams{MinInterval: interval, MaxInterval: interval}
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		return ble.DesiredConfig{}, err
+	}
+	return cfg, nil
+}
+
+// updateDesired 写入命令下发成功后同步修改期望配置，持久化失败只记录日志
+func (d *Driver) updateDesired(m *bleModule, fn func(cfg *ble.DesiredConfig)) {
+	if m.reconciler == nil {
+		return
+	}
+	if err := m.reconciler.Update(fn); err != nil {
+		d.logger.Errorf("更新期望配置失败: %v", err)
+	}
+}
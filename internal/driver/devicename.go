@@ -0,0 +1,65 @@
+package driver
+
+import (
+	"device-ble/pkg/ble"
+	"fmt"
+	"os"
+)
+
+// moduleNameVars 返回名称模板的占位符取值，首次使用时查询模块地址与主机名。
+// 地址查询需在模块完成协议栈初始化之后进行。
+func (d *Driver) moduleNameVars(m *bleModule, site string) ble.NameVars {
+	m.nameMu.Lock()
+	defer m.nameMu.Unlock()
+	if m.nameVars.MAC != "" {
+		m.nameVars.Site = site
+		return m.nameVars
+	}
+	vars := ble.NameVars{Device: m.name, Site: site}
+	if raw, err := m.controller.QueryAddress(); err != nil {
+		d.logger.Warnf("查询BLE模块 %s 的地址失败，名称模板中的 {mac} 不可用: %v", m.name, err)
+	} else if addr, err := ble.ParseAddressResponse(raw); err != nil {
+		d.logger.Warnf("解析BLE模块 %s 的地址失败，名称模板中的 {mac} 不可用: %v", m.name, err)
+	} else {
+		vars.MAC = addr
+	}
+	if host, err := os.Hostname(); err == nil {
+		vars.Hostname = host
+	}
+	m.nameVars = vars
+	return vars
+}
+
+// applyNameTemplate 按配置的名称模板生成并设置广播名称，成功后写入期望配置；
+// 失败时保留期望配置中的名称，不影响初始化
+func (d *Driver) applyNameTemplate(m *bleModule, bc *ble.BLEController, desired *ble.DesiredConfig) {
+	name, err := d.renderModuleName(m, "")
+	if err != nil {
+		d.logger.Errorf("设备 %s 按模板生成广播名称失败，使用期望配置中的名称 %s: %v", m.name, desired.Name, err)
+		return
+	}
+	if name == desired.Name {
+		return
+	}
+	if err := bc.SetDeviceName(name); err != nil {
+		d.logger.Errorf("设备 %s 设置模板生成的广播名称 %s 失败，使用期望配置中的名称 %s: %v", m.name, name, desired.Name, err)
+		return
+	}
+	d.logger.Infof("设备 %s 按模板生成广播名称: %s", m.name, name)
+	desired.Name = name
+}
+
+// renderModuleName 按模板生成模块的广播名称，template 为空时使用配置的名称模板；不含占位符的模板即为名称本身
+func (d *Driver) renderModuleName(m *bleModule, template string) (string, error) {
+	var site string
+	if d.serviceConfig != nil {
+		if template == "" {
+			template = d.serviceConfig.BleUserConfig.NameTemplate
+		}
+		site = d.serviceConfig.BleUserConfig.SiteLabel
+	}
+	if template == "" {
+		return "", fmt.Errorf("未指定广播名称，且未配置名称模板")
+	}
+	return ble.RenderDeviceName(template, d.moduleNameVars(m, site))
+}
@@ -24,10 +24,8 @@ package driver
 import (
 	"device-ble/cmd/config"
 	internalif "device-ble/internal/interfaces"
-	"log"
 
 	"device-ble/pkg/ble"
-	"device-ble/pkg/dataparse"
 	"device-ble/pkg/mqttbus"
 	"device-ble/pkg/uart"
 	errorDefault "errors"
@@ -53,13 +51,25 @@ type Driver struct {
 	asyncCh  chan<- *dsModels.AsyncValues
 	deviceCh chan<- []dsModels.DiscoveredDevice
 
-	// 核心组件
-	BleController    internalif.BLEController
+	// 核心组件：各设备的 BLE 模块共用一个消息总线客户端
+	pool             *ControllerPool
 	MessageBusClient internalif.MessageBusClient
+	busMu            sync.Mutex
 
-	// 业务服务
-	CommandService *CommandService
-	AgentService   *AgentService
+	// 标签在场判定，各模块的扫描结果合并判定
+	presence     *ble.PresenceEngine
+	presenceCfg  config.BLEPresenceConfig
+	presenceTags map[string]bool // 允许创建虚拟设备的标签，为空时以扫描过滤条件为准
+	presenceMu   sync.Mutex
+	presenceStop chan struct{}
+	presenceDone chan struct{}
+
+	// 可读特征值的最新内容，特征值 UUID -> 内容
+	readableValues map[string]string
+	readableMu     sync.Mutex
+
+	// 自定义配置
+	serviceConfig *config.MQTTUserClientConfig
 
 	// 内部状态
 	commandResponses sync.Map
@@ -75,34 +85,39 @@ func (d *Driver) Initialize(sdk edgexif.DeviceServiceSDK) error {
 	d.logger = sdk.LoggingClient()
 	d.asyncCh = sdk.AsyncValuesChannel()
 	d.deviceCh = sdk.DiscoveredDeviceChannel()
+	d.pool = NewControllerPool(d.logger)
 	return nil
 }
 
-// HandleUpAgentCallback 处理上行透明代理数据回调。
-func (d *Driver) HandleUpAgentCallback(data string) {
-	if d.AgentService != nil {
-		d.AgentService.HandleAgentData(data)
-	}
-}
-
-// HandleUpCommandCallback 处理上行命令回调。
-func (d *Driver) HandleUpCommandCallback(cmd string) {
-	if d.CommandService != nil {
-		d.CommandService.HandleCommand(cmd)
-	}
-}
-
 // Start 启动设备服务。
 func (d *Driver) Start() error {
 	return nil
 }
 
-// agentDown 处理蓝牙透明代理下行数据。
+// publishConnectionEvent 将BLE连接生命周期事件发布到消息总线。
+func (d *Driver) publishConnectionEvent(topic string, event internalif.BLEConnectionEvent) {
+	if d.MessageBusClient == nil {
+		return
+	}
+	if err := d.MessageBusClient.Publish(topic, event); err != nil {
+		d.logger.Errorf("【连接事件】发布 %s 事件失败 ❌: %v", event.Type, err)
+		return
+	}
+	d.logger.Debugf("【连接事件】发布 %s 事件成功 ✔, conn=%d", event.Type, event.Connection.ConnID)
+}
+
+// agentDown 处理蓝牙透明代理下行数据，由控制器池按中心设备选择模块发送。
 func (d *Driver) agentDown(topic string, envelope types.MessageEnvelope) error {
-	if err := dataparse.SendToBlE(d.BleController, envelope); err != nil {
-		d.logger.Infof("【透明代理（↓）】下行数据发送失败 ❌, err: %v", err) // 记录成功日志
+	if d.pool.connectionCount() == 0 && d.pool.connectionsTracked() {
+		d.logger.Warnf("【透明代理（↓）】当前无已连接的中心设备，丢弃下行数据")
+		return nil
+	}
+	n, err := d.pool.broadcast(envelope)
+	if err != nil {
+		d.logger.Errorf("【透明代理（↓）】下行数据发送失败 ❌, err: %v", err)
+		return nil
 	}
-	d.logger.Infof("【透明代理（↓）】下行数据发送成功 ✔, err: %v") // 记录成功日志
+	d.logger.Infof("【透明代理（↓）】下行数据发送成功 ✔, 路线数: %d", n)
 	return nil
 }
 
@@ -114,6 +129,13 @@ func (s *Driver) Discover() error {
 
 // ValidateDevice 校验设备协议属性。
 func (s *Driver) ValidateDevice(device models.Device) error {
+	// 标签虚拟设备由在场判定创建，只需要标签 MAC 地址
+	if mac, ok := tagDeviceMAC(device.Protocols); ok {
+		if mac == "" {
+			return errorDefault.New("Missing 'mac' information")
+		}
+		return nil
+	}
 
 	protocol, ok := device.Protocols["UART"]
 	if !ok {
@@ -134,6 +156,12 @@ func (s *Driver) ValidateDevice(device models.Device) error {
 		return errorDefault.New("baudRate must not empty")
 	}
 
+	if dialect, ok := protocol["dialect"]; ok {
+		if _, err := ble.LookupDialect(fmt.Sprintf("%v", dialect)); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -157,19 +185,11 @@ func (d *Driver) Stop(force bool) error {
 		}
 	}
 
-	// 关闭BLE控制器和串口
-	if d.BleController != nil {
-		if closer, ok := d.BleController.(interface{ Close() error }); ok {
-			err := closer.Close()
-			if err != nil {
-				d.logger.Errorf("BLE控制器关闭失败: %v", err)
-			} else {
-				d.logger.Debug("BLE控制器已关闭")
-			}
-		} else {
-			d.logger.Debug("BLE控制器不支持关闭操作")
-		}
+	// 停止各模块的配置校验，关闭BLE控制器和串口
+	if d.pool != nil {
+		d.pool.close()
 	}
+	d.stopPresence()
 
 	if d.logger != nil {
 		d.logger.Info("BLE代理服务已停止")
@@ -182,77 +202,204 @@ func (d *Driver) Stop(force bool) error {
 func (d *Driver) AddDevice(deviceName string, protocols map[string]models.ProtocolProperties, adminState models.AdminState) error {
 	d.logger.Debugf("新设备已添加: %s", deviceName)
 
+	// 标签虚拟设备没有对应的BLE模块
+	if mac, ok := tagDeviceMAC(protocols); ok {
+		d.logger.Debugf("标签 %s 的虚拟设备 %s 已添加", mac, deviceName)
+		return nil
+	}
+
 	// 获取 UART 配置信息
 	// 通过结构体字段访问 Protocols
 	var deviceLocation string
 	var baudRate int
+	var dialectName string
 
 	for i, protocol := range protocols {
 		deviceLocation = fmt.Sprintf("%v", protocol["deviceLocation"])
 		baudRate, _ = cast.ToIntE(protocol["baudRate"])
+		dialectName, _ = cast.ToStringE(protocol["dialect"])
 		d.logger.Debugf("Driver.HandleReadCommands(): protocol = %v, device location = %v, baud rate = %v timeout=%v", i, deviceLocation, baudRate)
 	}
 
+	// 加载自定义MQTT及BLE配置
+	cfg, err := config.LoadConfig("./res/configuration.yaml")
+	if err != nil {
+		return fmt.Errorf("设备 %s 获取自定义配置失败: %w", deviceName, err)
+	}
+	d.logger.Debugf("自定义Mqtt服务配置: %v\n", cfg)
+	d.serviceConfig = cfg
+	// 初始化消息总线，多个设备共用同一个客户端
+	if err := d.ensureMessageBus(cfg); err != nil {
+		return fmt.Errorf("设备 %s 创建 MessageBusClient 失败: %w", deviceName, err)
+	}
+
+	// 同名设备重新添加时先释放旧模块占用的串口
+	if old := d.pool.remove(deviceName); old != nil {
+		d.logger.Infof("设备 %s 已存在，释放旧的BLE模块", deviceName)
+		if err := old.close(); err != nil {
+			d.logger.Errorf("释放设备 %s 旧的BLE模块失败: %v", deviceName, err)
+		}
+	}
+
+	// 读取设备期望配置，波特率曾被修改过时按修改后的波特率打开串口
+	desiredPath := desiredConfigPath(cfg.BleUserConfig.DesiredStateDir, deviceName)
+	desired, err := d.loadDesiredConfig(desiredPath, protocols)
+	if err != nil {
+		return fmt.Errorf("设备 %s 读取期望配置失败: %w", deviceName, err)
+	}
+	if desired.Baud != 0 {
+		baudRate = int(desired.Baud)
+	}
+	// 由 EdgeX 读数维护内容的特征值加入 GATT 服务表
+	if n := addReadableCharacteristics(&desired, cfg.BleUserConfig.Readable.Characteristics); n > 0 {
+		d.logger.Infof("设备 %s 的 GATT 服务表新增 %d 个可读特征值", deviceName, n)
+	}
+
+	// 按设备协议属性选择模块 AT 方言，未配置时使用 Quectel
+	dialect, err := ble.LookupDialect(dialectName)
+	if err != nil {
+		return fmt.Errorf("设备 %s BLE模块方言配置错误: %w", deviceName, err)
+	}
+
 	serialPort, err := uart.NewSerialPort(serial.Config{
 		Name:        deviceLocation,
 		Baud:        baudRate,
 		ReadTimeout: time.Duration(10) * time.Millisecond,
 	}, d.logger)
 	if err != nil {
-		log.Fatal("创建串口实例失败:", err)
+		return fmt.Errorf("设备 %s 创建串口实例失败: %w", deviceName, err)
 	}
-	// 初始化串口队列，注册Driver回调
+	// 初始化串口队列，上行数据交给该设备的模块处理
+	module := &bleModule{name: deviceName}
 	serialQueue := uart.NewSerialQueue(
 		serialPort,
 		d.logger,
-		func(cmd string) { d.HandleUpCommandCallback(cmd) },
-		func(data string) { d.HandleUpAgentCallback(data) },
+		func(cmd string) { module.markActivity(); module.handleCommand(module.resolveConn(), cmd) },
+		func(data string) { module.markActivity(); module.handleAgentData(module.resolveConn(), data) },
 		5,
 	)
 
-	// 初始化BLE控制器
-	bleController := ble.NewBLEController(serialPort, serialQueue, d.logger)
-
-	// 初始化BLE设备为外围设备模式
-	if err := bleController.InitializeAsPeripheral(); err != nil {
-		log.Fatal("BLE设备初始化失败:", err)
+	// 初始化BLE控制器，注册连接生命周期事件
+	bleController := ble.NewBLEController(serialPort, serialQueue, d.logger, dialect)
+	bleController.SetAutoReadvertise(cfg.BleUserConfig.RestartAdvertisingOnDisconnect)
+	bleController.SetDefaultMTU(cfg.BleUserConfig.DefaultMTU)
+	bleController.SetAutoConnParams(cfg.BleUserConfig.AutoConnParams)
+	bleController.SetInboundHandlers(module.handleCommand, module.handleAgentData)
+	// 开启标签扫描时以多角色初始化，扫描与外围设备功能同时运行
+	bleController.SetMultiRole(cfg.BleUserConfig.Scan.Enabled)
+	bleController.SetConnectionEventHandler(func(event internalif.BLEConnectionEvent) {
+		d.publishConnectionEvent(cfg.BleUserConfig.ConnectionEventTopic, event)
+	})
+
+	module.controller = bleController
+
+	// 按期望配置初始化BLE设备为外围设备模式，链路安全与访问控制名单在开始广播之前下发，
+	// 任一步骤失败时释放串口并向 SDK 报告错误，不以未生效的安全配置对外广播。
+	// 配置了名称模板时，协议栈初始化之后才能查询模块地址，同样在开始广播之前按模板改名，区分各网关
+	// 链路安全与模块的连接过滤名单随模块复位丢失，在每次初始化（启动、复位后重建、SetPeripheralInit）开始广播之前下发；
+	// 访问控制名单每次从名单文件加载，SetAccessList 修改名单时同步写入该文件
+	sec := cfg.BleUserConfig.Security
+	secCfg := ble.SecurityConfig{Mode: sec.Mode, Bonding: sec.Bonding, RequireEncryption: sec.RequireEncryption}
+	bleController.SetInitSetup(func() error {
+		if secCfg.Mode != "" {
+			if err := d.applySecurity(bleController, secCfg); err != nil {
+				return fmt.Errorf("BLE链路安全配置失败: %w", err)
+			}
+		}
+		if err := d.loadAccessList(bleController, cfg.BleUserConfig.AccessListFile); err != nil {
+			return fmt.Errorf("加载访问控制名单失败: %w", err)
+		}
+		return nil
+	})
+	setup := func() error {
+		if cfg.BleUserConfig.NameTemplate != "" {
+			d.applyNameTemplate(module, bleController, &desired)
+		}
+		return nil
+	}
+	if err := bleController.InitializeWithSetup(desired, setup); err != nil {
+		if closeErr := bleController.Close(); closeErr != nil {
+			d.logger.Errorf("释放串口失败: %v", closeErr)
+		}
+		return fmt.Errorf("设备 %s BLE模块初始化失败: %w", deviceName, err)
 	}
 
-	// 加载自定义MQTT配置
-	cfg, err := config.LoadConfig("./res/configuration.yaml")
-	if err != nil {
-		log.Fatal("MessageBusClient 获取自定义配置失败:", err)
+	// 加载广播时间表，在窗口边界自动开始或停止广播
+	if err := d.loadAdvertisingSchedule(bleController, cfg.BleUserConfig.AdvertisingScheduleFile); err != nil {
+		d.logger.Errorf("加载广播时间表失败: %v", err)
 	}
-	d.logger.Debugf("自定义Mqtt服务配置: %v\n", cfg)
-	// 初始化消息总线
-	mqttClient, err := mqttbus.NewEdgexMessageBusClient(cfg, d.logger)
-	if err != nil {
-		log.Fatal("MessageBusClient 创建失败:", err)
+	bleController.StartAdvertisingScheduler(func(t ble.AdvertisingTransition) {
+		d.publishAdvertisingTransition(cfg.BleUserConfig.AdvertisingEventTopic, t)
+	})
+
+	// 启动模块配置校验：启动时、模块复位后以及周期性地恢复与期望不一致的配置
+	reconciler := ble.NewReconciler(bleController, desired, desiredPath, cfg.BleUserConfig.ReconcileEvery())
+	reconciler.Start()
+
+	// 周期查询各连接的信号强度，以 RSSI 读数推送
+	bleController.StartLinkMonitor(cfg.BleUserConfig.LinkQualityEvery(), func(samples []ble.LinkQuality) {
+		d.pushLinkQuality(deviceName, samples)
+	})
+
+	// 持续扫描标签，扫描结果按批发布
+	if cfg.BleUserConfig.Presence.Enabled {
+		if err := d.ensurePresence(cfg.BleUserConfig.Presence); err != nil {
+			d.logger.Errorf("创建标签在场判定失败: %v", err)
+		}
+	}
+	if cfg.BleUserConfig.Scan.Enabled {
+		if err := d.startScan(deviceName, bleController, cfg.BleUserConfig.Scan); err != nil {
+			d.logger.Errorf("设备 %s 开始标签扫描失败: %v", deviceName, err)
+		}
 	}
 
-	// 初始化业务服务
-	commandService := &CommandService{
+	// 空闲时放慢广播或使模块休眠
+	power := cfg.BleUserConfig.Power
+	bleController.StartIdleMonitor(ble.IdlePolicy{
+		LowPowerAfter:       power.LowPowerAfterDuration(),
+		LowPowerAdvInterval: power.LowPowerAdvInterval,
+		SleepAfter:          power.SleepAfterDuration(),
+	})
+
+	// 初始化业务服务，命令响应经收到命令的模块发回
+	module.reconciler = reconciler
+	module.commands = &CommandService{
 		Logger:           d.logger,
-		MessageBusClient: mqttClient,
+		MessageBusClient: d.MessageBusClient,
 		BleController:    bleController,
+		Indicate:         cfg.BleUserConfig.IndicateCommandResponses,
 	}
-	agentService := &AgentService{
+	module.agent = &AgentService{
 		Logger:           d.logger,
-		MessageBusClient: mqttClient,
+		MessageBusClient: d.MessageBusClient,
+		DeviceName:       deviceName,
 	}
 
-	// 注入依赖到Driver
-	d.BleController = bleController
-	d.MessageBusClient = mqttClient
-	d.CommandService = commandService
-	d.AgentService = agentService
+	// 加入控制器池，由池按中心设备分配下行传输，并周期检查各模块是否仍可响应
+	d.pool.add(module)
+	d.pool.startHealthCheck(poolProbeInterval)
 
-	if d.BleController == nil || d.MessageBusClient == nil {
-		return fmt.Errorf("依赖未注入")
+	// 写入已收到的可读特征值内容，加入控制器池之后收到的读数统一写入各模块
+	d.applyReadableValues(deviceName, bleController)
+	return nil
+}
+
+// ensureMessageBus 首次添加设备时创建消息总线客户端并订阅下行数据与 EdgeX 事件，之后的设备复用该客户端
+func (d *Driver) ensureMessageBus(cfg *config.MQTTUserClientConfig) error {
+	d.busMu.Lock()
+	defer d.busMu.Unlock()
+	if d.MessageBusClient != nil {
+		return nil
+	}
+	mqttClient, err := mqttbus.NewEdgexMessageBusClient(cfg, d.logger)
+	if err != nil {
+		return err
 	}
+	d.MessageBusClient = mqttClient
 	if err := d.MessageBusClient.Subscribe(TopicBLEDown, d.agentDown); err != nil { // 转发下行数据
 		d.logger.Errorf("【透明代理（↓）】 订阅下行总线失败 err: %v", err)
 	}
+	d.subscribeReadable(cfg.BleUserConfig.Readable)
 	return nil
 }
 
@@ -263,9 +410,14 @@ func (d *Driver) UpdateDevice(deviceName string, protocols map[string]models.Pro
 	return nil
 }
 
-// RemoveDevice 移除设备回调函数。
+// RemoveDevice 移除设备回调函数，释放该设备的BLE模块。
 func (d *Driver) RemoveDevice(deviceName string, protocols map[string]models.ProtocolProperties) error {
 	d.logger.Debugf("设备 %s 已移除", deviceName)
 
+	if m := d.pool.remove(deviceName); m != nil {
+		if err := m.close(); err != nil {
+			return fmt.Errorf("设备 %s 释放BLE模块失败: %w", deviceName, err)
+		}
+	}
 	return nil
 }
@@ -0,0 +1,54 @@
+package driver
+
+import (
+	"device-ble/cmd/config"
+	"device-ble/pkg/ble"
+	"fmt"
+	"strings"
+)
+
+// firmwareUpgrader 支持串口固件升级的 BLE 控制器
+type firmwareUpgrader interface {
+	UpgradeFirmware(img *ble.FirmwareImage, opts ble.UpgradeOptions, progress func(ble.UpgradeProgress)) error
+	Upgrading() bool
+}
+
+// handleUpgradeFirmware 校验固件镜像后在后台执行升级，进度发布到消息总线。
+// 镜像无效或已有升级在进行时直接返回错误。升级协议尚未经模块文档确认，未开启 firmwareUpgrade 时拒绝执行。
+func (d *Driver) handleUpgradeFirmware(path string, fu firmwareUpgrader) error {
+	if d.serviceConfig == nil || !d.serviceConfig.BleUserConfig.FirmwareUpgrade {
+		return fmt.Errorf("固件升级未开启，需在配置中设置 firmwareUpgrade")
+	}
+	img, err := ble.LoadFirmwareImage(strings.TrimSpace(path))
+	if err != nil {
+		return fmt.Errorf("固件镜像校验失败: %w", err)
+	}
+	if fu.Upgrading() {
+		return ble.ErrUpgradeInProgress
+	}
+	topic := config.DefaultFirmwareProgressTopic
+	if d.serviceConfig != nil {
+		topic = d.serviceConfig.BleUserConfig.FirmwareProgressTopic
+	}
+	d.logger.Infof("📦 开始升级模块固件: %s -> %s", path, img.Version)
+	go func() {
+		if err := fu.UpgradeFirmware(img, ble.UpgradeOptions{}, func(p ble.UpgradeProgress) {
+			d.publishUpgradeProgress(topic, p)
+		}); err != nil {
+			d.logger.Errorf("模块固件升级失败: %v", err)
+		}
+	}()
+	return nil
+}
+
+// publishUpgradeProgress 发布固件升级进度
+func (d *Driver) publishUpgradeProgress(topic string, p ble.UpgradeProgress) {
+	if d.MessageBusClient == nil {
+		return
+	}
+	if err := d.MessageBusClient.Publish(topic, p); err != nil {
+		d.logger.Errorf("【固件升级】发布 %s 进度失败 ❌: %v", p.Stage, err)
+		return
+	}
+	d.logger.Debugf("【固件升级】%s %d%% (%d/%d)", p.Stage, p.Percent, p.Sent, p.Total)
+}
@@ -1,9 +1,11 @@
 package driver
 
 import (
+	"device-ble/cmd/config"
 	"device-ble/internal/interfaces"
+	"encoding/json"
 	"fmt"
-	"log"
+	"time"
 
 	blecommand "device-ble/pkg/ble"
 
@@ -28,6 +30,18 @@ func (d *Driver) HandleReadCommands(
 	d.logger.Debugf("协议信息: %+v", protocols)
 	d.logger.Debugf("读取请求列表: %+v", reqs)
 
+	// 标签虚拟设备读取在场状态
+	if mac, ok := tagDeviceMAC(protocols); ok {
+		return d.handleTagRead(mac, reqs)
+	}
+
+	// 每个设备对应控制器池中的一个模块
+	module, err := d.pool.get(deviceName)
+	if err != nil {
+		return nil, err
+	}
+	bc := module.controller
+
 	// 初始化返回结果列表
 	responses = make([]*dsModels.CommandValue, 0, len(reqs))
 
@@ -39,18 +53,124 @@ func (d *Driver) HandleReadCommands(
 
 		switch req.DeviceResourceName {
 		case "GetVERSION":
-			res, err := d.BleController.SendSingleWithResponse(blecommand.GetVersion())
-			if err != nil {
-				return nil, err
+			fc, ok := bc.(firmwareController)
+			if !ok {
+				return nil, fmt.Errorf("BLE控制器不支持固件版本识别")
 			}
-			cv, err = dsModels.NewCommandValue(req.DeviceResourceName, common.ValueTypeString, res)
+			fw, detectErr := fc.DetectFirmware()
+			if detectErr != nil {
+				return nil, detectErr
+			}
+			cv, err = dsModels.NewCommandValue(req.DeviceResourceName, common.ValueTypeObject, firmwareToObject(fw))
 		case "GetBLEADDR":
-			res, err := d.BleController.SendSingleWithResponse(blecommand.QueryAddress())
+			res, queryErr := bc.QueryAddress()
+			if queryErr != nil {
+				return nil, queryErr
+			}
+			cv, err = dsModels.NewCommandValue(req.DeviceResourceName, common.ValueTypeString, res)
+		case "GetDeviceName", "GetTxPower", "GetAdvParams", "GetBaud", "GetRole", "GetAdvertising":
+			rc, ok := bc.(readbackController)
+			if !ok {
+				return nil, fmt.Errorf("BLE控制器不支持配置回读")
+			}
+			cv, err = readbackValue(req.DeviceResourceName, rc)
 			if err != nil {
 				return nil, err
 			}
-			cv, err = dsModels.NewCommandValue(req.DeviceResourceName, common.ValueTypeString, res)
-
+		case "GetDesiredGATTTable":
+			services, tableErr := module.desiredGATTTable()
+			if tableErr != nil {
+				return nil, tableErr
+			}
+			cv, err = dsModels.NewCommandValue(req.DeviceResourceName, common.ValueTypeObject, gattTableToObject(services))
+		case "GetModulePool":
+			cv, err = dsModels.NewCommandValue(req.DeviceResourceName, common.ValueTypeObject, poolStatusToObject(d.pool.status()))
+		case "GetConnectionCount":
+			cv, err = dsModels.NewCommandValue(req.DeviceResourceName, common.ValueTypeInt8, int8(len(bc.Connections())))
+		case "GetBondedPeers":
+			sc, ok := bc.(securityController)
+			if !ok {
+				return nil, fmt.Errorf("BLE控制器不支持绑定管理")
+			}
+			peers, queryErr := sc.BondedPeers()
+			if queryErr != nil {
+				return nil, queryErr
+			}
+			cv, err = dsModels.NewCommandValue(req.DeviceResourceName, common.ValueTypeObject, map[string]interface{}{
+				"count": len(peers),
+				"peers": peers,
+			})
+		case "GetAccessList":
+			ac, ok := bc.(accessController)
+			if !ok {
+				return nil, fmt.Errorf("BLE控制器不支持访问控制")
+			}
+			cv, err = dsModels.NewCommandValue(req.DeviceResourceName, common.ValueTypeObject, accessListToObject(ac))
+		case "GetAdvertisingSchedule":
+			as, ok := bc.(advertisingScheduler)
+			if !ok {
+				return nil, fmt.Errorf("BLE控制器不支持广播时间表")
+			}
+			cv, err = dsModels.NewCommandValue(req.DeviceResourceName, common.ValueTypeObject, advertisingScheduleToObject(as))
+		case "GetScanStatus":
+			sc, ok := bc.(scanner)
+			if !ok {
+				return nil, fmt.Errorf("BLE控制器不支持标签扫描")
+			}
+			cv, err = dsModels.NewCommandValue(req.DeviceResourceName, common.ValueTypeObject, scanStatsToObject(sc.ScanStats()))
+		case "GetTrackedTags":
+			obj, tagErr := d.trackedTagsToObject()
+			if tagErr != nil {
+				return nil, tagErr
+			}
+			cv, err = dsModels.NewCommandValue(req.DeviceResourceName, common.ValueTypeObject, obj)
+		case "GetReadableCharacteristics":
+			cvc, ok := bc.(charValueController)
+			if !ok {
+				return nil, fmt.Errorf("BLE控制器不支持可读特征值")
+			}
+			var mappings []config.BLEReadableMapping
+			if d.serviceConfig != nil {
+				mappings = d.serviceConfig.BleUserConfig.Readable.Characteristics
+			}
+			cv, err = dsModels.NewCommandValue(req.DeviceResourceName, common.ValueTypeObject, readableToObject(mappings, cvc))
+		case "GetLinkQuality":
+			lm, ok := bc.(linkMonitor)
+			if !ok {
+				return nil, fmt.Errorf("BLE控制器不支持链路质量查询")
+			}
+			cv, err = dsModels.NewCommandValue(req.DeviceResourceName, common.ValueTypeObject, linkQualityToObject(lm.LinkQualities()))
+		case "GetPowerState":
+			pc, ok := bc.(powerController)
+			if !ok {
+				return nil, fmt.Errorf("BLE控制器不支持功耗管理")
+			}
+			cv, err = dsModels.NewCommandValue(req.DeviceResourceName, common.ValueTypeObject, powerStateToObject(pc.PowerState()))
+		case resourceRSSI:
+			return nil, fmt.Errorf("%s 仅以异步读数推送，请读取 GetLinkQuality 获取各连接的最新值", resourceRSSI)
+		case "GetConnections":
+			cv, err = dsModels.NewCommandValue(req.DeviceResourceName, common.ValueTypeObject, connectionsToObject(bc.Connections()))
+		case "GetModuleState":
+			sr, ok := bc.(stateReporter)
+			if !ok {
+				return nil, fmt.Errorf("BLE控制器不支持模块状态查询")
+			}
+			state := map[string]interface{}{
+				"state": string(sr.State()),
+				"since": sr.StateSince().UnixNano(),
+			}
+			cv, err = dsModels.NewCommandValue(req.DeviceResourceName, common.ValueTypeObject, state)
+		case "GetCapabilities":
+			fc, ok := bc.(firmwareController)
+			if !ok {
+				return nil, fmt.Errorf("BLE控制器不支持固件能力查询")
+			}
+			cv, err = dsModels.NewCommandValue(req.DeviceResourceName, common.ValueTypeObject, capabilitiesToObject(fc))
+		default:
+			return nil, fmt.Errorf("不支持的读取资源: %s", req.DeviceResourceName)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("生成读数 %s 失败: %w", req.DeviceResourceName, err)
 		}
 		responses = append(responses, cv)
 	}
@@ -58,13 +178,82 @@ func (d *Driver) HandleReadCommands(
 	return responses, nil
 }
 
+// stateReporter 可以报告模块生命周期状态的 BLE 控制器
+type stateReporter interface {
+	State() blecommand.ModuleState
+	StateSince() time.Time
+}
+
+// firmwareController 支持固件版本识别与能力查询的 BLE 控制器
+type firmwareController interface {
+	DetectFirmware() (blecommand.FirmwareVersion, error)
+	Firmware() (blecommand.FirmwareVersion, bool)
+	Capabilities() (blecommand.Capabilities, bool)
+}
+
+// capabilitiesToObject 将固件版本与能力转换为 Object 类型读数所需的结构，未识别的部分为 nil
+func capabilitiesToObject(fc firmwareController) map[string]interface{} {
+	obj := map[string]interface{}{"firmware": nil, "capabilities": nil}
+	if fw, ok := fc.Firmware(); ok {
+		obj["firmware"] = fw
+	}
+	if caps, ok := fc.Capabilities(); ok {
+		obj["capabilities"] = caps
+	}
+	// 转换为通用 map，保证 Object 读数可以被序列化为 JSON
+	var out map[string]interface{}
+	if err := decodeObject(obj, &out); err != nil {
+		return obj
+	}
+	return out
+}
+
+// firmwareToObject 将解析后的固件版本转换为 Object 类型读数所需的结构
+func firmwareToObject(fw blecommand.FirmwareVersion) map[string]interface{} {
+	return map[string]interface{}{
+		"raw":     fw.Raw,
+		"model":   fw.Model,
+		"version": fw.String(),
+		"major":   fw.Major,
+		"minor":   fw.Minor,
+		"patch":   fw.Patch,
+	}
+}
+
+// connectionsToObject 将连接列表转换为 Object 类型读数所需的结构
+func connectionsToObject(conns []interfaces.BLEConnection) map[string]interface{} {
+	list := make([]interface{}, 0, len(conns))
+	for _, conn := range conns {
+		list = append(list, map[string]interface{}{
+			"connId":      conn.ConnID,
+			"peerAddr":    conn.PeerAddr,
+			"connectedAt": conn.ConnectedAt.Format(time.RFC3339),
+			"intervalMs":  conn.IntervalMs,
+			"latency":     conn.Latency,
+			"timeoutMs":   conn.TimeoutMs,
+			"rssi":        conn.RSSI,
+		})
+	}
+	return map[string]interface{}{
+		"count":       len(conns),
+		"connections": list,
+	}
+}
+
 // HandleWriteCommands 处理写入命令。
 func (d *Driver) HandleWriteCommands(deviceName string, protocols map[string]models.ProtocolProperties, reqs []dsModels.CommandRequest, params []*dsModels.CommandValue) error {
 	d.logger.Debugf("处理设备 %s 的写入命令", deviceName)
 	// TODO: 实现UI具体的写入逻辑
 	fmt.Printf("deviceName: \n\t%s,\n protocols: \n\t%v,\n reqs:\t%v,\n params:\n\t%v\n", deviceName, protocols, reqs, params)
+	if _, ok := tagDeviceMAC(protocols); ok {
+		return fmt.Errorf("标签虚拟设备 %s 不支持写入", deviceName)
+	}
+	module, err := d.pool.get(deviceName)
+	if err != nil {
+		return err
+	}
 	for _, param := range params {
-		if err := d.handleWrite(param, d.BleController); err != nil {
+		if err := d.handleWrite(param, module); err != nil {
 			return err
 		}
 	}
@@ -72,7 +261,8 @@ func (d *Driver) HandleWriteCommands(deviceName string, protocols map[string]mod
 }
 
 // handleWrite 对set命令进行分类处理
-func (d *Driver) handleWrite(param *dsModels.CommandValue, ble interfaces.BLEController) error {
+func (d *Driver) handleWrite(param *dsModels.CommandValue, module *bleModule) error {
+	ble := module.controller
 	switch param.DeviceResourceName {
 	case "Setting&&PeripheralInit":
 		objValue, err := param.ObjectValue()
@@ -82,12 +272,12 @@ func (d *Driver) handleWrite(param *dsModels.CommandValue, ble interfaces.BLECon
 		cmd := objValue.(map[string]interface{})
 		fmt.Printf("Setting&&PeripheralInit: %v\n", cmd)
 
-		// 数据合法性检查
+		// 数据合法性检查，未指定 BleName 时使用配置的名称模板
 		cmdName, ok := cmd["BleName"].(string)
-		if !ok {
+		if _, exists := cmd["BleName"]; exists && !ok {
 			return fmt.Errorf("输入的BleName不是String类型")
 		}
-		return d.handleSetPeripheralInit(cmdName, ble)
+		return d.handleSetPeripheralInit(cmdName, module)
 
 	case "SetTxPower":
 		{
@@ -96,7 +286,7 @@ func (d *Driver) handleWrite(param *dsModels.CommandValue, ble interfaces.BLECon
 				return fmt.Errorf("resourceObjectArray.write: failed to get int8 value: %v", err)
 			}
 			fmt.Printf("SetTxPower: %v\n", param.Value)
-			return d.handleSetTxPower(int8Value, d.BleController)
+			return d.handleSetTxPower(int8Value, module)
 		}
 	case "SetBaud":
 		{
@@ -105,8 +295,121 @@ func (d *Driver) handleWrite(param *dsModels.CommandValue, ble interfaces.BLECon
 				return fmt.Errorf("resourceObjectArray.write: failed to get int64 value: %v", err)
 			}
 			fmt.Printf("SetBaud: %v\n", param.Value)
-			return d.handleSetBaud(int64Value, d.BleController)
+			return d.handleSetBaud(int64Value, module)
+		}
+
+	case "SetAdvertisingData":
+		objValue, err := param.ObjectValue()
+		if err != nil {
+			return fmt.Errorf("resourceObjectArray.write: failed to get object value: %v", err)
+		}
+		return d.handleSetAdvertisingData(objValue, ble)
+
+	case "SetBeacon":
+		objValue, err := param.ObjectValue()
+		if err != nil {
+			return fmt.Errorf("resourceObjectArray.write: failed to get object value: %v", err)
+		}
+		return d.handleSetBeacon(objValue, ble)
+
+	case "SetSecurity":
+		objValue, err := param.ObjectValue()
+		if err != nil {
+			return fmt.Errorf("resourceObjectArray.write: failed to get object value: %v", err)
+		}
+		sc, ok := ble.(securityController)
+		if !ok {
+			return fmt.Errorf("BLE控制器不支持链路安全配置")
+		}
+		var cfg blecommand.SecurityConfig
+		if err := decodeObject(objValue, &cfg); err != nil {
+			return fmt.Errorf("安全配置格式错误: %v", err)
+		}
+		return d.applySecurity(sc, cfg)
+
+	case "DeleteBond":
+		stringValue, err := param.StringValue()
+		if err != nil {
+			return fmt.Errorf("resourceObjectArray.write: failed to get string value: %v", err)
+		}
+		sc, ok := ble.(securityController)
+		if !ok {
+			return fmt.Errorf("BLE控制器不支持绑定管理")
+		}
+		return sc.RemoveBond(stringValue)
+
+	case "SetAccessList":
+		objValue, err := param.ObjectValue()
+		if err != nil {
+			return fmt.Errorf("resourceObjectArray.write: failed to get object value: %v", err)
+		}
+		ac, ok := ble.(accessController)
+		if !ok {
+			return fmt.Errorf("BLE控制器不支持访问控制")
+		}
+		return d.handleSetAccessList(objValue, ac)
+
+	case "SetAdvertisingSchedule":
+		objValue, err := param.ObjectValue()
+		if err != nil {
+			return fmt.Errorf("resourceObjectArray.write: failed to get object value: %v", err)
+		}
+		as, ok := ble.(advertisingScheduler)
+		if !ok {
+			return fmt.Errorf("BLE控制器不支持广播时间表")
+		}
+		return d.handleSetAdvertisingSchedule(objValue, as)
+
+	case "SetAdvertisingOverride":
+		objValue, err := param.ObjectValue()
+		if err != nil {
+			return fmt.Errorf("resourceObjectArray.write: failed to get object value: %v", err)
+		}
+		as, ok := ble.(advertisingScheduler)
+		if !ok {
+			return fmt.Errorf("BLE控制器不支持广播时间表")
+		}
+		return d.handleSetAdvertisingOverride(objValue, as)
+
+	case "SetScanFilter":
+		objValue, err := param.ObjectValue()
+		if err != nil {
+			return fmt.Errorf("resourceObjectArray.write: failed to get object value: %v", err)
+		}
+		sc, ok := ble.(scanner)
+		if !ok {
+			return fmt.Errorf("BLE控制器不支持标签扫描")
+		}
+		return d.handleSetScanFilter(objValue, sc)
+
+	case "SetConnParams":
+		objValue, err := param.ObjectValue()
+		if err != nil {
+			return fmt.Errorf("resourceObjectArray.write: failed to get object value: %v", err)
+		}
+		return d.handleSetConnParams(objValue, ble)
+
+	case "SetPowerMode":
+		stringValue, err := param.StringValue()
+		if err != nil {
+			return fmt.Errorf("resourceObjectArray.write: failed to get string value: %v", err)
+		}
+		pc, ok := ble.(powerController)
+		if !ok {
+			return fmt.Errorf("BLE控制器不支持功耗管理")
+		}
+		return d.handleSetPowerMode(stringValue, pc)
+
+	case "UpgradeFirmware":
+		stringValue, err := param.StringValue()
+		if err != nil {
+			return fmt.Errorf("resourceObjectArray.write: failed to get string value: %v", err)
+		}
+		fu, ok := ble.(firmwareUpgrader)
+		if !ok {
+			return fmt.Errorf("BLE控制器不支持固件升级")
 		}
+		return d.handleUpgradeFirmware(stringValue, fu)
 
 	case "SendString":
 		{
@@ -115,7 +418,7 @@ func (d *Driver) handleWrite(param *dsModels.CommandValue, ble interfaces.BLECon
 				return fmt.Errorf("resourceObjectArray.write: failed to get int64 value: %v", err)
 			}
 			fmt.Printf("SendString: %v\n", param.Value)
-			return d.handleSendString(stringValue, d.BleController)
+			return d.handleSendString(stringValue, ble)
 		}
 	}
 
@@ -124,74 +427,154 @@ func (d *Driver) handleWrite(param *dsModels.CommandValue, ble interfaces.BLECon
 
 // 自定义初始化蓝牙模块
 // TODO：还需要加入自定义特征值，并同步到jsonSender当中
-func (d *Driver) handleSetPeripheralInit(BleName string, ble interfaces.BLEController) error {
-	var cmds []string
-	// 1. 添加通用模块控制命令
-	cmds = append(cmds, blecommand.Restart()) // AT+QRST\r\n
-	// 2. 添加 BLE 初始化与配置命令
-	if cmd, err := blecommand.Init(2); err == nil { // Peripheral 角色
-		cmds = append(cmds, cmd) // AT+QBLEINIT=2\r\n
-	} else {
-		log.Printf("Error generating Init: %v", err)
-	}
-	// 3. 设置BLE名称
-	if cmd, err := blecommand.SetDeviceName(BleName); err == nil {
-		cmds = append(cmds, cmd) // AT+QBLENAME="<BleName>"\r\n
-	} else {
-		log.Printf("Error generating SetDeviceName: %v", err)
-	}
-	// 4. 添加 GATT 服务端命令
-	if cmd, err := blecommand.AddService("fff1"); err == nil { // 示例 UUID
-		cmds = append(cmds, cmd) // AT+QBLEGATTSSRV=180F\r\n
-	} else {
-		log.Printf("Error generating AddService: %v", err)
-	}
-	// 5. 添加 GATT 服务端特征值
-	if cmd, err := blecommand.AddCharacteristic("fff2"); err == nil { // 默认Read + Notify
-		cmds = append(cmds, cmd) // AT+QBLEGATTSCHAR=2A19\r\n
-	} else {
-		log.Printf("Error generating AddCh aracteristic: %v", err)
-	}
-	// 6. 完成 GATT 服务配置
-	cmds = append(cmds, blecommand.FinishGATTServer()) // AT+QBLEGATTSSRVDONE\r\n
-	// 7. 开始广播
-	cmds = append(cmds, blecommand.StartAdvertising()) // AT+QBLEADVSTART\r\n
-	// 打印 cmds 切片内容
-	// fmt.Println("Generated AT Commands:")
+func (d *Driver) handleSetPeripheralInit(BleName string, module *bleModule) error {
+	ble := module.controller
+	// BleName 可以包含名称模板的占位符，为空时重新应用配置的名称模板
+	BleName, err := d.renderModuleName(module, BleName)
+	if err != nil {
+		return fmt.Errorf("生成广播名称失败: %w", err)
+	}
+	// 复位、初始化为外围设备、建立 GATT 服务并开始广播，命令内容由模块方言决定
+	cmds, err := ble.PeripheralInitCommands(BleName)
+	if err != nil {
+		return fmt.Errorf("Error generating peripheral init commands: %v", err)
+	}
 	for i, cmd := range cmds {
-		fmt.Printf("%d: %s", i, cmd)
+		d.logger.Debugf("%d: %s", i, cmd)
 	}
 
-	return ble.CustomInitializeBle(cmds)
+	if err := ble.CustomInitializeBle(cmds); err != nil {
+		return err
+	}
+	// 该命令按默认 GATT 服务与广播参数重新初始化，期望配置同步重置
+	d.updateDesired(module, func(cfg *blecommand.DesiredConfig) {
+		def := blecommand.DefaultDesiredConfig()
+		def.Name, def.Baud = BleName, cfg.Baud
+		*cfg = def
+	})
+	return nil
+}
+
+func (d *Driver) handleSetTxPower(TxPower int8, module *bleModule) error {
+	if err := module.controller.SetTxPower(TxPower); err != nil {
+		return err
+	}
+	d.updateDesired(module, func(cfg *blecommand.DesiredConfig) { cfg.TxPower = &TxPower })
+	return nil
 }
 
-func (d *Driver) handleSetTxPower(TxPower int8, ble interfaces.BLEController) error {
-	if cmd, err := blecommand.SetTxPower(TxPower); err != nil {
-		return fmt.Errorf("Error generating SetBaud: %v", err)
-	} else {
-		return ble.SendSingle(cmd)
+func (d *Driver) handleSetBaud(Baud int64, module *bleModule) error {
+	if err := module.controller.SetBaud(Baud); err != nil {
+		return err
 	}
+	// 记录新的波特率，服务重启后按该波特率打开串口
+	d.updateDesired(module, func(cfg *blecommand.DesiredConfig) { cfg.Baud = Baud })
+	return nil
+}
 
+// handleSetAdvertisingData 根据写入的 Object 生成广播包与扫描响应包并下发到模块
+func (d *Driver) handleSetAdvertisingData(objValue interface{}, ble interfaces.BLEController) error {
+	var payload blecommand.AdvertisingPayload
+	if err := decodeObject(objValue, &payload); err != nil {
+		return fmt.Errorf("广播内容格式错误: %v", err)
+	}
+	adv, scanRsp, err := payload.Encode()
+	if err != nil {
+		return fmt.Errorf("Error generating advertising data: %v", err)
+	}
+	d.logger.Infof("更新广播内容: adv=%X, scanRsp=%X", adv, scanRsp)
+	return ble.UpdateAdvertising(adv, scanRsp)
 }
 
-func (d *Driver) handleSetBaud(Baud int64, ble interfaces.BLEController) error {
-	if cmd, err := blecommand.SetBaud(Baud); err != nil {
-		return fmt.Errorf("Error generating SetBaud: %v", err)
+// beaconController 支持信标广播的 BLE 控制器
+type beaconController interface {
+	StartBeacon(cfg blecommand.BeaconConfig) error
+}
 
-	} else {
-		return ble.SendSingle(cmd)
+// handleSetBeacon 切换信标广播模式（off / ibeacon / eddystone）
+func (d *Driver) handleSetBeacon(objValue interface{}, ble interfaces.BLEController) error {
+	bc, ok := ble.(beaconController)
+	if !ok {
+		return fmt.Errorf("BLE控制器不支持信标广播")
 	}
+	var cfg blecommand.BeaconConfig
+	if err := decodeObject(objValue, &cfg); err != nil {
+		return fmt.Errorf("信标配置格式错误: %v", err)
+	}
+	return bc.StartBeacon(cfg)
+}
 
+// connParamsController 支持连接参数管理的 BLE 控制器
+type connParamsController interface {
+	UpdateConnParams(connID int, params blecommand.ConnParams) error
+	ApplyConnProfile(connID int, name string) error
 }
 
-func (d *Driver) handleSendString(Str string, ble interfaces.BLEController) error {
-	if len(Str) > 223 {
-		return fmt.Errorf("Error, Becuase sending str out of range (240bit)")
+// connParamsRequest 连接参数写入内容，指定 profile 时使用预设，否则使用 params
+type connParamsRequest struct {
+	ConnID  int                    `json:"connId"`
+	Profile string                 `json:"profile"`
+	Params  *blecommand.ConnParams `json:"params"`
+}
+
+// handleSetConnParams 按预设或自定义参数更新指定连接的连接参数
+func (d *Driver) handleSetConnParams(objValue interface{}, ble interfaces.BLEController) error {
+	cc, ok := ble.(connParamsController)
+	if !ok {
+		return fmt.Errorf("BLE控制器不支持连接参数管理")
+	}
+	var req connParamsRequest
+	if err := decodeObject(objValue, &req); err != nil {
+		return fmt.Errorf("连接参数格式错误: %v", err)
 	}
-	if cmd, err := blecommand.SendNotify("fff2", Str); err != nil {
-		return fmt.Errorf("Error generating SendString")
-	} else {
-		return ble.SendSingle(cmd)
+	switch {
+	case req.Profile != "":
+		return cc.ApplyConnProfile(req.ConnID, req.Profile)
+	case req.Params != nil:
+		return cc.UpdateConnParams(req.ConnID, *req.Params)
 	}
+	return fmt.Errorf("连接参数需要指定 profile 或 params")
+}
+
+// securityController 支持配对、绑定与链路安全配置的 BLE 控制器
+type securityController interface {
+	ApplySecurity(cfg blecommand.SecurityConfig) error
+	BondedPeers() ([]string, error)
+	RemoveBond(addr string) error
+}
 
+// secretKeyPasskey 密钥存储中配对码的键名
+const secretKeyPasskey = "passkey"
+
+// applySecurity 下发链路安全配置，需要配对码且未直接提供时从密钥存储中读取
+func (d *Driver) applySecurity(sc securityController, cfg blecommand.SecurityConfig) error {
+	mode, err := blecommand.ParseSecurityMode(cfg.Mode)
+	if err != nil {
+		return err
+	}
+	if mode.NeedsPasskey() && cfg.Passkey == "" {
+		secretName := config.DefaultSecuritySecretName
+		if d.serviceConfig != nil {
+			secretName = d.serviceConfig.BleUserConfig.Security.SecretName
+		}
+		secrets, err := d.sdk.SecretProvider().GetSecret(secretName, secretKeyPasskey)
+		if err != nil {
+			return fmt.Errorf("从密钥存储读取配对码失败 '%s': %w", secretName, err)
+		}
+		cfg.Passkey = secrets[secretKeyPasskey]
+	}
+	return sc.ApplySecurity(cfg)
+}
+
+// decodeObject 将 Object 类型的写入值转换为指定结构体
+func decodeObject(objValue interface{}, out interface{}) error {
+	data, err := json.Marshal(objValue)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+func (d *Driver) handleSendString(Str string, ble interfaces.BLEController) error {
+	return ble.SendString(Str)
 }
@@ -0,0 +1,59 @@
+package driver
+
+import (
+	"device-ble/pkg/ble"
+	"strconv"
+	"time"
+
+	dsModels "github.com/edgexfoundry/device-sdk-go/v4/pkg/models"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+)
+
+// resourceRSSI 信号强度异步读数的资源名称
+const resourceRSSI = "RSSI"
+
+// linkMonitor 支持链路质量查询的 BLE 控制器
+type linkMonitor interface {
+	LinkQualities() []ble.LinkQuality
+}
+
+// pushLinkQuality 将一轮信号强度查询结果作为 RSSI 读数推送到 EdgeX，每个连接一条读数，以标签区分对端
+func (d *Driver) pushLinkQuality(deviceName string, samples []ble.LinkQuality) {
+	values := make([]*dsModels.CommandValue, 0, len(samples))
+	for _, s := range samples {
+		cv, err := dsModels.NewCommandValueWithOrigin(resourceRSSI, common.ValueTypeInt16, int16(s.RSSI), s.UpdatedAt.UnixNano())
+		if err != nil {
+			d.logger.Errorf("生成 RSSI 读数失败: %v", err)
+			continue
+		}
+		cv.Tags["connId"] = strconv.Itoa(s.ConnID)
+		cv.Tags["peerAddr"] = s.PeerAddr
+		values = append(values, cv)
+	}
+	if len(values) == 0 {
+		return
+	}
+	d.asyncCh <- &dsModels.AsyncValues{
+		DeviceName:    deviceName,
+		SourceName:    resourceRSSI,
+		CommandValues: values,
+	}
+	d.logger.Debugf("已推送 %d 条 RSSI 读数", len(values))
+}
+
+// linkQualityToObject 将各连接最近的链路质量转换为 Object 类型读数所需的结构
+func linkQualityToObject(samples []ble.LinkQuality) map[string]interface{} {
+	peers := make([]interface{}, 0, len(samples))
+	for _, s := range samples {
+		peers = append(peers, map[string]interface{}{
+			"connId":    s.ConnID,
+			"peerAddr":  s.PeerAddr,
+			"rssi":      s.RSSI,
+			"updatedAt": s.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+	return map[string]interface{}{
+		"count": len(samples),
+		"peers": peers,
+	}
+}
@@ -0,0 +1,496 @@
+package driver
+
+import (
+	internalif "device-ble/internal/interfaces"
+	"device-ble/pkg/ble"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+)
+
+// 控制器池健康检查参数
+const (
+	poolProbeInterval = 30 * time.Second // 健康检查间隔
+	poolFailThreshold = 3                // 模块级命令连续失败多少次后判定模块无响应
+)
+
+// bleModule 一个 EdgeX 设备对应的 BLE 模块，包括控制器、配置校验与上行处理服务
+type bleModule struct {
+	name       string
+	controller internalif.BLEController
+	reconciler *ble.Reconciler
+	commands   *CommandService
+	agent      *AgentService
+
+	nameMu   sync.Mutex   // 保护 nameVars，添加设备与写命令可能同时生成名称
+	nameVars ble.NameVars // 名称模板的占位符取值
+}
+
+// handleCommand 处理该模块收到的上行命令，响应经同一模块发回
+func (m *bleModule) handleCommand(connID int, cmd string) {
+	if m.commands != nil {
+		m.commands.HandleCommand(connID, cmd)
+	}
+}
+
+// handleAgentData 处理该模块收到的透明代理数据
+func (m *bleModule) handleAgentData(connID int, data string) {
+	if m.agent != nil {
+		m.agent.HandleAgentData(connID, data)
+	}
+}
+
+// resolveConn 为模块透传输出、不带连接索引的上行数据确定来源连接
+func (m *bleModule) resolveConn() int {
+	if m.controller == nil {
+		return ble.ConnUnknown
+	}
+	return m.controller.ResolveConn()
+}
+
+// connectionTracker 控制器能否确认连接列表可信
+type connectionTracker interface {
+	ConnectionTracked() bool
+}
+
+// tracked 是否已解析到过该模块的连接上报。未确认前连接列表为空不代表没有中心设备，下行数据按原有方式发送给连接 0。
+func (m *bleModule) tracked() bool {
+	ct, ok := m.controller.(connectionTracker)
+	return ok && ct.ConnectionTracked()
+}
+
+// markActivity 记录一次上行数据，模块已因空闲放慢广播时恢复正常广播
+func (m *bleModule) markActivity() {
+	if pc, ok := m.controller.(powerController); ok {
+		pc.MarkActivity()
+	}
+}
+
+// close 停止配置校验并释放串口
+func (m *bleModule) close() error {
+	if m.reconciler != nil {
+		m.reconciler.Stop()
+	}
+	if m.controller == nil {
+		return nil
+	}
+	return m.controller.Close()
+}
+
+// moduleHealth 模块的健康状态
+type moduleHealth struct {
+	failures int       // 连续失败次数
+	down     bool      // 已判定无响应
+	since    time.Time // 最近一次状态变化时间
+	lastErr  error     // 最近一次失败原因
+}
+
+// ModuleStatus 控制器池中一个模块的状态
+type ModuleStatus struct {
+	Device      string `json:"device"`
+	Healthy     bool   `json:"healthy"`
+	Failures    int    `json:"failures"`
+	Since       string `json:"since"`
+	Connections int    `json:"connections"`
+	Load        int    `json:"load"`      // 正在经该模块发送的下行传输数
+	Transfers   int    `json:"transfers"` // 经该模块成功发送的下行传输总数
+	Error       string `json:"error,omitempty"`
+}
+
+// ControllerPool 同一网关上多个 BLE 模块的控制器池，以 EdgeX 设备名称区分各模块。
+// 各设备的读写命令只访问自己的模块；下行数据按中心设备划分路线并行发送：
+// 同一中心设备同时连接多个模块时，每次传输只经其中负载最低的模块发送，负载相同时轮流选择；
+// 发送失败或模块判定无响应时改经该中心设备连接的其他模块发送。
+// 只连接了故障模块的中心设备无法改道，需断开后重新连接到其他模块（各模块广播相同的服务）。
+type ControllerPool struct {
+	logger logger.LoggingClient
+
+	mu        sync.RWMutex
+	modules   map[string]*bleModule
+	order     []string // 添加顺序
+	health    map[string]*moduleHealth
+	load      map[string]int // 各模块正在发送的下行传输数
+	transfers map[string]int // 各模块成功发送的下行传输总数
+	next      int            // 轮流选择的起点
+
+	checkMu sync.Mutex
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewControllerPool 创建空的控制器池
+func NewControllerPool(lc logger.LoggingClient) *ControllerPool {
+	return &ControllerPool{
+		logger:    lc,
+		modules:   make(map[string]*bleModule),
+		health:    make(map[string]*moduleHealth),
+		load:      make(map[string]int),
+		transfers: make(map[string]int),
+	}
+}
+
+// add 加入模块，同名模块已存在时替换并返回旧模块，由调用方释放
+func (p *ControllerPool) add(m *bleModule) *bleModule {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	old := p.modules[m.name]
+	if old == nil {
+		p.order = append(p.order, m.name)
+	}
+	p.modules[m.name] = m
+	p.health[m.name] = &moduleHealth{since: time.Now()}
+	return old
+}
+
+// remove 移除模块并返回，由调用方释放
+func (p *ControllerPool) remove(name string) *bleModule {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	m := p.modules[name]
+	if m == nil {
+		return nil
+	}
+	delete(p.modules, name)
+	delete(p.health, name)
+	delete(p.load, name)
+	delete(p.transfers, name)
+	for i, n := range p.order {
+		if n == name {
+			p.order = append(p.order[:i], p.order[i+1:]...)
+			break
+		}
+	}
+	return m
+}
+
+// get 返回设备对应的模块
+func (p *ControllerPool) get(name string) (*bleModule, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	m, ok := p.modules[name]
+	if !ok {
+		return nil, fmt.Errorf("设备 %s 未关联BLE模块", name)
+	}
+	return m, nil
+}
+
+// all 按添加顺序返回全部模块
+func (p *ControllerPool) all() []*bleModule {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	list := make([]*bleModule, 0, len(p.order))
+	for _, name := range p.order {
+		list = append(list, p.modules[name])
+	}
+	return list
+}
+
+// healthy 模块是否可以承担下行传输
+func (p *ControllerPool) healthy(name string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	h, ok := p.health[name]
+	return ok && !h.down
+}
+
+// reportSuccess 记录一次成功的交互，故障模块恢复时重新加入
+func (p *ControllerPool) reportSuccess(name string) {
+	p.mu.Lock()
+	h, ok := p.health[name]
+	recovered := ok && h.down
+	if ok {
+		h.failures, h.lastErr = 0, nil
+		if h.down {
+			h.down, h.since = false, time.Now()
+		}
+	}
+	p.mu.Unlock()
+	if recovered {
+		p.logger.Infof("🔁 BLE模块 %s 已恢复响应，重新承担下行传输", name)
+	}
+}
+
+// reportFailure 记录一次模块级命令失败（如 AT 命令超时），连续失败达到阈值时判定模块无响应。
+// 单个中心设备的发送失败不代表模块故障，不应直接记录到这里。
+func (p *ControllerPool) reportFailure(name string, err error) {
+	p.mu.Lock()
+	h, ok := p.health[name]
+	markedDown := false
+	if ok {
+		h.failures++
+		h.lastErr = err
+		if !h.down && h.failures >= poolFailThreshold {
+			h.down, h.since = true, time.Now()
+			markedDown = true
+		}
+	}
+	p.mu.Unlock()
+	if markedDown {
+		p.logger.Errorf("⛔️ BLE模块 %s 连续 %d 次无响应，下行传输改经其他模块发送: %v", name, poolFailThreshold, err)
+	}
+}
+
+// transferTarget 下行传输可以经由的一个模块连接，connID 为 ble.ConnUnknown 时按模块原有方式发送给连接 0
+type transferTarget struct {
+	module *bleModule
+	connID int
+}
+
+// routes 按中心设备划分下行传输的路线，每条路线是同一中心设备可以经由的全部连接。
+// 同一对端地址连接在多个健康模块上时合并为一条路线；对端地址未知的连接各自成为一条路线；
+// 尚未确认连接上报的模块无法区分中心设备，整体作为一条路线发送给连接 0。
+func (p *ControllerPool) routes() [][]transferTarget {
+	var routes [][]transferTarget
+	byPeer := make(map[string]int)
+	for _, m := range p.all() {
+		if !p.healthy(m.name) {
+			p.logger.Warnf("【控制器池】BLE模块 %s 无响应，跳过其连接", m.name)
+			continue
+		}
+		if !m.tracked() {
+			routes = append(routes, []transferTarget{{module: m, connID: ble.ConnUnknown}})
+			continue
+		}
+		for _, conn := range m.controller.Connections() {
+			target := transferTarget{module: m, connID: conn.ConnID}
+			peer := strings.ToUpper(conn.PeerAddr)
+			if i, ok := byPeer[peer]; ok && peer != "" {
+				routes[i] = append(routes[i], target)
+				continue
+			}
+			if peer != "" {
+				byPeer[peer] = len(routes)
+			}
+			routes = append(routes, []transferTarget{target})
+		}
+	}
+	return routes
+}
+
+// rank 按负载排列一条路线的候选连接：正在发送的传输少的模块优先，负载相同时从轮流起点开始依次选择
+func (p *ControllerPool) rank(route []transferTarget) []transferTarget {
+	if len(route) < 2 {
+		return route
+	}
+	p.mu.Lock()
+	start := p.next % len(route)
+	p.next++
+	load := make(map[string]int, len(route))
+	for _, t := range route {
+		load[t.module.name] = p.load[t.module.name]
+	}
+	p.mu.Unlock()
+
+	ranked := append(append([]transferTarget(nil), route[start:]...), route[:start]...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return load[ranked[i].module.name] < load[ranked[j].module.name]
+	})
+	return ranked
+}
+
+// deliver 经一条路线发送数据，候选连接发送失败时改用下一个
+func (p *ControllerPool) deliver(route []transferTarget, data interface{}) error {
+	var errs []error
+	for i, t := range p.rank(route) {
+		err := p.send(t, data)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("%s conn %d: %w", t.module.name, t.connID, err))
+		if i < len(route)-1 {
+			p.logger.Warnf("【控制器池】经BLE模块 %s 发送失败，改经其他模块发送: %v", t.module.name, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// send 经一个模块连接发送数据并记录负载。发送失败可能只是中心设备断开，
+// 此时查询一次模块确认其是否仍可响应，只有模块级失败计入故障判定。
+func (p *ControllerPool) send(t transferTarget, data interface{}) error {
+	name := t.module.name
+	p.mu.Lock()
+	p.load[name]++
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		p.load[name]--
+		p.mu.Unlock()
+	}()
+
+	var err error
+	if t.connID == ble.ConnUnknown {
+		err = t.module.controller.SendJSON(data)
+	} else {
+		err = t.module.controller.SendJSONTo(t.connID, data)
+	}
+	if err != nil {
+		if !errors.Is(err, ble.ErrNoConnection) {
+			p.probe(t.module)
+		}
+		return err
+	}
+	p.mu.Lock()
+	p.transfers[name]++
+	p.mu.Unlock()
+	p.reportSuccess(name)
+	return nil
+}
+
+// broadcast 向全部中心设备发送 JSON 数据，各条路线并行传输，返回路线数（即目标中心设备数）
+func (p *ControllerPool) broadcast(data interface{}) (int, error) {
+	routes := p.routes()
+	errs := make([]error, len(routes))
+	var wg sync.WaitGroup
+	for i, route := range routes {
+		wg.Add(1)
+		go func(i int, route []transferTarget) {
+			defer wg.Done()
+			errs[i] = p.deliver(route, data)
+		}(i, route)
+	}
+	wg.Wait()
+	return len(routes), errors.Join(errs...)
+}
+
+// connectionCount 全部模块的连接数之和
+func (p *ControllerPool) connectionCount() int {
+	n := 0
+	for _, m := range p.all() {
+		n += len(m.controller.Connections())
+	}
+	return n
+}
+
+// connectionsTracked 是否全部模块都已确认连接列表可信，此时连接数为 0 才表示确实没有中心设备
+func (p *ControllerPool) connectionsTracked() bool {
+	for _, m := range p.all() {
+		if !m.tracked() {
+			return false
+		}
+	}
+	return true
+}
+
+// startHealthCheck 周期查询各模块的固件版本，确认模块仍可响应
+func (p *ControllerPool) startHealthCheck(interval time.Duration) {
+	p.checkMu.Lock()
+	defer p.checkMu.Unlock()
+	if p.stop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	p.stop, p.done = stop, done
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				for _, m := range p.all() {
+					p.probe(m)
+				}
+			}
+		}
+	}()
+}
+
+// stopHealthCheck 停止健康检查
+func (p *ControllerPool) stopHealthCheck() {
+	p.checkMu.Lock()
+	stop, done := p.stop, p.done
+	p.stop, p.done = nil, nil
+	p.checkMu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+// probe 检查一个模块是否可以响应。模块休眠或升级固件期间不检查，避免唤醒模块或干扰升级。
+func (p *ControllerPool) probe(m *bleModule) {
+	if pc, ok := m.controller.(powerController); ok && pc.PowerState().Asleep {
+		return
+	}
+	if fu, ok := m.controller.(firmwareUpgrader); ok && fu.Upgrading() {
+		return
+	}
+	if _, err := m.controller.QueryVersion(); err != nil {
+		p.reportFailure(m.name, err)
+		return
+	}
+	p.reportSuccess(m.name)
+}
+
+// status 返回各模块的状态
+func (p *ControllerPool) status() []ModuleStatus {
+	list := make([]ModuleStatus, 0)
+	for _, m := range p.all() {
+		p.mu.RLock()
+		h := *p.health[m.name]
+		load, transfers := p.load[m.name], p.transfers[m.name]
+		p.mu.RUnlock()
+		s := ModuleStatus{
+			Device:      m.name,
+			Healthy:     !h.down,
+			Failures:    h.failures,
+			Since:       h.since.Format(time.RFC3339),
+			Connections: len(m.controller.Connections()),
+			Load:        load,
+			Transfers:   transfers,
+		}
+		if h.lastErr != nil {
+			s.Error = h.lastErr.Error()
+		}
+		list = append(list, s)
+	}
+	return list
+}
+
+// close 停止健康检查并释放全部模块
+func (p *ControllerPool) close() {
+	p.stopHealthCheck()
+	for _, m := range p.all() {
+		p.remove(m.name)
+		if err := m.close(); err != nil {
+			p.logger.Errorf("BLE模块 %s 关闭失败: %v", m.name, err)
+		} else {
+			p.logger.Debugf("BLE模块 %s 已关闭", m.name)
+		}
+	}
+}
+
+// poolStatusToObject 将控制器池中各模块的状态转换为 Object 类型读数所需的结构
+func poolStatusToObject(list []ModuleStatus) map[string]interface{} {
+	modules := make([]interface{}, 0, len(list))
+	healthy := 0
+	for _, s := range list {
+		if s.Healthy {
+			healthy++
+		}
+		modules = append(modules, map[string]interface{}{
+			"device":      s.Device,
+			"healthy":     s.Healthy,
+			"failures":    s.Failures,
+			"since":       s.Since,
+			"connections": s.Connections,
+			"load":        s.Load,
+			"transfers":   s.Transfers,
+			"error":       s.Error,
+		})
+	}
+	return map[string]interface{}{
+		"count":   len(list),
+		"healthy": healthy,
+		"modules": modules,
+	}
+}
@@ -0,0 +1,196 @@
+package driver
+
+import (
+	internalif "device-ble/internal/interfaces"
+	"device-ble/pkg/ble"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+)
+
+// fakeController 记录下行发送的控制器，发送与版本查询的结果可配置
+type fakeController struct {
+	mu       sync.Mutex
+	conns    []internalif.BLEConnection
+	tracked  bool
+	sendErr  error
+	queryErr error
+	sent     []int // 每次发送的目标连接，ble.ConnUnknown 表示未区分连接的 SendJSON
+	queries  int
+}
+
+func (f *fakeController) Close() error                                    { return nil }
+func (f *fakeController) InitializeAsPeripheral() error                   { return nil }
+func (f *fakeController) CustomInitializeBle([]string) error              { return nil }
+func (f *fakeController) SendSingle(string) error                         { return nil }
+func (f *fakeController) SendMulti([]string) error                        { return nil }
+func (f *fakeController) SendSingleWithResponse(string) (string, error)   { return "OK", nil }
+func (f *fakeController) UpdateAdvertising([]byte, []byte) error          { return nil }
+func (f *fakeController) GetQueue() internalif.SerialQueueInterface       { return nil }
+func (f *fakeController) MTU(int) int                                     { return ble.DefaultMTU }
+func (f *fakeController) PeripheralInitCommands(string) ([]string, error) { return nil, nil }
+func (f *fakeController) SetTxPower(int8) error                           { return nil }
+func (f *fakeController) SetBaud(int64) error                             { return nil }
+func (f *fakeController) SendString(string) error                         { return nil }
+func (f *fakeController) ResolveConn() int                                { return ble.ConnUnknown }
+func (f *fakeController) QueryAddress() (string, error)                   { return "", nil }
+func (f *fakeController) ConnectionTracked() bool                         { return f.tracked }
+
+func (f *fakeController) Connections() []internalif.BLEConnection {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]internalif.BLEConnection(nil), f.conns...)
+}
+
+func (f *fakeController) SendJSON(data interface{}) error {
+	return f.SendJSONTo(ble.ConnUnknown, data)
+}
+
+func (f *fakeController) SendJSONTo(connID int, _ interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.sendErr != nil {
+		return f.sendErr
+	}
+	f.sent = append(f.sent, connID)
+	return nil
+}
+
+func (f *fakeController) QueryVersion() (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queries++
+	return "+QVERSION: HCM111Z V1.4.0", f.queryErr
+}
+
+// sends 返回成功发送的次数
+func (f *fakeController) sends() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.sent)
+}
+
+// newTestPool 创建包含指定模块的控制器池
+func newTestPool(controllers map[string]*fakeController, order ...string) *ControllerPool {
+	p := NewControllerPool(logger.NewMockClient())
+	for _, name := range order {
+		p.add(&bleModule{name: name, controller: controllers[name]})
+	}
+	return p
+}
+
+// connectedTo 返回连接了指定对端的控制器
+func connectedTo(peers ...string) *fakeController {
+	f := &fakeController{tracked: true}
+	for i, peer := range peers {
+		f.conns = append(f.conns, internalif.BLEConnection{ConnID: i, PeerAddr: peer})
+	}
+	return f
+}
+
+func TestPoolSendsToCentralsOnEachModule(t *testing.T) {
+	a, b := connectedTo("AA:BB:CC:DD:EE:01"), connectedTo("AA:BB:CC:DD:EE:02")
+	c := &fakeController{} // 尚未确认连接上报，按原有方式发送给连接 0
+	p := newTestPool(map[string]*fakeController{"a": a, "b": b, "c": c}, "a", "b", "c")
+
+	n, err := p.broadcast(map[string]string{"k": "v"})
+	if err != nil || n != 3 {
+		t.Fatalf("broadcast = %d, %v, want 3 routes", n, err)
+	}
+	if a.sends() != 1 || b.sends() != 1 || c.sends() != 1 {
+		t.Errorf("sends a=%d b=%d c=%d, want 1 each", a.sends(), b.sends(), c.sends())
+	}
+	if c.sent[0] != ble.ConnUnknown {
+		t.Errorf("untracked module sent to conn %d, want the module-wide send", c.sent[0])
+	}
+}
+
+func TestPoolBalancesCentralConnectedToSeveralModules(t *testing.T) {
+	a, b := connectedTo("aa:bb:cc:dd:ee:01"), connectedTo("AA:BB:CC:DD:EE:01")
+	p := newTestPool(map[string]*fakeController{"a": a, "b": b}, "a", "b")
+
+	for i := 0; i < 4; i++ {
+		n, err := p.broadcast(i)
+		if err != nil || n != 1 {
+			t.Fatalf("broadcast %d = %d, %v, want 1 route", i, n, err)
+		}
+	}
+	if a.sends() != 2 || b.sends() != 2 {
+		t.Errorf("sends a=%d b=%d, want 2 each", a.sends(), b.sends())
+	}
+}
+
+func TestPoolFailsOverWhenModuleStopsResponding(t *testing.T) {
+	timeout := errors.New("等待响应超时")
+	a, b := connectedTo("AA:BB:CC:DD:EE:01"), connectedTo("AA:BB:CC:DD:EE:01")
+	a.sendErr, a.queryErr = timeout, timeout
+	p := newTestPool(map[string]*fakeController{"a": a, "b": b}, "a", "b")
+
+	for i := 0; i < 2*poolFailThreshold; i++ {
+		if _, err := p.broadcast(i); err != nil {
+			t.Fatalf("broadcast %d: %v", i, err)
+		}
+	}
+	if b.sends() != 2*poolFailThreshold {
+		t.Errorf("module b sent %d transfers, want %d", b.sends(), 2*poolFailThreshold)
+	}
+	if p.healthy("a") {
+		t.Error("module a still healthy after repeated timeouts")
+	}
+	queries := a.queries
+	if _, err := p.broadcast("after"); err != nil {
+		t.Fatalf("broadcast after failover: %v", err)
+	}
+	if a.queries != queries {
+		t.Error("unhealthy module was still tried for downlink data")
+	}
+}
+
+func TestPoolHealthThreshold(t *testing.T) {
+	p := newTestPool(map[string]*fakeController{"a": connectedTo()}, "a")
+	err := errors.New("timeout")
+	for i := 1; i < poolFailThreshold; i++ {
+		p.reportFailure("a", err)
+		if !p.healthy("a") {
+			t.Fatalf("module unhealthy after %d failures, threshold is %d", i, poolFailThreshold)
+		}
+	}
+	p.reportFailure("a", err)
+	if p.healthy("a") {
+		t.Fatalf("module healthy after %d failures", poolFailThreshold)
+	}
+	p.reportSuccess("a")
+	if !p.healthy("a") {
+		t.Error("module not healthy after a success")
+	}
+	if s := p.status()[0]; s.Failures != 0 || s.Error != "" {
+		t.Errorf("status after recovery = %+v", s)
+	}
+}
+
+func TestPoolProbe(t *testing.T) {
+	a := connectedTo("AA:BB:CC:DD:EE:01")
+	a.sendErr = ble.ErrNoConnection
+	p := newTestPool(map[string]*fakeController{"a": a}, "a")
+
+	// 中心设备断开不代表模块故障，不查询模块
+	if _, err := p.broadcast("x"); !errors.Is(err, ble.ErrNoConnection) {
+		t.Fatalf("broadcast = %v, want ErrNoConnection", err)
+	}
+	if a.queries != 0 {
+		t.Errorf("probed the module %d times after a central-level failure", a.queries)
+	}
+
+	a.queryErr = errors.New("timeout")
+	p.probe(p.all()[0])
+	if s := p.status()[0]; s.Failures != 1 || s.Error != "timeout" {
+		t.Errorf("status after a failed probe = %+v", s)
+	}
+	a.queryErr = nil
+	p.probe(p.all()[0])
+	if s := p.status()[0]; s.Failures != 0 || !s.Healthy {
+		t.Errorf("status after a successful probe = %+v", s)
+	}
+}
@@ -0,0 +1,42 @@
+package driver
+
+import (
+	"device-ble/pkg/ble"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 模块功耗模式
+const (
+	powerModeSleep = "sleep"
+	powerModeWake  = "wake"
+)
+
+// powerController 支持休眠与空闲功耗管理的 BLE 控制器
+type powerController interface {
+	Sleep() error
+	Wake() error
+	PowerState() ble.PowerState
+	MarkActivity()
+}
+
+// handleSetPowerMode 使模块休眠或唤醒
+func (d *Driver) handleSetPowerMode(mode string, pc powerController) error {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case powerModeSleep:
+		return pc.Sleep()
+	case powerModeWake:
+		return pc.Wake()
+	}
+	return fmt.Errorf("未知的功耗模式 %q，可选 %s / %s", mode, powerModeSleep, powerModeWake)
+}
+
+// powerStateToObject 将功耗状态转换为 Object 类型读数所需的结构
+func powerStateToObject(s ble.PowerState) map[string]interface{} {
+	return map[string]interface{}{
+		"asleep":       s.Asleep,
+		"lowPower":     s.LowPower,
+		"lastActivity": s.LastActivity.Format(time.RFC3339),
+	}
+}
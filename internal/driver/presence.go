@@ -0,0 +1,255 @@
+package driver
+
+import (
+	"device-ble/cmd/config"
+	"device-ble/pkg/ble"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	dsModels "github.com/edgexfoundry/device-sdk-go/v4/pkg/models"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/models"
+	"github.com/spf13/cast"
+)
+
+// 标签虚拟设备的协议与资源名称
+const (
+	protocolBLETag       = "BLETag"        // 标签虚拟设备的协议，属性 mac 为标签 MAC 地址
+	resourcePresence     = "PresenceEvent" // 在场事件读数 enter / near / far / exit
+	resourceSmoothedRSSI = "SmoothedRSSI"  // 平滑后的信号强度读数
+)
+
+// tagDeviceMAC 返回标签虚拟设备对应的标签 MAC 地址，不是标签虚拟设备时返回 false
+func tagDeviceMAC(protocols map[string]models.ProtocolProperties) (string, bool) {
+	p, ok := protocols[protocolBLETag]
+	if !ok {
+		return "", false
+	}
+	mac, _ := cast.ToStringE(p["mac"])
+	return strings.ToUpper(mac), true
+}
+
+// tagDeviceName 返回标签虚拟设备的名称
+func tagDeviceName(prefix, mac string) string {
+	return prefix + strings.ReplaceAll(mac, ":", "")
+}
+
+// presenceConfig 将在场判定配置转换为判定参数
+func presenceConfig(cfg config.BLEPresenceConfig) ble.PresenceConfig {
+	return ble.PresenceConfig{
+		Smoothing:        cfg.Smoothing,
+		Window:           cfg.Window,
+		ProcessNoise:     cfg.ProcessNoise,
+		MeasurementNoise: cfg.MeasurementNoise,
+		NearRSSI:         cfg.NearRSSI,
+		FarRSSI:          cfg.FarRSSI,
+		Hysteresis:       cfg.Hysteresis,
+		Timeout:          cfg.TimeoutDuration(),
+	}
+}
+
+// ensurePresence 首次添加设备时创建在场判定引擎并启动超时检查，各模块的扫描结果合并判定
+func (d *Driver) ensurePresence(cfg config.BLEPresenceConfig) error {
+	d.presenceMu.Lock()
+	defer d.presenceMu.Unlock()
+	if d.presence != nil {
+		return nil
+	}
+	engine, err := ble.NewPresenceEngine(presenceConfig(cfg))
+	if err != nil {
+		return err
+	}
+	d.presence, d.presenceCfg = engine, cfg
+	d.presenceTags = tagSet(cfg.Tags)
+
+	interval := cfg.TimeoutDuration() / 4
+	if interval < time.Second {
+		interval = time.Second
+	}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	d.presenceStop, d.presenceDone = stop, done
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				d.pushPresence(engine.Expire(now))
+			}
+		}
+	}()
+	return nil
+}
+
+// stopPresence 停止超时检查
+func (d *Driver) stopPresence() {
+	d.presenceMu.Lock()
+	stop, done := d.presenceStop, d.presenceDone
+	d.presenceStop, d.presenceDone = nil, nil
+	d.presenceMu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+// tagSet 将配置的标签 MAC 地址转换为查找表
+func tagSet(tags []string) map[string]bool {
+	if len(tags) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		set[strings.ToUpper(tag)] = true
+	}
+	return set
+}
+
+// observePresence 将模块上报的一批扫描结果交给在场判定引擎，推送由此产生的事件。
+// 配置了标签名单时只判定名单中的标签，其余扫描结果不会产生虚拟设备。
+func (d *Driver) observePresence(source string, sightings []ble.Sighting) {
+	d.presenceMu.Lock()
+	engine, tags := d.presence, d.presenceTags
+	d.presenceMu.Unlock()
+	if engine == nil {
+		return
+	}
+	if tags != nil {
+		listed := sightings[:0:0]
+		for _, s := range sightings {
+			if tags[strings.ToUpper(s.MAC)] {
+				listed = append(listed, s)
+			}
+		}
+		if sightings = listed; len(sightings) == 0 {
+			return
+		}
+	}
+	d.pushPresence(engine.Observe(source, sightings))
+}
+
+// pushPresence 将在场事件作为标签虚拟设备的读数推送到 EdgeX，标签首次出现时创建虚拟设备
+func (d *Driver) pushPresence(events []ble.PresenceEvent) {
+	for _, e := range events {
+		name := tagDeviceName(d.presenceCfg.DevicePrefix, e.MAC)
+		if err := d.ensureTagDevice(name, e.MAC); err != nil {
+			d.logger.Errorf("【标签在场】创建标签 %s 的虚拟设备失败: %v", e.MAC, err)
+			continue
+		}
+		event, err := dsModels.NewCommandValueWithOrigin(resourcePresence, common.ValueTypeString, e.Event, e.Timestamp)
+		if err != nil {
+			d.logger.Errorf("生成在场事件读数失败: %v", err)
+			continue
+		}
+		event.Tags["mac"] = e.MAC
+		event.Tags["zone"] = e.Zone
+		event.Tags["source"] = e.Source
+		rssi, err := dsModels.NewCommandValueWithOrigin(resourceSmoothedRSSI, common.ValueTypeFloat64, e.RSSI, e.Timestamp)
+		if err != nil {
+			d.logger.Errorf("生成平滑信号强度读数失败: %v", err)
+			continue
+		}
+		d.asyncCh <- &dsModels.AsyncValues{
+			DeviceName:    name,
+			SourceName:    resourcePresence,
+			CommandValues: []*dsModels.CommandValue{event, rssi},
+		}
+		d.logger.Infof("【标签在场】%s %s, rssi=%s dBm, source=%s", e.MAC, e.Event, strconv.FormatFloat(e.RSSI, 'f', 1, 64), e.Source)
+	}
+}
+
+// ensureTagDevice 标签的虚拟设备不存在时创建
+func (d *Driver) ensureTagDevice(name, mac string) error {
+	if _, err := d.sdk.GetDeviceByName(name); err == nil {
+		return nil
+	}
+	_, err := d.sdk.AddDevice(models.Device{
+		Name:           name,
+		Description:    fmt.Sprintf("BLE tag %s", mac),
+		AdminState:     models.Unlocked,
+		OperatingState: models.Up,
+		ServiceName:    d.sdk.Name(),
+		ProfileName:    d.presenceCfg.ProfileName,
+		Labels:         []string{"ble-tag"},
+		Protocols: map[string]models.ProtocolProperties{
+			protocolBLETag: {"mac": mac},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	d.logger.Infof("【标签在场】已创建标签 %s 的虚拟设备 %s", mac, name)
+	return nil
+}
+
+// handleTagRead 处理标签虚拟设备的读取命令
+func (d *Driver) handleTagRead(mac string, reqs []dsModels.CommandRequest) ([]*dsModels.CommandValue, error) {
+	d.presenceMu.Lock()
+	engine := d.presence
+	d.presenceMu.Unlock()
+	if engine == nil {
+		return nil, fmt.Errorf("未开启标签在场判定")
+	}
+	tag, present := engine.Tag(mac)
+	responses := make([]*dsModels.CommandValue, 0, len(reqs))
+	for _, req := range reqs {
+		var (
+			cv  *dsModels.CommandValue
+			err error
+		)
+		switch req.DeviceResourceName {
+		case "GetPresence":
+			obj := tagPresenceToObject(tag)
+			obj["present"] = present
+			cv, err = dsModels.NewCommandValue(req.DeviceResourceName, common.ValueTypeObject, obj)
+		case resourcePresence, resourceSmoothedRSSI:
+			return nil, fmt.Errorf("%s 仅以异步读数推送，请读取 GetPresence 获取标签当前状态", req.DeviceResourceName)
+		default:
+			return nil, fmt.Errorf("不支持的读取资源: %s", req.DeviceResourceName)
+		}
+		if err != nil {
+			return nil, err
+		}
+		responses = append(responses, cv)
+	}
+	return responses, nil
+}
+
+// trackedTagsToObject 将全部标签的在场状态转换为 Object 类型读数所需的结构
+func (d *Driver) trackedTagsToObject() (map[string]interface{}, error) {
+	d.presenceMu.Lock()
+	engine := d.presence
+	d.presenceMu.Unlock()
+	if engine == nil {
+		return nil, fmt.Errorf("未开启标签在场判定")
+	}
+	list := engine.Tags()
+	tags := make([]interface{}, 0, len(list))
+	for _, t := range list {
+		tags = append(tags, tagPresenceToObject(t))
+	}
+	return map[string]interface{}{"count": len(list), "tags": tags}, nil
+}
+
+// tagPresenceToObject 将标签在场状态转换为 Object 类型读数所需的结构
+func tagPresenceToObject(t ble.TagPresence) map[string]interface{} {
+	obj := map[string]interface{}{
+		"mac":    t.MAC,
+		"name":   t.Name,
+		"zone":   t.Zone,
+		"rssi":   t.RSSI,
+		"source": t.Source,
+	}
+	if !t.LastSeen.IsZero() {
+		obj["firstSeen"] = t.FirstSeen.Format(time.RFC3339)
+		obj["lastSeen"] = t.LastSeen.Format(time.RFC3339)
+	}
+	return obj
+}
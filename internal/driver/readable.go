@@ -0,0 +1,137 @@
+package driver
+
+import (
+	"device-ble/cmd/config"
+	"device-ble/pkg/ble"
+	"device-ble/pkg/dataparse"
+	"strings"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// charValueController 支持可读特征值的 BLE 控制器
+type charValueController interface {
+	SetCharacteristicValue(uuid, value string) error
+	CharacteristicValues() []ble.CharValue
+}
+
+// addReadableCharacteristics 将映射的特征值以可读属性加入期望的 GATT 服务表，返回新增或补充了属性的特征值数量
+func addReadableCharacteristics(desired *ble.DesiredConfig, mappings []config.BLEReadableMapping) int {
+	added := 0
+	for _, m := range mappings {
+		service := m.Service
+		if service == "" {
+			service = ble.DefaultServiceUUID
+		}
+		if desired.AddCharacteristicTo(service, m.Characteristic, "read") {
+			added++
+		}
+	}
+	return added
+}
+
+// subscribeReadable 订阅 EdgeX 事件，将映射资源的最新读数写入各模块的特征值
+func (d *Driver) subscribeReadable(cfg config.BLEReadableConfig) {
+	if len(cfg.Characteristics) == 0 {
+		return
+	}
+	handler := func(topic string, envelope types.MessageEnvelope) error {
+		d.updateReadable(cfg.Characteristics, envelope)
+		return nil
+	}
+	if err := d.MessageBusClient.Subscribe(cfg.EventTopic, handler); err != nil {
+		d.logger.Errorf("【可读特征值】订阅 EdgeX 事件失败 err: %v", err)
+		return
+	}
+	d.logger.Infof("【可读特征值】已订阅 EdgeX 事件 %s，映射 %d 个特征值", cfg.EventTopic, len(cfg.Characteristics))
+}
+
+// updateReadable 处理一条 EdgeX 事件，映射资源的读数写入全部模块的对应特征值
+func (d *Driver) updateReadable(mappings []config.BLEReadableMapping, envelope types.MessageEnvelope) {
+	event, err := dataparse.ParseEvent(&envelope)
+	if err != nil {
+		d.logger.Debugf("【可读特征值】忽略无法解析的事件: %v", err)
+		return
+	}
+	for _, reading := range event.Readings {
+		for _, m := range mappings {
+			if m.Device != event.DeviceName || m.Resource != reading.ResourceName {
+				continue
+			}
+			value, err := dataparse.ReadingValue(reading)
+			if err != nil {
+				d.logger.Errorf("【可读特征值】读数 %s/%s 转换失败: %v", m.Device, m.Resource, err)
+				continue
+			}
+			// 模块不接受空的特征值内容，保留上一次的内容
+			if value == "" {
+				d.logger.Debugf("【可读特征值】读数 %s/%s 内容为空，忽略", m.Device, m.Resource)
+				continue
+			}
+			d.setReadableValue(strings.ToLower(m.Characteristic), value)
+		}
+	}
+}
+
+// setReadableValue 记录特征值的最新内容并写入全部模块，之后添加的模块初始化后同样写入
+func (d *Driver) setReadableValue(uuid, value string) {
+	d.readableMu.Lock()
+	if d.readableValues == nil {
+		d.readableValues = make(map[string]string)
+	}
+	d.readableValues[uuid] = value
+	d.readableMu.Unlock()
+
+	for _, m := range d.pool.all() {
+		cv, ok := m.controller.(charValueController)
+		if !ok {
+			continue
+		}
+		if err := cv.SetCharacteristicValue(uuid, value); err != nil {
+			d.logger.Errorf("【可读特征值】BLE模块 %s 更新特征值 %s 失败: %v", m.name, uuid, err)
+			continue
+		}
+		d.logger.Debugf("【可读特征值】BLE模块 %s 特征值 %s 已更新: %s", m.name, uuid, value)
+	}
+}
+
+// applyReadableValues 将已收到的特征值内容写入新初始化的模块
+func (d *Driver) applyReadableValues(name string, cv charValueController) {
+	d.readableMu.Lock()
+	values := make(map[string]string, len(d.readableValues))
+	for uuid, v := range d.readableValues {
+		values[uuid] = v
+	}
+	d.readableMu.Unlock()
+	for uuid, v := range values {
+		if err := cv.SetCharacteristicValue(uuid, v); err != nil {
+			d.logger.Errorf("【可读特征值】BLE模块 %s 写入特征值 %s 失败: %v", name, uuid, err)
+		}
+	}
+}
+
+// readableToObject 将可读特征值映射与当前内容转换为 Object 类型读数所需的结构
+func readableToObject(mappings []config.BLEReadableMapping, cv charValueController) map[string]interface{} {
+	current := make(map[string]ble.CharValue)
+	for _, v := range cv.CharacteristicValues() {
+		current[v.UUID] = v
+	}
+	list := make([]interface{}, 0, len(mappings))
+	for _, m := range mappings {
+		item := map[string]interface{}{
+			"characteristic": m.Characteristic,
+			"device":         m.Device,
+			"resource":       m.Resource,
+		}
+		if v, ok := current[strings.ToLower(m.Characteristic)]; ok {
+			item["value"] = v.Value
+			item["updatedAt"] = v.UpdatedAt.Format(time.RFC3339)
+		}
+		list = append(list, item)
+	}
+	return map[string]interface{}{
+		"count":           len(mappings),
+		"characteristics": list,
+	}
+}
@@ -0,0 +1,94 @@
+package driver
+
+import (
+	"device-ble/pkg/ble"
+	"fmt"
+
+	dsModels "github.com/edgexfoundry/device-sdk-go/v4/pkg/models"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+)
+
+// readbackController 支持回读模块配置的 BLE 控制器
+type readbackController interface {
+	QueryDeviceName() (string, error)
+	QueryTxPower() (int8, error)
+	QueryAdvParams() (ble.AdvParams, error)
+	QueryBaud() (int64, error)
+	QueryRole() (int, error)
+	Advertising() bool
+}
+
+// desiredGATTTable 返回期望配置中的 GATT 服务表。模块没有查询 GATT 服务的命令，
+// 该表不代表模块当前的实际状态，模块复位后由配置校验按此表重新下发。
+func (m *bleModule) desiredGATTTable() ([]ble.GATTService, error) {
+	if m.reconciler == nil {
+		return nil, fmt.Errorf("设备尚未完成初始化")
+	}
+	return m.reconciler.Desired().Services, nil
+}
+
+// gattTableToObject 将期望的 GATT 服务表转换为 Object 类型读数所需的结构，source 标明数据来自期望配置
+func gattTableToObject(services []ble.GATTService) map[string]interface{} {
+	list := make([]interface{}, 0, len(services))
+	for _, s := range services {
+		chars := make([]interface{}, 0, len(s.Characteristics))
+		for _, c := range s.Characteristics {
+			chars = append(chars, c)
+		}
+		entry := map[string]interface{}{
+			"uuid":            s.UUID,
+			"characteristics": chars,
+		}
+		if len(s.Properties) > 0 {
+			entry["properties"] = s.Properties
+		}
+		list = append(list, entry)
+	}
+	return map[string]interface{}{
+		"source":   "desired",
+		"count":    len(services),
+		"services": list,
+	}
+}
+
+// readbackValue 回读一项模块配置并转换为对应类型的读数
+func readbackValue(resource string, rc readbackController) (*dsModels.CommandValue, error) {
+	switch resource {
+	case "GetDeviceName":
+		name, err := rc.QueryDeviceName()
+		if err != nil {
+			return nil, err
+		}
+		return dsModels.NewCommandValue(resource, common.ValueTypeString, name)
+	case "GetTxPower":
+		txPower, err := rc.QueryTxPower()
+		if err != nil {
+			return nil, err
+		}
+		return dsModels.NewCommandValue(resource, common.ValueTypeInt8, txPower)
+	case "GetAdvParams":
+		p, err := rc.QueryAdvParams()
+		if err != nil {
+			return nil, err
+		}
+		return dsModels.NewCommandValue(resource, common.ValueTypeObject, map[string]interface{}{
+			"minInterval": p.MinInterval,
+			"maxInterval": p.MaxInterval,
+		})
+	case "GetBaud":
+		baud, err := rc.QueryBaud()
+		if err != nil {
+			return nil, err
+		}
+		return dsModels.NewCommandValue(resource, common.ValueTypeInt64, baud)
+	case "GetRole":
+		role, err := rc.QueryRole()
+		if err != nil {
+			return nil, err
+		}
+		return dsModels.NewCommandValue(resource, common.ValueTypeInt8, int8(role))
+	case "GetAdvertising":
+		return dsModels.NewCommandValue(resource, common.ValueTypeBool, rc.Advertising())
+	}
+	return nil, fmt.Errorf("未知的回读资源: %s", resource)
+}
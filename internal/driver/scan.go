@@ -0,0 +1,102 @@
+package driver
+
+import (
+	"device-ble/cmd/config"
+	"device-ble/pkg/ble"
+	"fmt"
+	"time"
+)
+
+// scanner 支持标签扫描的 BLE 控制器
+type scanner interface {
+	StartScan(opts ble.ScanOptions, handler func([]ble.Sighting)) error
+	StopScan() error
+	SetScanFilter(f ble.ScanFilter) error
+	ScanStats() ble.ScanStats
+}
+
+// sightingBatch 一批扫描结果，发布到扫描结果主题
+type sightingBatch struct {
+	Device    string         `json:"device"`
+	Count     int            `json:"count"`
+	Sightings []ble.Sighting `json:"sightings"`
+	Timestamp int64          `json:"timestamp"`
+}
+
+// scanOptions 将扫描配置转换为扫描参数
+func scanOptions(cfg config.BLEScanConfig) ble.ScanOptions {
+	return ble.ScanOptions{
+		Active:   cfg.Active,
+		Interval: cfg.Interval,
+		Window:   cfg.Window,
+		Filter: ble.ScanFilter{
+			NamePrefixes:    cfg.NamePrefixes,
+			ServiceUUIDs:    cfg.ServiceUUIDs,
+			ManufacturerIDs: cfg.ManufacturerIDs,
+			MinRSSI:         cfg.MinRSSI,
+		},
+		TagInterval:   cfg.TagIntervalDuration(),
+		BatchSize:     cfg.BatchSize,
+		BatchInterval: cfg.BatchIntervalDuration(),
+	}
+}
+
+// startScan 按配置开始持续扫描，扫描结果按批发布，并交给在场判定引擎（已开启时）
+func (d *Driver) startScan(deviceName string, sc scanner, cfg config.BLEScanConfig) error {
+	return sc.StartScan(scanOptions(cfg), func(sightings []ble.Sighting) {
+		d.publishSightings(cfg.Topic, deviceName, sightings)
+		d.observePresence(deviceName, sightings)
+	})
+}
+
+// publishSightings 发布一批扫描结果
+func (d *Driver) publishSightings(topic, deviceName string, sightings []ble.Sighting) {
+	if d.MessageBusClient == nil {
+		return
+	}
+	batch := sightingBatch{
+		Device:    deviceName,
+		Count:     len(sightings),
+		Sightings: sightings,
+		Timestamp: time.Now().UnixNano(),
+	}
+	if err := d.MessageBusClient.Publish(topic, batch); err != nil {
+		d.logger.Errorf("【标签扫描】发布扫描结果失败 ❌: %v", err)
+		return
+	}
+	d.logger.Debugf("【标签扫描】发布扫描结果成功 ✔, device=%s, count=%d", deviceName, len(sightings))
+}
+
+// handleSetScanFilter 修改扫描过滤条件，不写入配置文件，服务重启后恢复为配置文件中的条件。
+// 在场判定未配置标签名单时依靠过滤条件限制虚拟设备的创建，此时不允许清空过滤条件。
+func (d *Driver) handleSetScanFilter(objValue interface{}, sc scanner) error {
+	var f ble.ScanFilter
+	if err := decodeObject(objValue, &f); err != nil {
+		return fmt.Errorf("扫描过滤条件格式错误: %v", err)
+	}
+	d.presenceMu.Lock()
+	unlisted := d.presence != nil && d.presenceTags == nil
+	d.presenceMu.Unlock()
+	if unlisted && len(f.NamePrefixes) == 0 && len(f.ServiceUUIDs) == 0 && len(f.ManufacturerIDs) == 0 {
+		return fmt.Errorf("标签在场判定未配置标签名单，扫描过滤条件不能为空")
+	}
+	return sc.SetScanFilter(f)
+}
+
+// scanStatsToObject 将扫描状态转换为 Object 类型读数所需的结构
+func scanStatsToObject(s ble.ScanStats) map[string]interface{} {
+	return map[string]interface{}{
+		"scanning":    s.Scanning,
+		"reports":     s.Reports,
+		"filtered":    s.Filtered,
+		"rateLimited": s.RateLimited,
+		"published":   s.Published,
+		"tags":        s.Tags,
+		"filter": map[string]interface{}{
+			"namePrefixes":    s.Filter.NamePrefixes,
+			"serviceUUIDs":    s.Filter.ServiceUUIDs,
+			"manufacturerIds": s.Filter.ManufacturerIDs,
+			"minRssi":         s.Filter.MinRSSI,
+		},
+	}
+}
@@ -0,0 +1,111 @@
+package driver
+
+import (
+	"device-ble/cmd/config"
+	"device-ble/pkg/ble"
+	"fmt"
+	"time"
+)
+
+// advertisingScheduler 支持按时间表广播的 BLE 控制器
+type advertisingScheduler interface {
+	SetAdvertisingSchedule(s ble.AdvertisingSchedule) error
+	SetAdvertisingOverride(mode string, duration time.Duration) error
+	AdvertisingSchedule() (ble.AdvertisingSchedule, ble.AdvertisingOverride)
+	AdvertisingWanted() (want bool, reason string, managed bool)
+	Advertising() bool
+}
+
+// advertisingOverrideRequest SetAdvertisingOverride 的写入内容
+type advertisingOverrideRequest struct {
+	Mode     string `json:"mode"`     // auto / on / off
+	Duration string `json:"duration"` // 有效期，如 2h，为空表示直到下一次修改
+}
+
+// advScheduleFilePath 返回广播时间表文件路径
+func (d *Driver) advScheduleFilePath() string {
+	if d.serviceConfig != nil {
+		return d.serviceConfig.BleUserConfig.AdvertisingScheduleFile
+	}
+	return config.DefaultAdvScheduleFile
+}
+
+// loadAdvertisingSchedule 从时间表文件加载广播时间表，文件不存在时不限制广播时间
+func (d *Driver) loadAdvertisingSchedule(as advertisingScheduler, path string) error {
+	s, ok, err := ble.LoadAdvertisingSchedule(path)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		d.logger.Debugf("广播时间表文件 %s 不存在，不限制广播时间", path)
+		return nil
+	}
+	d.logger.Infof("已加载广播时间表: %s", path)
+	return as.SetAdvertisingSchedule(s)
+}
+
+// handleSetAdvertisingSchedule 更新广播时间表并写入时间表文件
+func (d *Driver) handleSetAdvertisingSchedule(objValue interface{}, as advertisingScheduler) error {
+	var s ble.AdvertisingSchedule
+	if err := decodeObject(objValue, &s); err != nil {
+		return fmt.Errorf("广播时间表格式错误: %v", err)
+	}
+	if err := as.SetAdvertisingSchedule(s); err != nil {
+		return err
+	}
+	if err := ble.SaveAdvertisingSchedule(d.advScheduleFilePath(), s); err != nil {
+		return fmt.Errorf("保存广播时间表失败: %w", err)
+	}
+	return nil
+}
+
+// handleSetAdvertisingOverride 手动开始或停止广播，不写入文件，服务重启后回到时间表控制
+func (d *Driver) handleSetAdvertisingOverride(objValue interface{}, as advertisingScheduler) error {
+	var req advertisingOverrideRequest
+	if err := decodeObject(objValue, &req); err != nil {
+		return fmt.Errorf("广播手动控制格式错误: %v", err)
+	}
+	var duration time.Duration
+	if req.Duration != "" {
+		var err error
+		if duration, err = time.ParseDuration(req.Duration); err != nil || duration < 0 {
+			return fmt.Errorf("广播手动控制有效期格式错误: %q", req.Duration)
+		}
+	}
+	return as.SetAdvertisingOverride(req.Mode, duration)
+}
+
+// publishAdvertisingTransition 发布按时间表或手动控制开始/停止广播的事件
+func (d *Driver) publishAdvertisingTransition(topic string, t ble.AdvertisingTransition) {
+	if d.MessageBusClient == nil {
+		return
+	}
+	if err := d.MessageBusClient.Publish(topic, t); err != nil {
+		d.logger.Errorf("【广播时间表】发布广播切换事件失败 ❌: %v", err)
+		return
+	}
+	d.logger.Debugf("【广播时间表】发布广播切换事件成功 ✔, advertising=%v, reason=%s", t.Advertising, t.Reason)
+}
+
+// advertisingScheduleToObject 将广播时间表与当前状态转换为 Object 类型读数所需的结构
+func advertisingScheduleToObject(as advertisingScheduler) map[string]interface{} {
+	s, o := as.AdvertisingSchedule()
+	want, reason, managed := as.AdvertisingWanted()
+	windows := make([]interface{}, 0, len(s.Windows))
+	for _, w := range s.Windows {
+		windows = append(windows, map[string]interface{}{"days": w.Days, "start": w.Start, "end": w.End})
+	}
+	override := map[string]interface{}{"mode": o.Mode}
+	if !o.Until.IsZero() {
+		override["until"] = o.Until.Format(time.RFC3339)
+	}
+	return map[string]interface{}{
+		"timezone":    s.Timezone,
+		"windows":     windows,
+		"override":    override,
+		"managed":     managed,
+		"wanted":      want,
+		"reason":      reason,
+		"advertising": as.Advertising(),
+	}
+}
@@ -24,19 +24,41 @@ type CommandService struct {
 	Logger           logger.LoggingClient
 	MessageBusClient interfaces.MessageBusClient
 	BleController    interfaces.BLEController
+	Indicate         bool // 命令响应使用 Indication 发送并等待手机确认
 }
 
-// HandleCommand 处理命令分发。
-func (cs *CommandService) HandleCommand(cmd string) {
+// reliableSender 支持 Indication 发送并报告送达结果的 BLE 控制器
+type reliableSender interface {
+	SendJSONWithOptions(data interface{}, opts ble.SendOptions) (ble.DeliveryReport, error)
+}
+
+// reply 向发起命令的中心设备发送响应，来源无法确定时不发送，避免响应被其他中心设备收到；
+// 配置了 Indication 时等待手机逐包确认并记录送达结果
+func (cs *CommandService) reply(connID int, data interface{}) error {
+	if connID == ble.ConnUnknown {
+		cs.Logger.Errorf("【运维】无法确定命令来自哪个连接，不发送响应，避免其他中心设备收到")
+		return fmt.Errorf("无法确定命令来源连接，响应未发送")
+	}
+	rs, ok := cs.BleController.(reliableSender)
+	if !cs.Indicate || !ok {
+		return cs.BleController.SendJSONTo(connID, data)
+	}
+	report, err := rs.SendJSONWithOptions(data, ble.SendOptions{ConnID: connID, Indicate: true})
+	cs.Logger.Infof("【运维】响应 %s 送达结果: mode=%s, status=%s, confirmed=%d/%d", report.ID, report.Mode, report.Status, report.Confirmed, report.Packets)
+	return err
+}
+
+// HandleCommand 处理命令分发，connID 为发起命令的连接，响应只发给该连接。
+func (cs *CommandService) HandleCommand(connID int, cmd string) {
 	if strings.Contains(cmd, "allstatus") {
 		// cmd数据格式：+COMMAND:allstatus
-		cs.Logger.Infof("【运维 — allstatus】开始查询所有设备状态")
+		cs.Logger.Infof("【运维 — allstatus】开始查询所有设备状态, conn=%d", connID)
 		data, err := cs.requestAndParseAll(TopicAllStatusReq, TopicResponse, 5*time.Second)
 		if err != nil {
 			cs.Logger.Errorf("【运维 — allstatus 请求&解析失败: %v", err)
 			return
 		}
-		err = ble.SendJSONOverBLE(cs.BleController.GetQueue(), data)
+		err = cs.reply(connID, data)
 		data = nil
 		if err != nil {
 			cs.Logger.Errorf("【运维 — allstatus】发送响应失败: %v", err)
@@ -60,7 +82,7 @@ func (cs *CommandService) HandleCommand(cmd string) {
 				cs.Logger.Errorf("【运维 —  monitor】 请求&解析失败: %v", err)
 				return
 			}
-			err = ble.SendJSONOverBLE(cs.BleController.GetQueue(), data)
+			err = cs.reply(connID, data)
 			data = nil
 			if err != nil {
 				cs.Logger.Errorf("【运维 —  monitor】发送响应失败: %v", err)
@@ -72,7 +94,7 @@ func (cs *CommandService) HandleCommand(cmd string) {
 		}
 	} else {
 		cs.Logger.Warnf("命名不支持！！")
-		err := ble.SendJSONOverBLE(cs.BleController.GetQueue(), "命名不支持！！")
+		err := cs.reply(connID, "命名不支持！！")
 		if err != nil {
 			cs.Logger.Errorf("【运维——status】发送响应失败: %v", err)
 			return
@@ -145,20 +167,25 @@ func (cs *CommandService) requestAndParseReading(
 type AgentService struct {
 	Logger           logger.LoggingClient
 	MessageBusClient interfaces.MessageBusClient
+	DeviceName       string // 收到数据的模块所属设备，多个模块时区分同一连接索引
 }
 
-// HandleAgentData 处理透明代理数据。
-func (as *AgentService) HandleAgentData(data string) {
+// HandleAgentData 处理透明代理数据，connID 为数据来源连接，无法确定时为 -1。
+func (as *AgentService) HandleAgentData(connID int, data string) {
 	if data == "" {
 		return
 	}
 	type Payload struct {
 		Timestamp int64
+		Device    string
+		ConnID    int
 		Data      string
 	}
-	as.Logger.Infof("【透明代理（↑）】：收到上行数据: %s", data)
+	as.Logger.Infof("【透明代理（↑）】：收到上行数据: device=%s, conn=%d, %s", as.DeviceName, connID, data)
 	p := Payload{
 		Timestamp: time.Now().UnixNano(),
+		Device:    as.DeviceName,
+		ConnID:    connID,
 		Data:      data,
 	}
 	if as.MessageBusClient != nil {
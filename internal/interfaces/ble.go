@@ -21,6 +21,26 @@ type SerialResponse struct {
 	Error error  // 错误信息（如超时、模块错误等）
 }
 
+// LineKind 表示串口读取到的一行数据的类别，由具体模块的 AT 方言决定
+type LineKind int
+
+const (
+	LineData    LineKind = iota // 透明代理数据
+	LineFinal                   // 命令的最终响应（OK、ERROR 等），用于匹配挂起请求
+	LineCommand                 // 终端运维命令（+COMMAND:）
+	LineIgnore                  // 模块输出的无效内容，直接丢弃
+)
+
+// LineParser 按模块的 AT 方言解析串口输出的一行数据，串口队列本身不假定任何输出格式
+type LineParser interface {
+	// Classify 对一行数据分类
+	Classify(line string) LineKind
+	// SplitCommands 将一行运维命令拆分为各条命令的内容
+	SplitCommands(line string) []string
+	// IsError 最终响应是否表示命令执行失败
+	IsError(line string) bool
+}
+
 // SerialPortInterface 定义 SerialQueue 所依赖的串口接口
 type SerialPortInterface interface {
 	Write([]byte) (int, error)
@@ -48,9 +68,49 @@ type SerialQueueInterface interface {
 	SendCommand(command []byte, timeout, readDelay, queueTimeout time.Duration) (string, error)
 	GetResponse(timeout time.Duration) (string, error)
 	GetPort() SerialPortInterface
+	// SetURCHandler 注册主动上报（URC）处理函数，返回 true 表示该行已被消费
+	SetURCHandler(handler func(line string) bool)
+	// SetLineParser 注册按 AT 方言解析输出的函数，未注册时全部输出按透明代理数据处理
+	SetLineParser(parser LineParser)
+	// SetWakePreamble 设置模块休眠后唤醒所用的前导数据与等待就绪时间
+	SetWakePreamble(preamble []byte, settle time.Duration)
+	// MarkAsleep 标记模块已休眠，下一条命令写入前自动发送唤醒前导
+	MarkAsleep()
+	// Asleep 返回模块是否处于休眠
+	Asleep() bool
 	Close() error
 }
 
+// BLEConnection 表示一个已连接的中心设备（手机等）
+type BLEConnection struct {
+	ConnID         int       `json:"connId"`                   // 模块分配的连接索引
+	PeerAddr       string    `json:"peerAddr"`                 // 对端 MAC 地址
+	MTU            int       `json:"mtu,omitempty"`            // 协商后的 ATT MTU，0 表示未知
+	Encrypted      bool      `json:"encrypted"`                // 链路是否已加密
+	IntervalMs     float64   `json:"intervalMs,omitempty"`     // 当前连接间隔（ms），0 表示未知
+	Latency        int       `json:"latency,omitempty"`        // 当前从机延迟
+	TimeoutMs      int       `json:"timeoutMs,omitempty"`      // 当前监督超时（ms）
+	RSSI           int       `json:"rssi,omitempty"`           // 最近一次查询的信号强度（dBm），0 表示未知
+	RSSIAt         time.Time `json:"rssiAt,omitempty"`         // 最近一次查询信号强度的时间
+	ConnectedAt    time.Time `json:"connectedAt"`              // 建立连接时间
+	DisconnectedAt time.Time `json:"disconnectedAt,omitempty"` // 断开连接时间（仅断开事件中有效）
+	Reason         string    `json:"reason,omitempty"`         // 断开原因（仅断开事件中有效）
+}
+
+// BLE 连接生命周期事件类型
+const (
+	BLEConnectionEventConnected    = "connected"
+	BLEConnectionEventDisconnected = "disconnected"
+	BLEConnectionEventRejected     = "rejected" // 对端不在访问控制名单允许范围内，连接被拒绝
+)
+
+// BLEConnectionEvent 表示一次连接或断开事件，用于发布到消息总线
+type BLEConnectionEvent struct {
+	Type       string        `json:"type"`       // connected / disconnected / rejected
+	Connection BLEConnection `json:"connection"` // 事件对应的连接信息
+	Timestamp  int64         `json:"timestamp"`  // 事件发生时间（纳秒）
+}
+
 type BLEController interface {
 	Close() error
 	InitializeAsPeripheral() error
@@ -58,5 +118,17 @@ type BLEController interface {
 	SendSingle(cmd string) error
 	SendMulti(cmds []string) error
 	SendSingleWithResponse(cmd string) (res string, err error)
-	GetQueue() SerialQueueInterface // 返回串口队列，具体类型由实现决定
+	UpdateAdvertising(adv, scanRsp []byte) error          // 更新广播数据与扫描响应数据
+	GetQueue() SerialQueueInterface                       // 返回串口队列，具体类型由实现决定
+	Connections() []BLEConnection                         // 返回当前已连接的中心设备列表
+	MTU(connID int) int                                   // 返回指定连接协商后的 MTU，未知时返回安全默认值
+	PeripheralInitCommands(name string) ([]string, error) // 按方言生成外围设备初始化命令序列
+	SetTxPower(txpower int8) error                        // 设置发射功率
+	SetBaud(baud int64) error                             // 设置模块串口波特率
+	SendString(value string) error                        // 通过 Notify 向所有连接发送短字符串
+	SendJSON(data interface{}) error                      // 分包向所有连接广播 JSON 数据
+	SendJSONTo(connID int, data interface{}) error        // 分包向指定连接发送 JSON 数据
+	ResolveConn() int                                     // 为不带连接索引的上行数据确定来源连接，无法确定时返回 -1
+	QueryVersion() (string, error)                        // 查询模块固件版本
+	QueryAddress() (string, error)                        // 查询模块蓝牙地址
 }
@@ -0,0 +1,208 @@
+package ble
+
+import (
+	"device-ble/internal/interfaces"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// 中心设备访问控制模式
+const (
+	AccessModeOff       = "off"       // 不限制
+	AccessModeAllowlist = "allowlist" // 只允许名单内的设备连接
+	AccessModeDenylist  = "denylist"  // 拒绝名单内的设备连接
+)
+
+// AccessList 中心设备访问控制名单。
+// 手机默认使用随机私有地址，按 MAC 控制只对已绑定（可解析出身份地址）或使用公共地址的设备可靠。
+type AccessList struct {
+	Mode      string   `json:"mode"`      // off / allowlist / denylist
+	Addresses []string `json:"addresses"` // 对端 MAC 地址
+}
+
+// Normalize 校验名单并统一地址格式（大写、去重），模式为空时视为 off
+func (l AccessList) Normalize() (AccessList, error) {
+	out := AccessList{Mode: strings.ToLower(strings.TrimSpace(l.Mode))}
+	switch out.Mode {
+	case "":
+		out.Mode = AccessModeOff
+	case AccessModeOff, AccessModeAllowlist, AccessModeDenylist:
+	default:
+		return AccessList{}, fmt.Errorf("unsupported access mode %q", l.Mode)
+	}
+	seen := make(map[string]bool, len(l.Addresses))
+	out.Addresses = make([]string, 0, len(l.Addresses))
+	for _, addr := range l.Addresses {
+		addr = strings.ToUpper(strings.TrimSpace(addr))
+		if !isMACAddress(addr) {
+			return AccessList{}, fmt.Errorf("invalid peer address: %s", addr)
+		}
+		if !seen[addr] {
+			seen[addr] = true
+			out.Addresses = append(out.Addresses, addr)
+		}
+	}
+	return out, nil
+}
+
+// Allows 判断指定地址的设备是否允许连接
+func (l AccessList) Allows(addr string) bool {
+	listed := false
+	for _, a := range l.Addresses {
+		if strings.EqualFold(a, addr) {
+			listed = true
+			break
+		}
+	}
+	switch l.Mode {
+	case AccessModeAllowlist:
+		return listed
+	case AccessModeDenylist:
+		return !listed
+	}
+	return true
+}
+
+// LoadAccessList 从 JSON 文件读取访问控制名单，文件不存在时返回 false
+func LoadAccessList(path string) (AccessList, bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return AccessList{}, false, nil
+	}
+	if err != nil {
+		return AccessList{}, false, err
+	}
+	var list AccessList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return AccessList{}, false, fmt.Errorf("invalid access list %s: %v", path, err)
+	}
+	if list, err = list.Normalize(); err != nil {
+		return AccessList{}, false, fmt.Errorf("invalid access list %s: %v", path, err)
+	}
+	return list, true, nil
+}
+
+// SaveAccessList 将访问控制名单写入 JSON 文件
+func SaveAccessList(path string, list AccessList) error {
+	return writeJSONFile(path, list)
+}
+
+// ApplyAccessList 更新中心设备访问控制名单。
+// 白名单优先下发到模块的连接过滤名单，由模块直接拒绝名单外的设备；模块不支持或下发失败时，
+// 以及黑名单模式下，由服务端在收到连接上报后立即断开不允许的设备。
+func (c *BLEController) ApplyAccessList(list AccessList) error {
+	list, err := list.Normalize()
+	if err != nil {
+		return err
+	}
+
+	c.aclMu.RLock()
+	wasFiltered := c.aclFiltered
+	c.aclMu.RUnlock()
+
+	filtered := false
+	if list.Mode == AccessModeAllowlist {
+		filtered = c.applyFilterList(list.Addresses)
+	} else if wasFiltered {
+		c.disableFilterList()
+	}
+
+	c.aclMu.Lock()
+	c.acl = list
+	c.aclFiltered = filtered
+	c.aclMu.Unlock()
+
+	enforcement := "service"
+	if filtered {
+		enforcement = "module"
+	}
+	c.logger.Infof("🛡️ 访问控制名单已更新: mode=%s, addresses=%d, enforcement=%s", list.Mode, len(list.Addresses), enforcement)
+	return nil
+}
+
+// AccessControl 返回当前的访问控制名单，以及名单是否已由模块的连接过滤名单执行。
+func (c *BLEController) AccessControl() (AccessList, bool) {
+	c.aclMu.RLock()
+	defer c.aclMu.RUnlock()
+	list := c.acl
+	list.Addresses = append([]string(nil), c.acl.Addresses...)
+	return list, c.aclFiltered
+}
+
+// applyFilterList 将白名单下发到模块的连接过滤名单，返回模块是否已开启过滤
+func (c *BLEController) applyFilterList(addrs []string) bool {
+	ops := []opArgs{opOf(OpClearFilterList)}
+	for _, addr := range addrs {
+		ops = append(ops, opOf(OpAddFilterAddr, addr))
+	}
+	ops = append(ops, opOf(OpSetFilterPolicy, true))
+	// 广播进行中不能修改过滤名单
+	advertising := c.State() == StateAdvertising
+	if advertising {
+		ops = append([]opArgs{opOf(OpStopAdvertising)}, append(ops, opOf(OpStartAdvertising))...)
+	}
+	cmds, err := c.buildAll(ops...)
+	if err != nil {
+		if isUnsupported(err) {
+			c.logger.Infof("模块不支持连接过滤名单，由服务端断开名单外的设备")
+		} else {
+			c.logger.Warnf("生成连接过滤名单命令失败，由服务端断开名单外的设备: %v", err)
+		}
+		return false
+	}
+	if err := c.SendMulti(cmds); err != nil {
+		c.logger.Warnf("下发连接过滤名单失败，由服务端断开名单外的设备: %v", err)
+		if advertising {
+			go c.restartAdvertising()
+		}
+		return false
+	}
+	return true
+}
+
+// disableFilterList 关闭模块的连接过滤
+func (c *BLEController) disableFilterList() {
+	cmd, err := c.build(OpSetFilterPolicy, false)
+	if err == nil {
+		err = c.SendSingle(cmd)
+	}
+	if err != nil {
+		c.logger.Warnf("关闭模块连接过滤失败: %v", err)
+	}
+}
+
+// allowsPeer 判断对端是否允许连接。模块已执行过滤时仍在服务端再检查一次，
+// 模块意外复位丢失过滤名单后依然有效。
+func (c *BLEController) allowsPeer(addr string) bool {
+	c.aclMu.RLock()
+	defer c.aclMu.RUnlock()
+	return c.acl.Allows(addr)
+}
+
+// rejectConnection 断开不允许连接的对端并发布拒绝事件。
+// 被拒绝的连接不计入连接列表，其断开上报也不再发布断开事件。
+func (c *BLEController) rejectConnection(conn interfaces.BLEConnection, handler func(interfaces.BLEConnectionEvent)) {
+	c.connMu.Lock()
+	c.rejected[conn.ConnID] = true
+	c.connMu.Unlock()
+
+	c.logger.Warnf("⛔️ 拒绝访问控制名单外的中心设备: conn=%d, addr=%s", conn.ConnID, conn.PeerAddr)
+	conn.Reason = "不在访问控制名单允许范围内"
+	c.emitConnectionEvent(handler, interfaces.BLEConnectionEventRejected, conn)
+
+	cmd, err := c.build(OpDisconnect, conn.ConnID)
+	if err != nil {
+		c.logger.Errorf("生成断开命令失败: %v", err)
+		return
+	}
+	// 被拒绝的连接未进入连接状态，直接经串口队列发送，不做状态检查
+	go func() {
+		if _, err := c.Queue.SendCommand([]byte(cmd), 2*time.Second, 1*time.Millisecond, 100*time.Millisecond); err != nil {
+			c.logger.Errorf("断开被拒绝的连接 %d 失败: %v", conn.ConnID, err)
+		}
+	}()
+}
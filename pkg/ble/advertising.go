@@ -0,0 +1,298 @@
+package ble
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// AD 类型（Bluetooth Assigned Numbers, Generic Access Profile）
+const (
+	ADTypeFlags                byte = 0x01
+	ADTypeIncompleteUUID16     byte = 0x02
+	ADTypeCompleteUUID16       byte = 0x03
+	ADTypeIncompleteUUID128    byte = 0x06
+	ADTypeCompleteUUID128      byte = 0x07
+	ADTypeShortName            byte = 0x08
+	ADTypeCompleteName         byte = 0x09
+	ADTypeTxPower              byte = 0x0A
+	ADTypeServiceData16        byte = 0x16
+	ADTypeServiceData128       byte = 0x21
+	ADTypeManufacturerSpecific byte = 0xFF
+)
+
+// 广播 Flags 位定义
+const (
+	FlagLELimitedDiscoverable byte = 0x01
+	FlagLEGeneralDiscoverable byte = 0x02
+	FlagBREDRNotSupported     byte = 0x04
+
+	// DefaultAdvertisingFlags 通用可发现、不支持 BR/EDR
+	DefaultAdvertisingFlags = FlagLEGeneralDiscoverable | FlagBREDRNotSupported
+)
+
+// MaxAdvertisingDataLen 传统广播包与扫描响应包的最大长度
+const MaxAdvertisingDataLen = 31
+
+// ADStructure 表示广播数据中的一个 AD 结构：长度(1) + 类型(1) + 数据
+type ADStructure struct {
+	Type byte
+	Data []byte
+}
+
+// AdvertisingData 广播数据构建器，出错后后续调用不再生效，错误在 Bytes 时返回
+type AdvertisingData struct {
+	structures []ADStructure
+	err        error
+}
+
+// NewAdvertisingData 创建空的广播数据构建器。
+func NewAdvertisingData() *AdvertisingData {
+	return &AdvertisingData{}
+}
+
+// add 追加一个 AD 结构
+func (a *AdvertisingData) add(adType byte, data []byte) *AdvertisingData {
+	if a.err != nil {
+		return a
+	}
+	if len(data) > MaxAdvertisingDataLen-2 {
+		a.err = fmt.Errorf("AD type 0x%02X data too long: %d bytes", adType, len(data))
+		return a
+	}
+	a.structures = append(a.structures, ADStructure{Type: adType, Data: data})
+	return a
+}
+
+// Flags 添加广播 Flags。
+func (a *AdvertisingData) Flags(flags byte) *AdvertisingData {
+	return a.add(ADTypeFlags, []byte{flags})
+}
+
+// CompleteName 添加完整设备名称。
+func (a *AdvertisingData) CompleteName(name string) *AdvertisingData {
+	if name == "" {
+		return a
+	}
+	return a.add(ADTypeCompleteName, []byte(name))
+}
+
+// ShortName 添加缩短的设备名称。
+func (a *AdvertisingData) ShortName(name string) *AdvertisingData {
+	if name == "" {
+		return a
+	}
+	return a.add(ADTypeShortName, []byte(name))
+}
+
+// ServiceUUIDs16 添加 16 位服务 UUID 列表，complete 表示列表是否完整。
+func (a *AdvertisingData) ServiceUUIDs16(complete bool, uuids ...string) *AdvertisingData {
+	if a.err != nil || len(uuids) == 0 {
+		return a
+	}
+	var data []byte
+	for _, u := range uuids {
+		b, err := ParseUUID(u)
+		if err != nil {
+			a.err = err
+			return a
+		}
+		if len(b) != 2 {
+			a.err = fmt.Errorf("%q is not a 16-bit UUID", u)
+			return a
+		}
+		data = append(data, b...)
+	}
+	if complete {
+		return a.add(ADTypeCompleteUUID16, data)
+	}
+	return a.add(ADTypeIncompleteUUID16, data)
+}
+
+// ServiceUUIDs128 添加 128 位服务 UUID 列表，complete 表示列表是否完整。
+func (a *AdvertisingData) ServiceUUIDs128(complete bool, uuids ...string) *AdvertisingData {
+	if a.err != nil || len(uuids) == 0 {
+		return a
+	}
+	var data []byte
+	for _, u := range uuids {
+		b, err := ParseUUID(u)
+		if err != nil {
+			a.err = err
+			return a
+		}
+		if len(b) != 16 {
+			a.err = fmt.Errorf("%q is not a 128-bit UUID", u)
+			return a
+		}
+		data = append(data, b...)
+	}
+	if complete {
+		return a.add(ADTypeCompleteUUID128, data)
+	}
+	return a.add(ADTypeIncompleteUUID128, data)
+}
+
+// ManufacturerData 添加厂商自定义数据，companyID 为 Bluetooth SIG 分配的公司标识。
+func (a *AdvertisingData) ManufacturerData(companyID uint16, payload []byte) *AdvertisingData {
+	data := make([]byte, 2, 2+len(payload))
+	binary.LittleEndian.PutUint16(data, companyID)
+	return a.add(ADTypeManufacturerSpecific, append(data, payload...))
+}
+
+// TxPower 添加发射功率等级（dBm）。
+func (a *AdvertisingData) TxPower(dbm int8) *AdvertisingData {
+	return a.add(ADTypeTxPower, []byte{byte(dbm)})
+}
+
+// ServiceData 添加服务数据，uuid 可以是 16 位或 128 位。
+func (a *AdvertisingData) ServiceData(uuid string, payload []byte) *AdvertisingData {
+	if a.err != nil {
+		return a
+	}
+	b, err := ParseUUID(uuid)
+	if err != nil {
+		a.err = err
+		return a
+	}
+	data := append(b, payload...)
+	if len(b) == 2 {
+		return a.add(ADTypeServiceData16, data)
+	}
+	return a.add(ADTypeServiceData128, data)
+}
+
+// Bytes 编码全部 AD 结构，并校验总长度不超过 31 字节。
+func (a *AdvertisingData) Bytes() ([]byte, error) {
+	if a.err != nil {
+		return nil, a.err
+	}
+	var out []byte
+	for _, s := range a.structures {
+		out = append(out, byte(len(s.Data)+1), s.Type)
+		out = append(out, s.Data...)
+	}
+	if len(out) > MaxAdvertisingDataLen {
+		return nil, fmt.Errorf("advertising data too long: %d bytes, max %d", len(out), MaxAdvertisingDataLen)
+	}
+	return out, nil
+}
+
+// ParseADStructures 解析广播数据中的 AD 结构，遇到长度为 0 的结构（填充）时结束。
+func ParseADStructures(data []byte) ([]ADStructure, error) {
+	var list []ADStructure
+	for i := 0; i < len(data); {
+		n := int(data[i])
+		if n == 0 {
+			break
+		}
+		if i+1+n > len(data) {
+			return list, fmt.Errorf("AD structure at offset %d overruns data: length %d, remaining %d", i, n, len(data)-i-1)
+		}
+		list = append(list, ADStructure{Type: data[i+1], Data: data[i+2 : i+1+n]})
+		i += 1 + n
+	}
+	return list, nil
+}
+
+// FormatUUID 将空口小端字节序的 16 位或 128 位 UUID 转换为字符串，128 位 UUID 带连字符。
+func FormatUUID(b []byte) string {
+	r := make([]byte, len(b))
+	for i := range b {
+		r[len(b)-1-i] = b[i]
+	}
+	s := hex.EncodeToString(r)
+	if len(s) != 32 {
+		return s
+	}
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:32]
+}
+
+// ParseUUID 将 16 位（如 "fff1"）或 128 位（带或不带连字符）UUID 转换为空口使用的小端字节序。
+func ParseUUID(uuid string) ([]byte, error) {
+	s := strings.ReplaceAll(strings.TrimPrefix(strings.ToLower(uuid), "0x"), "-", "")
+	if len(s) != 4 && len(s) != 32 {
+		return nil, fmt.Errorf("invalid UUID %q: must be 16-bit or 128-bit", uuid)
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid UUID %q: %v", uuid, err)
+	}
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return b, nil
+}
+
+// ServiceDataConfig 服务数据配置
+type ServiceDataConfig struct {
+	UUID string `json:"uuid"`
+	Data string `json:"data"` // 十六进制字符串
+}
+
+// AdvertisingConfig 广播包或扫描响应包的内容配置，可由写资源的 Object 值直接解析得到
+type AdvertisingConfig struct {
+	Flags            *uint8              `json:"flags,omitempty"`
+	Name             string              `json:"name,omitempty"`
+	ShortName        string              `json:"shortName,omitempty"`
+	UUID16           []string            `json:"uuid16,omitempty"`
+	UUID128          []string            `json:"uuid128,omitempty"`
+	ManufacturerID   *uint16             `json:"manufacturerId,omitempty"`
+	ManufacturerData string              `json:"manufacturerData,omitempty"` // 十六进制字符串
+	TxPower          *int8               `json:"txPower,omitempty"`
+	ServiceData      []ServiceDataConfig `json:"serviceData,omitempty"`
+}
+
+// AdvertisingPayload 广播包与可选的扫描响应包
+type AdvertisingPayload struct {
+	Advertising  AdvertisingConfig  `json:"advertising"`
+	ScanResponse *AdvertisingConfig `json:"scanResponse,omitempty"`
+}
+
+// Build 根据配置生成广播数据，withFlags 为 true 时未配置 Flags 则使用默认值（扫描响应包不应携带 Flags）。
+func (cfg AdvertisingConfig) Build(withFlags bool) ([]byte, error) {
+	ad := NewAdvertisingData()
+	if cfg.Flags != nil {
+		ad.Flags(*cfg.Flags)
+	} else if withFlags {
+		ad.Flags(DefaultAdvertisingFlags)
+	}
+	ad.CompleteName(cfg.Name).
+		ShortName(cfg.ShortName).
+		ServiceUUIDs16(true, cfg.UUID16...).
+		ServiceUUIDs128(true, cfg.UUID128...)
+	if cfg.TxPower != nil {
+		ad.TxPower(*cfg.TxPower)
+	}
+	if cfg.ManufacturerID != nil {
+		payload, err := hex.DecodeString(cfg.ManufacturerData)
+		if err != nil {
+			return nil, fmt.Errorf("invalid manufacturerData: %v", err)
+		}
+		ad.ManufacturerData(*cfg.ManufacturerID, payload)
+	} else if cfg.ManufacturerData != "" {
+		return nil, fmt.Errorf("manufacturerData requires manufacturerId")
+	}
+	for _, sd := range cfg.ServiceData {
+		payload, err := hex.DecodeString(sd.Data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid serviceData for %s: %v", sd.UUID, err)
+		}
+		ad.ServiceData(sd.UUID, payload)
+	}
+	return ad.Bytes()
+}
+
+// Encode 生成广播包与扫描响应包，未配置扫描响应时返回的 scanRsp 为 nil。
+func (p AdvertisingPayload) Encode() (adv []byte, scanRsp []byte, err error) {
+	if adv, err = p.Advertising.Build(true); err != nil {
+		return nil, nil, fmt.Errorf("advertising: %w", err)
+	}
+	if p.ScanResponse != nil {
+		if scanRsp, err = p.ScanResponse.Build(false); err != nil {
+			return nil, nil, fmt.Errorf("scanResponse: %w", err)
+		}
+	}
+	return adv, scanRsp, nil
+}
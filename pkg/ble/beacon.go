@@ -0,0 +1,319 @@
+package ble
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 信标模式
+const (
+	BeaconModeOff       = "off"
+	BeaconModeIBeacon   = "ibeacon"
+	BeaconModeEddystone = "eddystone"
+)
+
+// iBeacon 与 Eddystone 协议常量
+const (
+	appleCompanyID        uint16 = 0x004C
+	iBeaconType           byte   = 0x02
+	iBeaconLength         byte   = 0x15
+	eddystoneUUID                = "feaa"
+	eddystoneFrameUID     byte   = 0x00
+	eddystoneFrameURL     byte   = 0x10
+	eddystoneFrameTLM     byte   = 0x20
+	eddystoneTempUnknown  uint16 = 0x8000
+	defaultBeaconSlotTime        = 1000 * time.Millisecond
+)
+
+// eddystoneURLSchemes Eddystone-URL 协议前缀编码
+var eddystoneURLSchemes = []string{"http://www.", "https://www.", "http://", "https://"}
+
+// eddystoneURLExpansions Eddystone-URL 常用后缀编码，下标即编码值
+var eddystoneURLExpansions = []string{
+	".com/", ".org/", ".edu/", ".net/", ".info/", ".biz/", ".gov/",
+	".com", ".org", ".edu", ".net", ".info", ".biz", ".gov",
+}
+
+// IBeacon iBeacon 广播配置
+type IBeacon struct {
+	UUID          string `json:"uuid"`          // 128 位 Proximity UUID
+	Major         uint16 `json:"major"`         // 主标识
+	Minor         uint16 `json:"minor"`         // 次标识
+	MeasuredPower int8   `json:"measuredPower"` // 1 米处的校准 RSSI
+}
+
+// Encode 生成 iBeacon 广播数据（Flags + Apple 厂商数据，共 30 字节）。
+func (b IBeacon) Encode() ([]byte, error) {
+	uuid, err := hex.DecodeString(strings.ReplaceAll(b.UUID, "-", ""))
+	if err != nil || len(uuid) != 16 {
+		return nil, fmt.Errorf("invalid iBeacon UUID %q", b.UUID)
+	}
+	payload := []byte{iBeaconType, iBeaconLength}
+	payload = append(payload, uuid...) // iBeacon 的 UUID、Major、Minor 均为大端序
+	payload = binary.BigEndian.AppendUint16(payload, b.Major)
+	payload = binary.BigEndian.AppendUint16(payload, b.Minor)
+	payload = append(payload, byte(b.MeasuredPower))
+	return NewAdvertisingData().
+		Flags(DefaultAdvertisingFlags).
+		ManufacturerData(appleCompanyID, payload).
+		Bytes()
+}
+
+// EddystoneConfig Eddystone 广播配置，UID、URL、TLM 帧按配置项是否为空决定是否启用
+type EddystoneConfig struct {
+	Namespace string `json:"namespace,omitempty"` // UID 命名空间，10 字节十六进制
+	Instance  string `json:"instance,omitempty"`  // UID 实例，6 字节十六进制
+	URL       string `json:"url,omitempty"`       // URL 帧内容
+	TxPower   int8   `json:"txPower"`             // 0 米处的校准发射功率
+	TLM       bool   `json:"tlm"`                 // 是否发送 TLM 遥测帧
+	BatteryMv uint16 `json:"batteryMv,omitempty"` // TLM 帧电池电压（mV），0 表示无电池
+}
+
+// eddystoneFrame 将 Eddystone 帧封装为广播数据
+func eddystoneFrame(frame []byte) ([]byte, error) {
+	return NewAdvertisingData().
+		Flags(DefaultAdvertisingFlags).
+		ServiceUUIDs16(true, eddystoneUUID).
+		ServiceData(eddystoneUUID, frame).
+		Bytes()
+}
+
+// EncodeUID 生成 Eddystone-UID 帧。
+func (e EddystoneConfig) EncodeUID() ([]byte, error) {
+	namespace, err := hex.DecodeString(e.Namespace)
+	if err != nil || len(namespace) != 10 {
+		return nil, fmt.Errorf("invalid Eddystone namespace %q: must be 10 bytes hex", e.Namespace)
+	}
+	instance, err := hex.DecodeString(e.Instance)
+	if err != nil || len(instance) != 6 {
+		return nil, fmt.Errorf("invalid Eddystone instance %q: must be 6 bytes hex", e.Instance)
+	}
+	frame := []byte{eddystoneFrameUID, byte(e.TxPower)}
+	frame = append(frame, namespace...)
+	frame = append(frame, instance...)
+	frame = append(frame, 0x00, 0x00) // RFU
+	return eddystoneFrame(frame)
+}
+
+// EncodeURL 生成 Eddystone-URL 帧，URL 会按协议进行前缀与后缀压缩。
+func (e EddystoneConfig) EncodeURL() ([]byte, error) {
+	scheme := -1
+	rest := e.URL
+	for i, prefix := range eddystoneURLSchemes {
+		if strings.HasPrefix(e.URL, prefix) {
+			scheme, rest = i, strings.TrimPrefix(e.URL, prefix)
+			break
+		}
+	}
+	if scheme < 0 {
+		return nil, fmt.Errorf("invalid Eddystone URL %q: must start with http:// or https://", e.URL)
+	}
+
+	frame := []byte{eddystoneFrameURL, byte(e.TxPower), byte(scheme)}
+	for len(rest) > 0 {
+		matched := false
+		for code, expansion := range eddystoneURLExpansions {
+			if strings.HasPrefix(rest, expansion) {
+				frame = append(frame, byte(code))
+				rest = rest[len(expansion):]
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			if rest[0] <= 0x20 || rest[0] >= 0x7f {
+				return nil, fmt.Errorf("invalid character in Eddystone URL %q", e.URL)
+			}
+			frame = append(frame, rest[0])
+			rest = rest[1:]
+		}
+	}
+	if len(frame) > 20 {
+		return nil, fmt.Errorf("Eddystone URL %q too long after encoding", e.URL)
+	}
+	return eddystoneFrame(frame)
+}
+
+// EncodeTLM 生成 Eddystone-TLM（未加密）遥测帧，advCount 为已发送广播次数，uptime 为开机时长。
+func (e EddystoneConfig) EncodeTLM(advCount uint32, uptime time.Duration) ([]byte, error) {
+	frame := []byte{eddystoneFrameTLM, 0x00}
+	frame = binary.BigEndian.AppendUint16(frame, e.BatteryMv)
+	frame = binary.BigEndian.AppendUint16(frame, eddystoneTempUnknown)
+	frame = binary.BigEndian.AppendUint32(frame, advCount)
+	frame = binary.BigEndian.AppendUint32(frame, uint32(uptime/(100*time.Millisecond)))
+	return eddystoneFrame(frame)
+}
+
+// BeaconConfig 信标广播配置
+type BeaconConfig struct {
+	Mode       string           `json:"mode"` // off / ibeacon / eddystone
+	IBeacon    *IBeacon         `json:"ibeacon,omitempty"`
+	Eddystone  *EddystoneConfig `json:"eddystone,omitempty"`
+	Interleave bool             `json:"interleave"` // 是否与常规可连接广播交替发送
+	SlotMs     int              `json:"slotMs"`     // 每一帧持续的时长（毫秒），默认 1000
+}
+
+// slotTime 返回每一帧的持续时长
+func (cfg BeaconConfig) slotTime() time.Duration {
+	if cfg.SlotMs <= 0 {
+		return defaultBeaconSlotTime
+	}
+	return time.Duration(cfg.SlotMs) * time.Millisecond
+}
+
+// frameBuilder 生成一帧广播数据，advCount 与 uptime 供 TLM 帧使用
+type frameBuilder func(advCount uint32, uptime time.Duration) ([]byte, error)
+
+// frames 根据配置生成需要轮流发送的信标帧
+func (cfg BeaconConfig) frames() ([]frameBuilder, error) {
+	static := func(data []byte, err error) (frameBuilder, error) {
+		if err != nil {
+			return nil, err
+		}
+		return func(uint32, time.Duration) ([]byte, error) { return data, nil }, nil
+	}
+
+	var builders []frameBuilder
+	switch cfg.Mode {
+	case BeaconModeIBeacon:
+		if cfg.IBeacon == nil {
+			return nil, fmt.Errorf("ibeacon mode requires ibeacon settings")
+		}
+		b, err := static(cfg.IBeacon.Encode())
+		if err != nil {
+			return nil, err
+		}
+		builders = append(builders, b)
+
+	case BeaconModeEddystone:
+		e := cfg.Eddystone
+		if e == nil {
+			return nil, fmt.Errorf("eddystone mode requires eddystone settings")
+		}
+		if e.Namespace != "" || e.Instance != "" {
+			b, err := static(e.EncodeUID())
+			if err != nil {
+				return nil, err
+			}
+			builders = append(builders, b)
+		}
+		if e.URL != "" {
+			b, err := static(e.EncodeURL())
+			if err != nil {
+				return nil, err
+			}
+			builders = append(builders, b)
+		}
+		if e.TLM {
+			builders = append(builders, e.EncodeTLM)
+		}
+		if len(builders) == 0 {
+			return nil, fmt.Errorf("eddystone mode requires at least one of uid, url or tlm")
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported beacon mode %q", cfg.Mode)
+	}
+	return builders, nil
+}
+
+// StartBeacon 按配置开始信标广播。多帧或与常规广播交替时，在后台按时隙轮流切换广播数据。
+func (c *BLEController) StartBeacon(cfg BeaconConfig) error {
+	if cfg.Mode == "" || cfg.Mode == BeaconModeOff {
+		return c.StopBeacon()
+	}
+	builders, err := cfg.frames()
+	if err != nil {
+		return err
+	}
+	if cfg.Interleave {
+		builders = append(builders, c.normalAdvertisingFrame)
+	}
+
+	c.stopBeaconLoop()
+	first, err := builders[0](0, 0)
+	if err != nil {
+		return err
+	}
+	if err := c.applyAdvertising(first, nil); err != nil {
+		return err
+	}
+	if len(builders) > 1 {
+		stop := make(chan struct{})
+		done := make(chan struct{})
+		c.advMu.Lock()
+		c.beaconStop, c.beaconDone = stop, done
+		c.advMu.Unlock()
+		go c.runBeaconLoop(builders, cfg.slotTime(), stop, done)
+	}
+	c.logger.Infof("📡 信标广播已启动: mode=%s, frames=%d, interleave=%v", cfg.Mode, len(builders), cfg.Interleave)
+	return nil
+}
+
+// StopBeacon 停止信标广播并恢复常规可连接广播。
+func (c *BLEController) StopBeacon() error {
+	c.stopBeaconLoop()
+	adv, err := c.normalAdvertisingFrame(0, 0)
+	if err != nil {
+		return err
+	}
+	c.logger.Info("📡 信标广播已停止，恢复常规广播")
+	return c.applyAdvertising(adv, nil)
+}
+
+// stopBeaconLoop 停止后台信标轮播协程，等待其退出后返回，避免其写入的信标帧覆盖随后设置的广播数据
+func (c *BLEController) stopBeaconLoop() {
+	c.advMu.Lock()
+	stop, done := c.beaconStop, c.beaconDone
+	c.beaconStop, c.beaconDone = nil, nil
+	c.advMu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+// runBeaconLoop 按时隙轮流写入各帧广播数据
+func (c *BLEController) runBeaconLoop(builders []frameBuilder, slot time.Duration, stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(slot)
+	defer ticker.Stop()
+	start := time.Now()
+	var advCount uint32
+	for i := 1; ; i++ {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		advCount++
+		data, err := builders[i%len(builders)](advCount, time.Since(start))
+		if err != nil {
+			c.logger.Errorf("生成信标帧失败: %v", err)
+			continue
+		}
+		cmd, err := c.build(OpSetAdvertisingData, data)
+		if err != nil {
+			c.logger.Errorf("生成信标广播命令失败: %v", err)
+			continue
+		}
+		if _, err := c.transmit(cmd, 300*time.Millisecond, 1*time.Millisecond); err != nil {
+			c.logger.Warnf("切换信标帧失败: %v", err)
+		}
+	}
+}
+
+// normalAdvertisingFrame 返回常规可连接广播数据，未设置过时使用默认设备名称
+func (c *BLEController) normalAdvertisingFrame(uint32, time.Duration) ([]byte, error) {
+	c.advMu.Lock()
+	adv := c.advData
+	c.advMu.Unlock()
+	if len(adv) > 0 {
+		return adv, nil
+	}
+	return AdvertisingConfig{Name: DefaultDeviceName}.Build(true)
+}
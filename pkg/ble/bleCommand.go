@@ -2,6 +2,22 @@ package ble
 
 import (
 	"fmt"
+	"strings"
+)
+
+// 外围设备默认配置
+const (
+	DefaultDeviceName         = "QuecHCM111Z" // 模块默认广播的设备名称
+	DefaultServiceUUID        = "fff1"        // 默认 GATT 服务
+	DefaultCharacteristicUUID = "fff2"        // 默认特征值（Read + Notify）
+	DefaultAdvIntervalMs      = 150           // 默认广播间隔（ms）
+)
+
+// BLE 角色
+const (
+	RoleCentral    = 1
+	RolePeripheral = 2
+	RoleMultiRole  = 4
 )
 
 // BLECommand 表示BLE AT命令
@@ -17,7 +33,7 @@ const (
 
 	// 设备初始化命令
 	CommandInitPeripheral BLECommand = "AT+QBLEINIT=2\r\n"
-	CommandSetDeviceName  BLECommand = "AT+QBLENAME=QuecHCM111Z\r\n"
+	CommandSetDeviceName  BLECommand = "AT+QBLENAME=" + DefaultDeviceName + "\r\n"
 
 	// 广播相关命令
 	CommandSetAdvertisingParams BLECommand = "AT+QBLEADVPARAM=150,150\r\n"
@@ -56,6 +72,12 @@ func SetBaud(baud int64) (string, error) {
 	return fmt.Sprintf("AT+QSETBAUD=%d\r\n", baud), nil
 }
 
+// QueryBaud 生成查询串口波特率的 AT 命令
+// 未经实机确认，quectel 方言下以 ErrUnsupportedByFirmware 拒绝，仅 quectel-extended 方言发送
+func QueryBaud() string {
+	return "AT+QSETBAUD?\r\n"
+}
+
 // SetTxPower 生成发送功率，限制值的大小
 func SetTxPower(txpower int8) (string, error) {
 	if txpower > 10 || txpower < -16 {
@@ -76,21 +98,68 @@ func Init(role int) (string, error) {
 	return fmt.Sprintf("AT+QBLEINIT=%d\r\n", role), nil
 }
 
+// QueryRole 生成查询 BLE 角色的 AT 命令，未初始化时模块返回 0
+// 未经实机确认，quectel 方言下以 ErrUnsupportedByFirmware 拒绝，仅 quectel-extended 方言发送
+func QueryRole() string {
+	return "AT+QBLEINIT?\r\n"
+}
+
 // SetDeviceName 生成设置设备名称的 AT 命令
 func SetDeviceName(name string) (string, error) {
-	if name == "" {
-		return "", fmt.Errorf("device name cannot be empty")
+	if err := ValidateDeviceName(name); err != nil {
+		return "", err
 	}
 	return fmt.Sprintf("AT+QBLENAME=%s\r\n", name), nil
 }
 
+// QueryDeviceName 生成查询设备名称的 AT 命令
+// 未经实机确认，quectel 方言下以 ErrUnsupportedByFirmware 拒绝，仅 quectel-extended 方言发送
+func QueryDeviceName() string {
+	return "AT+QBLENAME?\r\n"
+}
+
+// QueryTxPower 生成查询发射功率的 AT 命令
+// 未经实机确认，quectel 方言下以 ErrUnsupportedByFirmware 拒绝，仅 quectel-extended 方言发送
+func QueryTxPower() string {
+	return "AT+QTXPOWER?\r\n"
+}
+
 // QueryAddress 生成查询 BLE MAC 地址的 AT 命令
 func QueryAddress() string {
 	return "AT+QBLEADDR?\r\n"
 }
 
+// QueryMTU 生成查询指定连接协商 MTU 的 AT 命令，结果以 +QBLEMTU 上报
+// 未经实机确认，quectel 方言下以 ErrUnsupportedByFirmware 拒绝，仅 quectel-extended 方言发送
+func QueryMTU(connID int) (string, error) {
+	if connID < 0 {
+		return "", fmt.Errorf("invalid connection index: %d", connID)
+	}
+	return fmt.Sprintf("AT+QBLEMTU=%d\r\n", connID), nil
+}
+
+// Sleep 生成使模块进入休眠的 AT 命令，模块应答 OK 后休眠，广播与连接由协议栈继续维持
+// 未经实机确认，quectel 方言下以 ErrUnsupportedByFirmware 拒绝，仅 quectel-extended 方言发送
+func Sleep() string {
+	return "AT+QSLEEP\r\n"
+}
+
 // --- 广播控制 ---
 
+// SetAdvertisingParams 生成设置广播间隔的 AT 命令，单位 ms
+func SetAdvertisingParams(min, max int) (string, error) {
+	if min < 20 || max > 10240 || min > max {
+		return "", fmt.Errorf("invalid advertising interval [%d,%d], must satisfy 20 <= min <= max <= 10240", min, max)
+	}
+	return fmt.Sprintf("AT+QBLEADVPARAM=%d,%d\r\n", min, max), nil
+}
+
+// QueryAdvertisingParams 生成查询广播间隔的 AT 命令
+// 未经实机确认，quectel 方言下以 ErrUnsupportedByFirmware 拒绝，仅 quectel-extended 方言发送
+func QueryAdvertisingParams() string {
+	return "AT+QBLEADVPARAM?\r\n"
+}
+
 // StartAdvertising 生成启动广播的 AT 命令
 func StartAdvertising() string {
 	return "AT+QBLEADVSTART\r\n"
@@ -101,6 +170,24 @@ func StopAdvertising() string {
 	return "AT+QBLEADVSTOP\r\n"
 }
 
+// SetAdvertisingData 生成设置广播数据的 AT 命令，data 为编码后的 AD 结构
+// 未经实机确认，quectel 方言下以 ErrUnsupportedByFirmware 拒绝，仅 quectel-extended 方言发送
+func SetAdvertisingData(data []byte) (string, error) {
+	if len(data) == 0 || len(data) > MaxAdvertisingDataLen {
+		return "", fmt.Errorf("advertising data length out of range [1,%d]: %d", MaxAdvertisingDataLen, len(data))
+	}
+	return fmt.Sprintf("AT+QBLEADVDATA=%X\r\n", data), nil
+}
+
+// SetScanResponseData 生成设置扫描响应数据的 AT 命令，data 为编码后的 AD 结构
+// 未经实机确认，quectel 方言下以 ErrUnsupportedByFirmware 拒绝，仅 quectel-extended 方言发送
+func SetScanResponseData(data []byte) (string, error) {
+	if len(data) == 0 || len(data) > MaxAdvertisingDataLen {
+		return "", fmt.Errorf("scan response data length out of range [1,%d]: %d", MaxAdvertisingDataLen, len(data))
+	}
+	return fmt.Sprintf("AT+QBLESCANRSPDATA=%X\r\n", data), nil
+}
+
 // --- GATT 服务端 ---
 
 // AddService 生成添加 GATT 服务的 AT 命令
@@ -111,12 +198,33 @@ func AddService(uuid string) (string, error) {
 	return fmt.Sprintf("AT+QBLEGATTSSRV=%s\r\n", uuid), nil
 }
 
-// AddCharacteristic 生成添加特征值的 AT 命令
-func AddCharacteristic(uuid string) (string, error) {
+// CharProperty 特征值属性，取值与 GATT 规范 Characteristic Properties 字段的位定义一致
+type CharProperty uint8
+
+const (
+	CharPropRead            CharProperty = 0x02
+	CharPropWriteNoResponse CharProperty = 0x04
+	CharPropWrite           CharProperty = 0x08
+	CharPropNotify          CharProperty = 0x10
+	CharPropIndicate        CharProperty = 0x20
+)
+
+// charPropertyMask 全部已定义的属性位
+const charPropertyMask = CharPropRead | CharPropWriteNoResponse | CharPropWrite | CharPropNotify | CharPropIndicate
+
+// AddCharacteristic 生成添加特征值的 AT 命令。props 为 0 时不指定属性，由模块使用默认属性；
+// 指定属性时以十六进制属性位追加在 UUID 之后。该参数格式未经 HCM111Z AT 手册确认，需对照实机核实。
+func AddCharacteristic(uuid string, props CharProperty) (string, error) {
 	if uuid == "" {
 		return "", fmt.Errorf("UUID cannot be empty")
 	}
-	return fmt.Sprintf("AT+QBLEGATTSCHAR=%s\r\n", uuid), nil
+	if props&^charPropertyMask != 0 {
+		return "", fmt.Errorf("invalid characteristic properties: 0x%02X", uint8(props))
+	}
+	if props == 0 {
+		return fmt.Sprintf("AT+QBLEGATTSCHAR=%s\r\n", uuid), nil
+	}
+	return fmt.Sprintf("AT+QBLEGATTSCHAR=%s,%02X\r\n", uuid, uint8(props)), nil
 }
 
 // FinishGATTServer 生成提交 GATT 服务定义的 AT 命令
@@ -124,13 +232,228 @@ func FinishGATTServer() string {
 	return "AT+QBLEGATTSSRVDONE\r\n"
 }
 
-// SendNotify 生成发送 Notify 通知的 AT 命令
+// SendNotify 生成向连接 0 发送 Notify 通知的 AT 命令
 func SendNotify(handle string, value string) (string, error) {
+	return SendNotifyTo(0, handle, value)
+}
+
+// SendNotifyTo 生成向指定连接发送 Notify 通知的 AT 命令
+func SendNotifyTo(connID int, handle string, value string) (string, error) {
 	if handle <= "" {
 		return "", fmt.Errorf("invalid handle: %s", handle)
 	}
 	if value == "" {
 		return "", fmt.Errorf("value cannot be empty")
 	}
-	return fmt.Sprintf("AT+QBLEGATTSNTFY=0,%s,%s\r\n", handle, value), nil
+	if connID < 0 {
+		return "", fmt.Errorf("invalid connection index: %d", connID)
+	}
+	return fmt.Sprintf("AT+QBLEGATTSNTFY=%d,%s,%s\r\n", connID, handle, value), nil
+}
+
+// SendIndicateTo 生成向指定连接发送 Indication 的 AT 命令，对端确认后模块输出 +QBLEINDCFM 上报
+// 未经实机确认，quectel 方言下以 ErrUnsupportedByFirmware 拒绝，仅 quectel-extended 方言发送
+func SendIndicateTo(connID int, handle string, value string) (string, error) {
+	if handle <= "" {
+		return "", fmt.Errorf("invalid handle: %s", handle)
+	}
+	if value == "" {
+		return "", fmt.Errorf("value cannot be empty")
+	}
+	if connID < 0 {
+		return "", fmt.Errorf("invalid connection index: %d", connID)
+	}
+	return fmt.Sprintf("AT+QBLEGATTSIND=%d,%s,%s\r\n", connID, handle, value), nil
+}
+
+// Disconnect 生成断开指定连接的 AT 命令
+// 未经实机确认，quectel 方言下以 ErrUnsupportedByFirmware 拒绝，仅 quectel-extended 方言发送
+func Disconnect(connID int) (string, error) {
+	if connID < 0 {
+		return "", fmt.Errorf("invalid connection index: %d", connID)
+	}
+	return fmt.Sprintf("AT+QBLEDISCONN=%d\r\n", connID), nil
+}
+
+// UpdateConnParams 生成请求更新连接参数的 AT 命令，生效结果以 +QBLECONNPARAM 上报
+// 未经实机确认，quectel 方言下以 ErrUnsupportedByFirmware 拒绝，仅 quectel-extended 方言发送
+func UpdateConnParams(connID int, p ConnParams) (string, error) {
+	if connID < 0 {
+		return "", fmt.Errorf("invalid connection index: %d", connID)
+	}
+	if err := p.Validate(); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("AT+QBLECONNPARAM=%d,%d,%d,%d,%d\r\n", connID, p.MinInterval, p.MaxInterval, p.Latency, p.Timeout), nil
+}
+
+// QueryRSSI 生成查询指定连接信号强度的 AT 命令，结果以 +QBLERSSI: <conn>,<rssi> 返回
+// 未经实机确认，quectel 方言下以 ErrUnsupportedByFirmware 拒绝，仅 quectel-extended 方言发送
+func QueryRSSI(connID int) (string, error) {
+	if connID < 0 {
+		return "", fmt.Errorf("invalid connection index: %d", connID)
+	}
+	return fmt.Sprintf("AT+QBLERSSI=%d\r\n", connID), nil
+}
+
+// --- 安全与绑定 ---
+
+// SetSecurityMode 生成设置配对安全模式的 AT 命令
+// 未经实机确认，quectel 方言下以 ErrUnsupportedByFirmware 拒绝，仅 quectel-extended 方言发送
+func SetSecurityMode(mode SecurityMode) (string, error) {
+	if mode < SecurityModeNone || mode > SecurityModeStaticPasskey {
+		return "", fmt.Errorf("invalid security mode: %d", mode)
+	}
+	return fmt.Sprintf("AT+QBLESECMODE=%d\r\n", mode), nil
+}
+
+// SetStaticPasskey 生成设置静态配对码的 AT 命令，配对码为 6 位数字
+// 未经实机确认，quectel 方言下以 ErrUnsupportedByFirmware 拒绝，仅 quectel-extended 方言发送
+func SetStaticPasskey(passkey string) (string, error) {
+	if err := validatePasskey(passkey); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("AT+QBLEPASSKEY=%s\r\n", passkey), nil
+}
+
+// ReplyPasskey 生成回复配对码请求的 AT 命令
+// 未经实机确认，quectel 方言下以 ErrUnsupportedByFirmware 拒绝，仅 quectel-extended 方言发送
+func ReplyPasskey(connID int, passkey string) (string, error) {
+	if err := validatePasskey(passkey); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("AT+QBLEPASSKEYREPLY=%d,%s\r\n", connID, passkey), nil
+}
+
+// SetBonding 生成开启或关闭绑定的 AT 命令
+// 未经实机确认，quectel 方言下以 ErrUnsupportedByFirmware 拒绝，仅 quectel-extended 方言发送
+func SetBonding(enabled bool) string {
+	return fmt.Sprintf("AT+QBLEBOND=%d\r\n", boolToInt(enabled))
+}
+
+// SetEncryptionRequired 生成设置是否拒绝未加密访问的 AT 命令
+// 未经实机确认，quectel 方言下以 ErrUnsupportedByFirmware 拒绝，仅 quectel-extended 方言发送
+func SetEncryptionRequired(required bool) string {
+	return fmt.Sprintf("AT+QBLESECREQ=%d\r\n", boolToInt(required))
+}
+
+// QueryBondedPeers 生成查询已绑定设备列表的 AT 命令，结果以 +QBLEBONDLIST 逐行上报
+// 未经实机确认，quectel 方言下以 ErrUnsupportedByFirmware 拒绝，仅 quectel-extended 方言发送
+func QueryBondedPeers() string {
+	return "AT+QBLEBONDLIST?\r\n"
+}
+
+// DeleteBond 生成删除指定已绑定设备的 AT 命令
+// 未经实机确认，quectel 方言下以 ErrUnsupportedByFirmware 拒绝，仅 quectel-extended 方言发送
+func DeleteBond(addr string) (string, error) {
+	if !isMACAddress(addr) {
+		return "", fmt.Errorf("invalid peer address: %s", addr)
+	}
+	return fmt.Sprintf("AT+QBLEBONDDEL=%s\r\n", strings.ToUpper(addr)), nil
+}
+
+// ClearBonds 生成删除全部已绑定设备的 AT 命令
+// 未经实机确认，quectel 方言下以 ErrUnsupportedByFirmware 拒绝，仅 quectel-extended 方言发送
+func ClearBonds() string {
+	return "AT+QBLEBONDCLR\r\n"
+}
+
+// --- 连接过滤名单 ---
+
+// ClearFilterList 生成清空连接过滤名单的 AT 命令
+// 未经实机确认，quectel 方言下以 ErrUnsupportedByFirmware 拒绝，仅 quectel-extended 方言发送
+func ClearFilterList() string {
+	return "AT+QBLEWLCLR\r\n"
+}
+
+// AddFilterAddress 生成向连接过滤名单添加设备地址的 AT 命令
+// 未经实机确认，quectel 方言下以 ErrUnsupportedByFirmware 拒绝，仅 quectel-extended 方言发送
+func AddFilterAddress(addr string) (string, error) {
+	if !isMACAddress(addr) {
+		return "", fmt.Errorf("invalid peer address: %s", addr)
+	}
+	return fmt.Sprintf("AT+QBLEWLADD=%s\r\n", strings.ToUpper(addr)), nil
+}
+
+// SetFilterPolicy 生成开启或关闭连接过滤的 AT 命令，开启后模块只接受名单内设备的连接
+// 未经实机确认，quectel 方言下以 ErrUnsupportedByFirmware 拒绝，仅 quectel-extended 方言发送
+func SetFilterPolicy(enabled bool) string {
+	return fmt.Sprintf("AT+QBLEWLPOLICY=%d\r\n", boolToInt(enabled))
+}
+
+// --- 固件升级 ---
+// 以下命令格式尚未经 HCM111Z 升级协议文档确认，见 upgrade.go 中的镜像格式说明
+
+// MaxFirmwareChunk 单条固件数据命令携带的最大字节数
+const MaxFirmwareChunk = 256
+
+// BeginFirmwareUpgrade 生成开始接收新固件的 AT 命令，模块擦除升级分区后应答 OK
+func BeginFirmwareUpgrade(size int, crc uint32) (string, error) {
+	if size <= 0 {
+		return "", fmt.Errorf("invalid firmware size: %d", size)
+	}
+	return fmt.Sprintf("AT+QFOTA=%d,%08X\r\n", size, crc), nil
+}
+
+// WriteFirmwareChunk 生成写入一段固件数据的 AT 命令，数据以十六进制编码，offset 必须等于模块已接收的长度
+func WriteFirmwareChunk(offset int, data []byte) (string, error) {
+	if offset < 0 {
+		return "", fmt.Errorf("invalid firmware offset: %d", offset)
+	}
+	if len(data) == 0 || len(data) > MaxFirmwareChunk {
+		return "", fmt.Errorf("firmware chunk must be 1-%d bytes, got %d", MaxFirmwareChunk, len(data))
+	}
+	return fmt.Sprintf("AT+QFOTADATA=%d,%X\r\n", offset, data), nil
+}
+
+// QueryFirmwareUpgrade 生成查询固件接收进度的 AT 命令，结果以 +QFOTA: <size>,<crc32>,<received> 返回
+func QueryFirmwareUpgrade() string {
+	return "AT+QFOTA?\r\n"
+}
+
+// ApplyFirmwareUpgrade 生成结束传输的 AT 命令，模块校验通过后应答 OK 并重启进入新固件
+func ApplyFirmwareUpgrade() string {
+	return "AT+QFOTAEND\r\n"
+}
+
+// --- 扫描 ---
+
+// SetScanParams 生成设置扫描参数的 AT 命令，active 为 true 时主动扫描以获取扫描响应包，间隔与窗口单位 ms
+// 未经实机确认，quectel 方言下以 ErrUnsupportedByFirmware 拒绝，仅 quectel-extended 方言发送
+func SetScanParams(active bool, interval, window int) (string, error) {
+	if window < 3 || interval > 10240 || window > interval {
+		return "", fmt.Errorf("invalid scan interval %d / window %d, must satisfy 3 <= window <= interval <= 10240", interval, window)
+	}
+	return fmt.Sprintf("AT+QBLESCANPARAM=%d,%d,%d\r\n", boolToInt(active), interval, window), nil
+}
+
+// StartScan 生成开始扫描的 AT 命令，扫描结果以 +QBLESCAN: <addr_type>,<addr>,<rssi>,<adv_data> 上报
+// 未经实机确认，quectel 方言下以 ErrUnsupportedByFirmware 拒绝，仅 quectel-extended 方言发送
+func StartScan() string {
+	return "AT+QBLESCAN=1\r\n"
+}
+
+// StopScan 生成停止扫描的 AT 命令
+// 未经实机确认，quectel 方言下以 ErrUnsupportedByFirmware 拒绝，仅 quectel-extended 方言发送
+func StopScan() string {
+	return "AT+QBLESCAN=0\r\n"
+}
+
+// MaxCharValueLen 特征值的最大长度，与最大 MTU 247 减去 ATT 头一致，中心设备一次读取即可取得完整值
+const MaxCharValueLen = 244
+
+// SetCharacteristicValue 生成设置特征值内容的 AT 命令，中心设备读取该特征值时由模块直接返回。
+// 内容以十六进制编码，JSON 等内容中的逗号、引号与换行不会破坏命令参数的解析。
+// 未经实机确认，quectel 方言下以 ErrUnsupportedByFirmware 拒绝，仅 quectel-extended 方言发送
+func SetCharacteristicValue(uuid string, value string) (string, error) {
+	if uuid == "" {
+		return "", fmt.Errorf("UUID cannot be empty")
+	}
+	if value == "" {
+		return "", fmt.Errorf("characteristic value cannot be empty")
+	}
+	if len(value) > MaxCharValueLen {
+		return "", fmt.Errorf("characteristic value too long: %d bytes, max %d", len(value), MaxCharValueLen)
+	}
+	return fmt.Sprintf("AT+QBLEGATTSCHARVAL=%s,%X\r\n", uuid, []byte(value)), nil
 }
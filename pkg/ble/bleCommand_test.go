@@ -0,0 +1,50 @@
+package ble
+
+import "testing"
+
+func TestSetCharacteristicValueEncodesValue(t *testing.T) {
+	cmd, err := SetCharacteristicValue("fff3", `{"a":1,"b":"x,y"}`)
+	if err != nil {
+		t.Fatalf("SetCharacteristicValue: %v", err)
+	}
+	want := "AT+QBLEGATTSCHARVAL=fff3,7B2261223A312C2262223A22782C79227D\r\n"
+	if cmd != want {
+		t.Errorf("SetCharacteristicValue = %q, want %q", cmd, want)
+	}
+	if _, err := SetCharacteristicValue("fff3", ""); err == nil {
+		t.Error("SetCharacteristicValue accepted an empty value")
+	}
+}
+
+func TestAddCharacteristicProperties(t *testing.T) {
+	if cmd, err := AddCharacteristic("fff2", 0); err != nil || cmd != "AT+QBLEGATTSCHAR=fff2\r\n" {
+		t.Errorf("AddCharacteristic without properties = %q, %v", cmd, err)
+	}
+	if cmd, err := AddCharacteristic("fff3", CharPropRead|CharPropNotify); err != nil || cmd != "AT+QBLEGATTSCHAR=fff3,12\r\n" {
+		t.Errorf("AddCharacteristic read|notify = %q, %v", cmd, err)
+	}
+	if _, err := AddCharacteristic("fff3", 0x80); err == nil {
+		t.Error("AddCharacteristic accepted an undefined property bit")
+	}
+}
+
+func TestAddCharacteristicToMergesProperties(t *testing.T) {
+	d := DefaultDesiredConfig()
+	if !d.AddCharacteristicTo(DefaultServiceUUID, "fff3", "read") {
+		t.Fatal("adding a new characteristic reported no change")
+	}
+	if d.AddCharacteristicTo(DefaultServiceUUID, "FFF3", "read") {
+		t.Error("adding the same characteristic and property reported a change")
+	}
+	props, err := d.Services[0].CharProperties("fff3")
+	if err != nil || props != CharPropRead {
+		t.Errorf("CharProperties = 0x%02X, %v, want read", uint8(props), err)
+	}
+	if err := d.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+	d.Services[0].Properties["fff3"] = append(d.Services[0].Properties["fff3"], "broadcast")
+	if err := d.Validate(); err == nil {
+		t.Error("Validate accepted an unknown property name")
+	}
+}
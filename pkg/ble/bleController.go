@@ -3,7 +3,7 @@ package ble
 import (
 	"device-ble/internal/interfaces"
 	"device-ble/pkg/uart"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
@@ -11,73 +11,403 @@ import (
 
 // BLEController 蓝牙低功耗控制器，管理BLE设备的初始化、命令发送和状态控制。
 type BLEController struct {
-	Port   *uart.SerialPort
-	Queue  interfaces.SerialQueueInterface
-	logger logger.LoggingClient
+	Port    *uart.SerialPort
+	Queue   interfaces.SerialQueueInterface
+	logger  logger.LoggingClient
+	dialect Dialect // 模块 AT 方言
+
+	// 连接状态
+	connMu          sync.RWMutex
+	connections     map[int]*interfaces.BLEConnection   // 连接索引 -> 连接信息
+	connHandler     func(interfaces.BLEConnectionEvent) // 连接生命周期事件处理函数
+	autoReadvertise bool                                // 断开后是否自动重新广播
+	defaultMTU      int                                 // 未获知协商 MTU 时使用的安全默认值
+	rejected        map[int]bool                        // 因访问控制被拒绝、等待断开的连接索引
+	connTracked     bool                                // 已解析到过连接上报，连接列表可信
+
+	// 携带连接索引的上行数据处理
+	inMu           sync.RWMutex
+	commandHandler func(connID int, cmd string)  // 运维命令
+	dataHandler    func(connID int, data string) // 透明代理数据
+
+	// 广播状态
+	advMu      sync.Mutex
+	advData    []byte        // 最近一次设置的常规广播数据
+	advLive    []byte        // 模块上最近一次成功生效的广播数据
+	advLiveRsp []byte        // 模块上最近一次成功生效的扫描响应数据
+	beaconStop chan struct{} // 信标轮播停止信号
+	beaconDone chan struct{} // 信标轮播协程已退出
+
+	// 链路安全
+	secMu             sync.RWMutex
+	security          SecurityConfig // 当前生效的安全配置
+	enforceEncryption bool           // 模块不支持拒绝未加密访问时由服务端断开未加密连接
+
+	// 中心设备访问控制
+	aclMu       sync.RWMutex
+	acl         AccessList
+	aclFiltered bool // 白名单已下发到模块的连接过滤名单
+
+	// 连接参数自动切换
+	paramMu        sync.Mutex
+	autoConnParams bool                // 多包传输前后是否自动切换连接参数
+	bulkConns      map[int]bool        // 已切换到大数据量参数的连接
+	idleTimers     map[int]*time.Timer // 连接索引 -> 切回空闲参数的定时器
+
+	// 链路质量监控
+	linkMu   sync.Mutex
+	linkStop chan struct{}
+	linkDone chan struct{}
+
+	// 低功耗管理
+	powerMu       sync.Mutex
+	lastActivity  time.Time // 最近一次连接或数据往来的时间
+	lowPower      bool      // 已因空闲放慢广播
+	activeHandler func()    // 退出低功耗广播后的处理函数
+	idleStop      chan struct{}
+	idleDone      chan struct{}
+
+	// 固件升级
+	upgradeMu sync.Mutex
+	upgrading bool
+
+	// 标签扫描
+	scanMu      sync.Mutex
+	multiRole   bool // 以多角色初始化，扫描与外围设备功能同时运行
+	scanning    bool
+	scanLost    bool // 模块复位后扫描已停止，待恢复
+	scanOpts    ScanOptions
+	scanFilter  *compiledScanFilter
+	scanHandler func([]Sighting)
+	scanBatch   []Sighting
+	scanSeen    map[string]time.Time // 标签地址 -> 最近一次上报时间，用于限流
+	scanStats   ScanStats
+	scanFlush   chan struct{}
+	scanStop    chan struct{}
+	scanDone    chan struct{}
+
+	// 可读特征值
+	charMu     sync.Mutex
+	charValues map[string]CharValue // 特征值 UUID -> 当前内容
+	charLost   bool                 // 模块复位后特征值内容已丢失，待恢复
+
+	// 广播时间表
+	schedMu      sync.Mutex
+	schedule     AdvertisingSchedule
+	compiled     *compiledSchedule
+	override     AdvertisingOverride
+	schedStopped bool // 广播由时间表或手动控制停止，取消管理后需要恢复
+	schedStop    chan struct{}
+	schedDone    chan struct{}
+	schedTrigger chan struct{}
+
+	// Indication 确认
+	indSendMu  sync.Mutex // 保证同一时间只有一条消息以 Indication 发送
+	indMu      sync.Mutex
+	indWaiters map[int]chan URC // 连接索引 -> 等待确认的发送方
+
+	// 查询结果收集
+	collectMu  sync.Mutex
+	collectors []*lineCollector
+
+	// 初始化序列
+	initMu     sync.Mutex
+	initPolicy InitPolicy
+	initReport InitReport   // 最近一次初始化序列的执行结果
+	initSetup  func() error // 每次初始化在开始广播之前执行，见 SetInitSetup
+
+	// 模块生命周期状态
+	stateMu    sync.RWMutex
+	state      ModuleState
+	stateSince time.Time
+
+	// 模块复位
+	resetMu        sync.Mutex
+	resetHandler   func(expected bool) // 模块复位处理函数，expected 表示由本服务主动复位
+	resetRequested time.Time           // 最近一次生成复位命令的时间
+
+	// 固件版本与能力，未识别时不做能力检查
+	fwMu     sync.RWMutex
+	firmware *FirmwareVersion
+	caps     *Capabilities
 }
 
-// NewBLEController 创建新的BLE控制器。
-func NewBLEController(port *uart.SerialPort, queue interfaces.SerialQueueInterface, logger logger.LoggingClient) *BLEController {
-	return &BLEController{
-		Port:   port,
-		Queue:  queue,
-		logger: logger,
+// NewBLEController 创建新的BLE控制器，注册模块主动上报的处理函数与方言的行分类规则。
+// dialect 为 nil 时使用默认的 Quectel 方言。
+func NewBLEController(port *uart.SerialPort, queue interfaces.SerialQueueInterface, logger logger.LoggingClient, dialect Dialect) *BLEController {
+	if dialect == nil {
+		dialect = quectelDialect{}
+	}
+	c := &BLEController{
+		Port:        port,
+		Queue:       queue,
+		logger:      logger,
+		dialect:     dialect,
+		connections: make(map[int]*interfaces.BLEConnection),
+		rejected:    make(map[int]bool),
+		indWaiters:  make(map[int]chan URC),
+		bulkConns:   make(map[int]bool),
+		idleTimers:  make(map[int]*time.Timer),
+		acl:         AccessList{Mode: AccessModeOff},
+		defaultMTU:  DefaultMTU,
+		initPolicy:  DefaultInitPolicy,
+		state:       StateUnknown,
+		stateSince:  time.Now(),
+	}
+	c.lastActivity = c.stateSince
+	// 固件版本识别之前按零版本查找能力表，不限版本的能力区间在此时即生效
+	if caps, ok := dialect.Capabilities(FirmwareVersion{}); ok {
+		c.caps = &caps
 	}
+	queue.SetLineParser(dialect)
+	queue.SetWakePreamble(dialect.WakeSequence())
+	queue.SetURCHandler(c.handleURC)
+	return c
+}
+
+// Dialect 返回控制器使用的 AT 方言。
+func (c *BLEController) Dialect() Dialect {
+	return c.dialect
+}
+
+// build 检查固件能力后通过方言生成指定操作的 AT 命令
+func (c *BLEController) build(op Operation, args ...interface{}) (string, error) {
+	if err := c.checkCapability(op, args); err != nil {
+		return "", err
+	}
+	if op == OpReset {
+		c.markResetRequested()
+	}
+	return c.dialect.Build(op, args...)
+}
+
+// buildAll 依次生成多条 AT 命令，任意一条失败即返回错误
+func (c *BLEController) buildAll(ops ...opArgs) ([]string, error) {
+	cmds := make([]string, 0, len(ops))
+	for _, o := range ops {
+		cmd, err := c.build(o.op, o.args...)
+		if err != nil {
+			return nil, err
+		}
+		cmds = append(cmds, cmd)
+	}
+	return cmds, nil
+}
+
+// opArgs 一个待生成的操作及其参数
+type opArgs struct {
+	op   Operation
+	args []interface{}
+}
+
+// opOf 构造 opArgs
+func opOf(o Operation, args ...interface{}) opArgs {
+	return opArgs{op: o, args: args}
+}
+
+// PeripheralInitCommands 生成以指定名称初始化为外围设备的完整命令序列。
+func (c *BLEController) PeripheralInitCommands(name string) ([]string, error) {
+	cfg := DefaultDesiredConfig()
+	cfg.Name = name
+	return c.DesiredInitCommands(cfg, true)
 }
 
 // InitializeAsPeripheral 启动初始化BLE设备为外围设备模式。
 func (c *BLEController) InitializeAsPeripheral() error {
-	initCommands := []BLECommand{
-		CommandReset,
-		CommandInitPeripheral,
-		CommandSetAdvertisingParams,
-		CommandCreateGATTService,
-		CommandCreateGATTCharacteristic,
-		CommandCompleteGATTService,
-		CommandSetDeviceName,
-		CommandStartAdvertising,
-	}
-	for _, cmd := range initCommands {
-		response, err := c.Queue.SendCommand([]byte(cmd), 2*time.Second, 1*time.Second, 100*time.Millisecond)
-		if strings.Contains(response, "OK") {
-			c.logger.Infof("✅ 发送 %q 成功, 回显： %v", cmd, response)
-		} else if strings.Contains(response, "ERROR") {
-			c.logger.Errorf("⛔️  发送 %q 失败: %q , 回显： %v ", cmd, err, response)
-		} else if err != nil {
-			c.logger.Errorf("❗❓未知回显 :%v, response:%v", err, response)
-		} else {
-			c.logger.Warnf("❗❓未知回显, response:%v", response)
-		}
+	return c.InitializeWithConfig(DefaultDesiredConfig())
+}
+
+// InitializeWithConfig 复位模块并按期望配置初始化为外围设备模式。
+func (c *BLEController) InitializeWithConfig(cfg DesiredConfig) error {
+	return c.InitializeWithSetup(cfg, nil)
+}
+
+// InitializeWithSetup 复位模块并按期望配置初始化为外围设备模式，setup 在 GATT 服务与名称配置完成后、
+// 开始广播之前执行，只用于本次初始化；每次初始化都需要的配置通过 SetInitSetup 设置。
+// setup 返回错误时不开始广播。
+func (c *BLEController) InitializeWithSetup(cfg DesiredConfig, setup func() error) error {
+	if _, err := c.DetectFirmware(); err != nil {
+		c.logger.Warnf("❗识别模块固件版本失败，跳过能力检查: %v", err)
+	}
+	initCommands, err := c.DesiredInitCommands(cfg, true)
+	if err != nil {
+		return err
+	}
+	return c.initialize(initCommands, setup)
+}
+
+// CustomInitializeBle 按给定命令序列自定义初始化BLE设备，序列以开始广播结束时在开始广播之前执行 SetInitSetup 设置的配置。
+func (c *BLEController) CustomInitializeBle(cmds []string) error {
+	return c.initialize(cmds, nil)
+}
+
+// initialize 执行初始化命令序列，setup 与 SetInitSetup 设置的配置在开始广播之前依次执行，
+// 保证链路安全等配置在中心设备可以连接之前生效。
+func (c *BLEController) initialize(cmds []string, setup func() error) error {
+	c.initMu.Lock()
+	initSetup := c.initSetup
+	c.initMu.Unlock()
+	if setup == nil && initSetup == nil {
+		return c.runSequence(cmds, nil)
 	}
+	return c.runSequence(cmds, func() error { return runSetups(setup, initSetup) })
+}
 
-	c.logger.Info("BLE设备已成功初始化为外围设备")
+// runSetups 依次执行非空的配置函数，遇到错误即返回
+func runSetups(setups ...func() error) error {
+	for _, setup := range setups {
+		if setup == nil {
+			continue
+		}
+		if err := setup(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-// InitializeAsPeripheral 自定义初始化BLE设备。
-func (c *BLEController) CustomInitializeBle(cmds []string) error {
-	for _, cmd := range cmds {
-		response, err := c.Queue.SendCommand([]byte(cmd), 2*time.Second, 1*time.Second, 100*time.Millisecond)
-		if strings.Contains(response, "OK") {
-			c.logger.Infof("✅ 发送 %q 成功, 回显： %v", cmd, response)
-		} else if strings.Contains(response, "ERROR") {
-			c.logger.Errorf("⛔️  发送 %q 失败: %q , 回显： %v ", cmd, err, response)
-		} else if err != nil {
-			c.logger.Errorf("❗❓未知回显 :%v, response:%v", err, response)
-		} else {
-			c.logger.Warnf("❗❓未知回显, response:%v", response)
+// UpdateAdvertising 运行时更新常规广播内容，信标轮播时会在下一个常规广播时隙生效。
+// scanRsp 为空时不修改扫描响应数据。
+func (c *BLEController) UpdateAdvertising(adv, scanRsp []byte) error {
+	c.advMu.Lock()
+	interleaving := c.beaconStop != nil
+	if interleaving {
+		c.advData = adv
+	}
+	c.advMu.Unlock()
+	if !interleaving {
+		if err := c.applyAdvertising(adv, scanRsp); err != nil {
+			return err
 		}
+		c.advMu.Lock()
+		c.advData = adv
+		c.advMu.Unlock()
+		return nil
+	}
+	if len(scanRsp) == 0 {
+		return nil
+	}
+	cmd, err := c.build(OpSetScanResponseData, scanRsp)
+	if err != nil {
+		return err
 	}
+	return c.SendSingle(cmd)
+}
 
-	c.logger.Info("BLE设备已成功初始化为外围设备")
+// applyAdvertising 停止广播、写入广播数据与扫描响应数据后重新开始广播
+func (c *BLEController) applyAdvertising(adv, scanRsp []byte) error {
+	ops := []opArgs{opOf(OpStopAdvertising), opOf(OpSetAdvertisingData, adv)}
+	if len(scanRsp) > 0 {
+		ops = append(ops, opOf(OpSetScanResponseData, scanRsp))
+	}
+	ops = append(ops, opOf(OpStartAdvertising))
+	cmds, err := c.buildAll(ops...)
+	if err != nil {
+		return err
+	}
+	if err := c.SendMulti(cmds); err != nil {
+		c.resumeAdvertising(err)
+		return err
+	}
+	c.advMu.Lock()
+	c.advLive = adv
+	if len(scanRsp) > 0 {
+		c.advLiveRsp = scanRsp
+	}
+	c.advMu.Unlock()
 	return nil
 }
 
+// resumeAdvertising 更新广播的命令序列中途失败时，以此前生效的广播数据重新开始广播，避免模块停留在已停止广播的状态。
+// 写入新广播数据之前就失败时模块中仍是原有数据，直接开始广播即可。
+func (c *BLEController) resumeAdvertising(cause error) {
+	c.advMu.Lock()
+	adv, scanRsp := c.advLive, c.advLiveRsp
+	c.advMu.Unlock()
+	var ops []opArgs
+	if len(adv) > 0 {
+		ops = append(ops, opOf(OpSetAdvertisingData, adv))
+	}
+	if len(scanRsp) > 0 {
+		ops = append(ops, opOf(OpSetScanResponseData, scanRsp))
+	}
+	ops = append(ops, opOf(OpStartAdvertising))
+	cmds, err := c.buildAll(ops...)
+	if err == nil {
+		err = c.SendMulti(cmds)
+	}
+	if err != nil {
+		c.logger.Errorf("更新广播失败（%v），恢复原有广播也失败: %v", cause, err)
+		return
+	}
+	c.logger.Warnf("更新广播失败（%v），已恢复原有广播", cause)
+}
+
+// SetDeviceName 设置模块广播名称，广播开始前设置即在首次广播中生效。
+func (c *BLEController) SetDeviceName(name string) error {
+	cmd, err := c.build(OpSetName, name)
+	if err != nil {
+		return err
+	}
+	return c.SendSingle(cmd)
+}
+
+// SetTxPower 设置模块发射功率（dBm）。
+func (c *BLEController) SetTxPower(txpower int8) error {
+	cmd, err := c.build(OpSetTxPower, txpower)
+	if err != nil {
+		return err
+	}
+	return c.SendSingle(cmd)
+}
+
+// QueryVersion 查询模块固件版本，返回模块原始应答。
+func (c *BLEController) QueryVersion() (string, error) {
+	cmd, err := c.build(OpVersion)
+	if err != nil {
+		return "", err
+	}
+	return c.SendSingleWithResponse(cmd)
+}
+
+// QueryAddress 查询模块蓝牙地址，返回模块原始应答。
+func (c *BLEController) QueryAddress() (string, error) {
+	cmd, err := c.build(OpQueryAddr)
+	if err != nil {
+		return "", err
+	}
+	return c.SendSingleWithResponse(cmd)
+}
+
+// SetBaud 设置模块串口波特率。
+func (c *BLEController) SetBaud(baud int64) error {
+	cmd, err := c.build(OpSetBaud, baud)
+	if err != nil {
+		return err
+	}
+	return c.SendSingle(cmd)
+}
+
+// SendString 通过默认特征值向所有已连接的中心设备发送一条字符串 Notify，长度受各连接协商的 MTU 限制。
+func (c *BLEController) SendString(value string) error {
+	return c.broadcast(func(connID int) error { return c.SendStringTo(connID, value) })
+}
+
+// SendJSON 将数据序列化为 JSON，按各连接协商的 MTU 分包后广播给所有已连接的中心设备。
+func (c *BLEController) SendJSON(data interface{}) error {
+	return c.broadcast(func(connID int) error { return c.SendJSONTo(connID, data) })
+}
+
 func (c *BLEController) GetQueue() interfaces.SerialQueueInterface {
 	return c.Queue
 }
 
 func (c *BLEController) Close() error {
+	c.StopLinkMonitor()
+	c.StopIdleMonitor()
+	c.StopAdvertisingScheduler()
+	c.stopBeaconLoop()
+	c.stopScanLoop()
 	err := c.Queue.Close()
 	if err != nil {
 		return err
@@ -85,19 +415,39 @@ func (c *BLEController) Close() error {
 	return nil
 }
 
+// checkResponse 解析模块应答并记录日志，模块返回错误时返回 *ResponseError
+func (c *BLEController) checkResponse(cmd, response string) error {
+	r := ParseResponse(response)
+	switch {
+	case r.Code == ResultError:
+		c.logger.Errorf("⛔️  发送 %q 失败: %v , 回显： %v ", cmd, r.Err(), response)
+		return r.Err()
+	case r.Succeeded():
+		c.logger.Infof("✅ 发送 %q 成功, 回显： %v", cmd, response)
+		c.advance(cmd)
+	default:
+		c.logger.Warnf("❗❓  未知回显, response:%v", response)
+	}
+	return nil
+}
+
+// transmit 检查当前模块状态是否允许发送该命令，然后通过串口队列发送并等待应答
+func (c *BLEController) transmit(cmd string, timeout, readDelay time.Duration) (string, error) {
+	if err := c.guard(cmd); err != nil {
+		return "", err
+	}
+	return c.Queue.SendCommand([]byte(cmd), timeout, readDelay, 100*time.Millisecond)
+}
+
 // 向BLE发送一条数据（MTU小于247), 不带返回值
 func (c *BLEController) SendSingle(cmd string) error {
-	response, err := c.Queue.SendCommand([]byte(cmd), 2*time.Second, 1*time.Second, 100*time.Millisecond)
+	response, err := c.transmit(cmd, 2*time.Second, 1*time.Second)
 	if err != nil {
 		c.logger.Errorf("❌发送%v, 出现错误 :%v, response:%v", cmd, err, response)
 		return err
 	} else {
-		if strings.Contains(response, "OK") {
-			c.logger.Infof("✅ 发送 %q 成功, 回显： %v", cmd, response)
-		} else if strings.Contains(response, "ERROR") {
-			c.logger.Errorf("⛔️  发送 %q 失败: %q , 回显： %v ", cmd, err, response)
-		} else {
-			c.logger.Warnf("❗❓  未知回显, response:%v", response)
+		if err := c.checkResponse(cmd, response); err != nil {
+			return err
 		}
 	}
 	c.logger.Info("BLE 单条指令发送成功")
@@ -108,17 +458,13 @@ func (c *BLEController) SendSingle(cmd string) error {
 // 如果需要发送JSON数据请使用jsonSender中的方法
 func (c *BLEController) SendMulti(cmds []string) error {
 	for _, cmd := range cmds {
-		response, err := c.Queue.SendCommand([]byte(cmd), 2*time.Second, 1*time.Second, 100*time.Millisecond)
+		response, err := c.transmit(cmd, 2*time.Second, 1*time.Second)
 		if err != nil {
 			c.logger.Errorf("❌发送%v, 出现错误 :%v, response:%v", cmd, err, response)
 			return err
 		} else {
-			if strings.Contains(response, "OK") {
-				c.logger.Infof("✅ 发送 %q 成功, 回显： %v", cmd, response)
-			} else if strings.Contains(response, "ERROR") {
-				c.logger.Errorf("⛔️  发送 %q 失败: %q , 回显： %v ", cmd, err, response)
-			} else {
-				c.logger.Warnf("❗❓  未知回显, response:%v", response)
+			if err := c.checkResponse(cmd, response); err != nil {
+				return err
 			}
 		}
 	}
@@ -128,17 +474,13 @@ func (c *BLEController) SendMulti(cmds []string) error {
 }
 
 func (c *BLEController) SendSingleWithResponse(cmd string) (res string, err error) {
-	response, err := c.Queue.SendCommand([]byte(cmd), 300*time.Millisecond, 1*time.Millisecond, 100*time.Millisecond)
+	response, err := c.transmit(cmd, 300*time.Millisecond, 1*time.Millisecond)
 	if err != nil {
 		c.logger.Errorf("❌发送%v, 出现错误 :%v, response:%v", cmd, err, response)
 		return "", err
 	} else {
-		if strings.Contains(response, "OK") {
-			c.logger.Infof("✅ 发送 %q 成功, 回显： %v", cmd, response)
-		} else if strings.Contains(response, "ERROR") {
-			c.logger.Errorf("⛔️  发送 %q 失败: %q , 回显： %v ", cmd, err, response)
-		} else {
-			c.logger.Warnf("❗❓  未知回显, response:%v", response)
+		if err := c.checkResponse(cmd, response); err != nil {
+			return "", err
 		}
 	}
 	c.logger.Info("BLE 单条指令发送成功")
@@ -0,0 +1,133 @@
+package ble
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FirmwareVersion 模块固件版本
+type FirmwareVersion struct {
+	Raw   string `json:"raw"`   // 模块原始应答
+	Model string `json:"model"` // 模块型号，如 HCM111Z
+	Major int    `json:"major"`
+	Minor int    `json:"minor"`
+	Patch int    `json:"patch"`
+}
+
+// String 返回 major.minor.patch 形式的版本号
+func (v FirmwareVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Compare 比较两个版本号，v 较旧返回 -1，相同返回 0，较新返回 1
+func (v FirmwareVersion) Compare(o FirmwareVersion) int {
+	for _, d := range [][2]int{{v.Major, o.Major}, {v.Minor, o.Minor}, {v.Patch, o.Patch}} {
+		if d[0] < d[1] {
+			return -1
+		}
+		if d[0] > d[1] {
+			return 1
+		}
+	}
+	return 0
+}
+
+var (
+	// revisionPattern 厂商常用的 R01A05V02 形式版本号，依次对应 major、minor、patch
+	revisionPattern = regexp.MustCompile(`(?i)R(\d+)A(\d+)(?:V(\d+))?`)
+	// semverPattern 1.2 或 1.2.3 形式版本号
+	semverPattern = regexp.MustCompile(`[vV]?(\d+)\.(\d+)(?:\.(\d+))?`)
+)
+
+// ParseFirmwareVersion 从版本查询应答中解析固件版本，prefix 为应答行前缀（可为空）。
+// 支持 R01A05V02 与 1.2.3 两种版本号写法，版本号之前的部分作为模块型号。
+func ParseFirmwareVersion(resp, prefix string) (FirmwareVersion, error) {
+	body := strings.TrimSpace(resp)
+	if i := strings.Index(body, prefix); prefix != "" && i >= 0 {
+		body = body[i+len(prefix):]
+	}
+	if i := strings.IndexAny(body, "\r\n"); i >= 0 {
+		body = body[:i]
+	}
+	body = strings.Trim(strings.TrimSpace(body), `"`)
+	v := FirmwareVersion{Raw: body}
+
+	for _, pattern := range []*regexp.Regexp{revisionPattern, semverPattern} {
+		m := pattern.FindStringSubmatchIndex(body)
+		if m == nil {
+			continue
+		}
+		v.Major, _ = strconv.Atoi(body[m[2]:m[3]])
+		v.Minor, _ = strconv.Atoi(body[m[4]:m[5]])
+		if m[6] >= 0 {
+			v.Patch, _ = strconv.Atoi(body[m[6]:m[7]])
+		}
+		v.Model = strings.Trim(body[:m[0]], " _-,:")
+		return v, nil
+	}
+	return v, fmt.Errorf("unrecognized firmware version %q", body)
+}
+
+// Capabilities 某一固件版本支持的能力
+type Capabilities struct {
+	Roles          []int              `json:"roles"`          // 支持的角色，见 RoleCentral 等常量
+	MaxMTU         int                `json:"maxMTU"`         // 支持协商的最大 ATT MTU
+	MaxConnections int                `json:"maxConnections"` // 同时连接的最大数量
+	Commands       map[Operation]bool `json:"commands"`       // 支持的操作
+}
+
+// SupportsRole 判断是否支持指定角色
+func (c Capabilities) SupportsRole(role int) bool {
+	for _, r := range c.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Supports 判断是否支持指定操作
+func (c Capabilities) Supports(op Operation) bool {
+	return c.Commands[op]
+}
+
+// capabilityRange 固件版本区间 [Min, Max] 对应的能力，Max 为零值表示不设上限
+type capabilityRange struct {
+	Min, Max FirmwareVersion
+	Caps     Capabilities
+}
+
+// contains 判断版本是否落在区间内
+func (r capabilityRange) contains(v FirmwareVersion) bool {
+	if v.Compare(r.Min) < 0 {
+		return false
+	}
+	return r.Max == (FirmwareVersion{}) || v.Compare(r.Max) <= 0
+}
+
+// lookupCapabilities 在能力表中查找版本对应的能力，未命中时返回 false
+func lookupCapabilities(table []capabilityRange, v FirmwareVersion) (Capabilities, bool) {
+	for _, r := range table {
+		if r.contains(v) {
+			return r.Caps, true
+		}
+	}
+	return Capabilities{}, false
+}
+
+// ErrUnsupportedByFirmware 当前固件不支持该操作
+type ErrUnsupportedByFirmware struct {
+	Firmware FirmwareVersion
+	Op       Operation
+	Detail   string
+}
+
+func (e ErrUnsupportedByFirmware) Error() string {
+	msg := fmt.Sprintf("operation %q is not supported by firmware %s (%s)", e.Op, e.Firmware.String(), e.Firmware.Raw)
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	return msg
+}
@@ -0,0 +1,47 @@
+package ble
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestQuectelRejectsUnconfirmedCommandsBeforeSending(t *testing.T) {
+	m := NewFakeModule("HCM111Z V1.4.0")
+	c := newFakeController(t, m, quectelDialect{})
+
+	var unsupported ErrUnsupportedByFirmware
+	if err := c.Sleep(); !errors.As(err, &unsupported) || unsupported.Op != OpSleep {
+		t.Fatalf("Sleep before firmware detection = %v, want ErrUnsupportedByFirmware", err)
+	}
+	if _, err := c.DetectFirmware(); err != nil {
+		t.Fatalf("DetectFirmware: %v", err)
+	}
+	if err := c.Sleep(); !errors.As(err, &unsupported) {
+		t.Errorf("Sleep after firmware detection = %v, want ErrUnsupportedByFirmware", err)
+	}
+	if _, err := c.build(OpInit, RoleCentral); !errors.As(err, &unsupported) {
+		t.Errorf("central init = %v, want ErrUnsupportedByFirmware", err)
+	}
+	if n := countCommands(m, "AT+QSLEEP"); n != 0 {
+		t.Errorf("sent %d sleep commands, want none", n)
+	}
+
+	if err := c.SetTxPower(4); err != nil {
+		t.Fatalf("SetTxPower: %v", err)
+	}
+	if n := countCommands(m, "AT+QTXPOWER=4"); n != 1 {
+		t.Errorf("sent %d tx power commands, want 1", n)
+	}
+}
+
+func TestQuectelExtendedSkipsCapabilityCheck(t *testing.T) {
+	m := NewFakeModule("HCM111Z V1.4.0")
+	c := newFakeController(t, m, quectelExtendedDialect{})
+
+	if err := c.Sleep(); err != nil {
+		t.Fatalf("Sleep: %v", err)
+	}
+	if n := countCommands(m, "AT+QSLEEP"); n != 1 {
+		t.Errorf("sent %d sleep commands, want 1", n)
+	}
+}
@@ -0,0 +1,89 @@
+package ble
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CharValue 可读特征值的当前内容
+type CharValue struct {
+	UUID      string    `json:"uuid"`
+	Value     string    `json:"value"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// SetCharacteristicValue 更新可读特征值的内容，中心设备读取该特征值时由模块直接返回，无需自定义协议。
+// 内容保存在控制器中，模块复位且配置恢复后重新下发。
+func (c *BLEController) SetCharacteristicValue(uuid, value string) error {
+	uuid = strings.ToLower(uuid)
+	cmd, err := c.build(OpSetCharValue, uuid, value)
+	if err != nil {
+		return err
+	}
+	c.charMu.Lock()
+	if c.charValues == nil {
+		c.charValues = make(map[string]CharValue)
+	}
+	c.charValues[uuid] = CharValue{UUID: uuid, Value: value, UpdatedAt: time.Now()}
+	lost := c.charLost
+	c.charMu.Unlock()
+	// 模块复位后配置尚未恢复，恢复时一并下发
+	if lost {
+		return nil
+	}
+	if err := c.SendSingle(cmd); err != nil {
+		return fmt.Errorf("set characteristic %s value: %w", uuid, err)
+	}
+	return nil
+}
+
+// CharacteristicValues 按 UUID 顺序返回各可读特征值的当前内容
+func (c *BLEController) CharacteristicValues() []CharValue {
+	c.charMu.Lock()
+	defer c.charMu.Unlock()
+	list := make([]CharValue, 0, len(c.charValues))
+	for _, v := range c.charValues {
+		list = append(list, v)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].UUID < list[j].UUID })
+	return list
+}
+
+// markCharValuesLost 模块复位后特征值内容已丢失，待配置恢复后重新下发
+func (c *BLEController) markCharValuesLost() {
+	c.charMu.Lock()
+	defer c.charMu.Unlock()
+	if len(c.charValues) > 0 {
+		c.charLost = true
+	}
+}
+
+// restoreCharValues 模块复位且配置已恢复后重新下发各特征值的内容
+func (c *BLEController) restoreCharValues() {
+	c.charMu.Lock()
+	lost := c.charLost
+	values := make([]CharValue, 0, len(c.charValues))
+	for _, v := range c.charValues {
+		values = append(values, v)
+	}
+	c.charMu.Unlock()
+	if !lost {
+		return
+	}
+	for _, v := range values {
+		cmd, err := c.build(OpSetCharValue, v.UUID, v.Value)
+		if err == nil {
+			err = c.SendSingle(cmd)
+		}
+		if err != nil {
+			c.logger.Errorf("模块复位后恢复特征值 %s 的内容失败: %v", v.UUID, err)
+			return
+		}
+	}
+	c.charMu.Lock()
+	c.charLost = false
+	c.charMu.Unlock()
+	c.logger.Infof("📖 模块复位后已恢复 %d 个可读特征值的内容", len(values))
+}
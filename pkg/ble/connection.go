@@ -0,0 +1,309 @@
+package ble
+
+import (
+	"device-ble/internal/interfaces"
+	"sort"
+	"time"
+)
+
+// SetConnectionEventHandler 注册连接生命周期事件处理函数（如发布到消息总线）。
+func (c *BLEController) SetConnectionEventHandler(handler func(event interfaces.BLEConnectionEvent)) {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	c.connHandler = handler
+}
+
+// SetResetHandler 注册模块复位处理函数，expected 为 true 表示复位由本服务的初始化命令触发。
+func (c *BLEController) SetResetHandler(handler func(expected bool)) {
+	c.resetMu.Lock()
+	defer c.resetMu.Unlock()
+	c.resetHandler = handler
+}
+
+// SetAutoReadvertise 设置断开连接后是否自动重新开始广播。
+func (c *BLEController) SetAutoReadvertise(enabled bool) {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	c.autoReadvertise = enabled
+}
+
+// SetDefaultMTU 设置未获知协商 MTU 时使用的默认值，0 表示使用 DefaultMTU，小于 MinMTU 时按 MinMTU 处理。
+func (c *BLEController) SetDefaultMTU(mtu int) {
+	if mtu == 0 {
+		mtu = DefaultMTU
+	}
+	if mtu < MinMTU {
+		mtu = MinMTU
+	}
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	c.defaultMTU = mtu
+}
+
+// MTU 返回指定连接协商后的 ATT MTU，连接不存在或尚未协商时返回默认值。
+func (c *BLEController) MTU(connID int) int {
+	c.connMu.RLock()
+	defer c.connMu.RUnlock()
+	if conn, ok := c.connections[connID]; ok && conn.MTU >= MinMTU {
+		return conn.MTU
+	}
+	return c.defaultMTU
+}
+
+// Connections 返回当前已连接的中心设备列表，按连接索引排序。
+func (c *BLEController) Connections() []interfaces.BLEConnection {
+	c.connMu.RLock()
+	defer c.connMu.RUnlock()
+	conns := make([]interfaces.BLEConnection, 0, len(c.connections))
+	for _, conn := range c.connections {
+		conns = append(conns, *conn)
+	}
+	sort.Slice(conns, func(i, j int) bool { return conns[i].ConnID < conns[j].ConnID })
+	return conns
+}
+
+// ConnectionTracked 是否已解析到过模块的连接上报。未确认前连接列表为空不代表没有中心设备，
+// 可能是固件的连接上报格式与方言不符，此时下行数据仍按原有方式发送给连接 0。
+func (c *BLEController) ConnectionTracked() bool {
+	c.connMu.RLock()
+	defer c.connMu.RUnlock()
+	return c.connTracked
+}
+
+// handleURC 处理模块主动上报，返回 true 表示该行已被消费。
+// 该函数运行在串口读取协程中，耗时操作必须放到新的协程里执行。
+func (c *BLEController) handleURC(line string) bool {
+	if c.collectLine(line) {
+		return true
+	}
+	urc, ok := c.dialect.ParseURC(line)
+	if !ok {
+		return false
+	}
+	switch urc.Type {
+	case URCConnected:
+		c.onConnected(urc)
+	case URCDisconnected:
+		c.onDisconnected(urc)
+	case URCMTUChanged:
+		c.onMTUChanged(urc)
+	case URCPasskeyDisplay:
+		c.onPasskeyDisplay(urc)
+	case URCPasskeyRequest:
+		c.onPasskeyRequest(urc)
+	case URCEncryptionChanged:
+		c.onEncryptionChanged(urc)
+	case URCModuleReset:
+		c.onModuleReset(urc)
+	case URCIndicationConfirmed:
+		c.onIndicationConfirmed(urc)
+	case URCConnParamsUpdated:
+		c.onConnParamsUpdated(urc)
+	case URCDataWritten:
+		c.onDataWritten(urc)
+	case URCAdvReport:
+		c.onAdvReport(urc)
+	}
+	return true
+}
+
+// onConnected 记录新建立的连接并发布连接事件，访问控制名单不允许的对端立即断开
+func (c *BLEController) onConnected(urc URC) {
+	c.connMu.Lock()
+	c.connTracked = true
+	c.connMu.Unlock()
+
+	conn := &interfaces.BLEConnection{
+		ConnID:      urc.ConnID,
+		PeerAddr:    urc.Addr,
+		ConnectedAt: time.Now(),
+	}
+	if !c.allowsPeer(conn.PeerAddr) {
+		c.connMu.RLock()
+		handler := c.connHandler
+		c.connMu.RUnlock()
+		c.rejectConnection(*conn, handler)
+		return
+	}
+
+	c.connMu.Lock()
+	c.connections[conn.ConnID] = conn
+	handler := c.connHandler
+	c.connMu.Unlock()
+	c.setState(StateConnected)
+	c.MarkActivity()
+
+	c.logger.Infof("🔗 中心设备已连接: conn=%d, addr=%s", conn.ConnID, conn.PeerAddr)
+	c.emitConnectionEvent(handler, interfaces.BLEConnectionEventConnected, *conn)
+
+	// 部分手机不会主动发起 MTU 交换，主动查询一次以获知协商结果
+	go c.queryMTU(conn.ConnID)
+
+	c.secMu.RLock()
+	enforce := c.enforceEncryption
+	c.secMu.RUnlock()
+	if enforce {
+		go c.enforceEncryptionFor(*conn)
+	}
+}
+
+// onMTUChanged 记录连接协商后的 MTU
+func (c *BLEController) onMTUChanged(urc URC) {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	conn, ok := c.connections[urc.ConnID]
+	if !ok {
+		c.logger.Warnf("收到未知连接的 MTU 上报: conn=%d, mtu=%d", urc.ConnID, urc.MTU)
+		return
+	}
+	mtu := urc.MTU
+	if max := c.maxMTU(); max > 0 && mtu > max {
+		c.logger.Warnf("连接 %d 上报的 MTU %d 超过固件上限 %d，按上限处理", urc.ConnID, mtu, max)
+		mtu = max
+	}
+	conn.MTU = mtu
+	c.logger.Infof("📏 连接 MTU 已更新: conn=%d, mtu=%d", urc.ConnID, mtu)
+}
+
+// queryMTU 查询指定连接的 MTU，结果通过 MTU 上报由 onMTUChanged 记录
+func (c *BLEController) queryMTU(connID int) {
+	cmd, err := c.build(OpQueryMTU, connID)
+	if isUnsupported(err) {
+		c.logger.Debugf("模块不支持查询 MTU，连接 %d 使用默认值 %d", connID, c.MTU(connID))
+		return
+	}
+	if err != nil {
+		c.logger.Errorf("生成 MTU 查询命令失败: %v", err)
+		return
+	}
+	if _, err := c.Queue.SendCommand([]byte(cmd), 2*time.Second, 1*time.Millisecond, 100*time.Millisecond); err != nil {
+		c.logger.Warnf("查询连接 %d 的 MTU 失败，使用默认值 %d: %v", connID, c.MTU(connID), err)
+	}
+}
+
+// onDisconnected 移除已断开的连接，发布断开事件，并按配置重新开始广播
+func (c *BLEController) onDisconnected(urc URC) {
+	c.connMu.Lock()
+	if c.rejected[urc.ConnID] {
+		delete(c.rejected, urc.ConnID)
+		readvertise := c.autoReadvertise
+		c.connMu.Unlock()
+		c.logger.Debugf("被拒绝的连接已断开: conn=%d", urc.ConnID)
+		if readvertise {
+			go c.restartAdvertising()
+		}
+		return
+	}
+	conn, ok := c.connections[urc.ConnID]
+	if !ok {
+		conn = &interfaces.BLEConnection{ConnID: urc.ConnID}
+	}
+	delete(c.connections, urc.ConnID)
+	c.cancelIndication(urc.ConnID)
+	remaining := len(c.connections)
+	handler := c.connHandler
+	readvertise := c.autoReadvertise
+	c.connMu.Unlock()
+	// 模块在连接建立后停止广播，最后一个连接断开后回到 GATT 就绪状态
+	if remaining == 0 && c.State() == StateConnected {
+		c.setState(StateGATTReady)
+	}
+
+	c.clearConnParams(urc.ConnID)
+
+	conn.DisconnectedAt = time.Now()
+	conn.Reason = urc.Reason
+	c.logger.Infof("🔌 中心设备已断开: conn=%d, addr=%s, reason=%s", conn.ConnID, conn.PeerAddr, conn.Reason)
+	c.emitConnectionEvent(handler, interfaces.BLEConnectionEventDisconnected, *conn)
+
+	if readvertise {
+		go c.restartAdvertising()
+	}
+}
+
+// expectedResetWindow 生成复位命令后，在该时间内收到的复位上报视为本服务主动复位
+const expectedResetWindow = 10 * time.Second
+
+// markResetRequested 记录本服务即将复位模块
+func (c *BLEController) markResetRequested() {
+	c.resetMu.Lock()
+	defer c.resetMu.Unlock()
+	c.resetRequested = time.Now()
+}
+
+// onModuleReset 模块复位后所有连接都已断开，清理连接并通知复位处理函数
+func (c *BLEController) onModuleReset(urc URC) {
+	c.connMu.Lock()
+	dropped := make([]interfaces.BLEConnection, 0, len(c.connections))
+	for id, conn := range c.connections {
+		dropped = append(dropped, *conn)
+		delete(c.connections, id)
+	}
+	c.rejected = make(map[int]bool)
+	for _, conn := range dropped {
+		c.cancelIndication(conn.ConnID)
+	}
+	handler := c.connHandler
+	c.connMu.Unlock()
+	c.setState(StateReset)
+
+	// 模块的连接过滤名单随复位丢失，重新初始化时由 SetInitSetup 设置的配置重新下发，此前由服务端检查访问控制名单
+	c.aclMu.Lock()
+	c.aclFiltered = false
+	c.aclMu.Unlock()
+	c.clearPowerState()
+	c.markScanLost()
+	c.markCharValuesLost()
+
+	now := time.Now()
+	for _, conn := range dropped {
+		c.clearConnParams(conn.ConnID)
+		conn.DisconnectedAt = now
+		conn.Reason = "模块复位"
+		c.emitConnectionEvent(handler, interfaces.BLEConnectionEventDisconnected, conn)
+	}
+
+	c.resetMu.Lock()
+	expected := now.Sub(c.resetRequested) < expectedResetWindow
+	resetHandler := c.resetHandler
+	c.resetMu.Unlock()
+
+	if expected {
+		c.logger.Infof("🔄 模块已复位（主动复位）: %s", urc.Raw)
+	} else {
+		c.logger.Warnf("⚠️ 检测到模块意外复位，易失配置已丢失: %s", urc.Raw)
+	}
+	if resetHandler != nil {
+		go resetHandler(expected)
+	}
+}
+
+// emitConnectionEvent 异步调用事件处理函数，避免阻塞串口读取协程
+func (c *BLEController) emitConnectionEvent(handler func(interfaces.BLEConnectionEvent), eventType string, conn interfaces.BLEConnection) {
+	if handler == nil {
+		return
+	}
+	event := interfaces.BLEConnectionEvent{
+		Type:       eventType,
+		Connection: conn,
+		Timestamp:  time.Now().UnixNano(),
+	}
+	go handler(event)
+}
+
+// restartAdvertising 断开连接后重新开始广播
+func (c *BLEController) restartAdvertising() {
+	if !c.advertisingAllowed() {
+		c.logger.Info("🗓️ 当前不在广播时间窗口内，断开连接后不重新广播")
+		return
+	}
+	cmd, err := c.build(OpStartAdvertising)
+	if err == nil {
+		err = c.SendSingle(cmd)
+	}
+	if err != nil {
+		c.logger.Errorf("断开连接后重新广播失败: %v", err)
+		return
+	}
+	c.logger.Info("断开连接后已重新开始广播")
+}
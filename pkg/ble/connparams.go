@@ -0,0 +1,167 @@
+package ble
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ConnParams 连接参数，取值单位与 BLE 规范一致
+type ConnParams struct {
+	MinInterval int `json:"minInterval"` // 最小连接间隔，单位 1.25ms，取值 [6, 3200]
+	MaxInterval int `json:"maxInterval"` // 最大连接间隔，单位 1.25ms，取值 [6, 3200]
+	Latency     int `json:"latency"`     // 从机延迟（可跳过的连接事件数），取值 [0, 499]
+	Timeout     int `json:"timeout"`     // 监督超时，单位 10ms，取值 [10, 3200]
+}
+
+// 连接参数预设名称
+const (
+	ConnProfileBulk     = "bulk"     // 大数据量传输：最短连接间隔，不跳过连接事件
+	ConnProfileBalanced = "balanced" // 交互：中等连接间隔
+	ConnProfileIdle     = "idle"     // 空闲：较长连接间隔并允许跳过连接事件，降低功耗
+)
+
+// connProfiles 连接参数预设
+var connProfiles = map[string]ConnParams{
+	ConnProfileBulk:     {MinInterval: 6, MaxInterval: 12, Latency: 0, Timeout: 400},   // 7.5~15ms，超时 4s
+	ConnProfileBalanced: {MinInterval: 24, MaxInterval: 40, Latency: 0, Timeout: 400},  // 30~50ms，超时 4s
+	ConnProfileIdle:     {MinInterval: 80, MaxInterval: 160, Latency: 4, Timeout: 600}, // 100~200ms，超时 6s
+}
+
+// bulkHoldTime 多包传输结束后保持大数据量参数的时间，避免连续传输时反复切换
+const bulkHoldTime = 2 * time.Second
+
+// Validate 校验连接参数是否符合 BLE 规范
+func (p ConnParams) Validate() error {
+	if p.MinInterval < 6 || p.MaxInterval > 3200 || p.MinInterval > p.MaxInterval {
+		return fmt.Errorf("connection interval must satisfy 6 <= min <= max <= 3200, got %d-%d", p.MinInterval, p.MaxInterval)
+	}
+	if p.Latency < 0 || p.Latency > 499 {
+		return fmt.Errorf("connection latency out of range [0,499]: %d", p.Latency)
+	}
+	if p.Timeout < 10 || p.Timeout > 3200 {
+		return fmt.Errorf("supervision timeout out of range [10,3200]: %d", p.Timeout)
+	}
+	// 监督超时必须大于 (1 + latency) * maxInterval * 2
+	if p.Timeout*10*4 <= (1+p.Latency)*p.MaxInterval*5*2 {
+		return fmt.Errorf("supervision timeout %dms too short for interval %.2fms and latency %d",
+			p.Timeout*10, float64(p.MaxInterval)*1.25, p.Latency)
+	}
+	return nil
+}
+
+// ConnProfile 按名称返回连接参数预设
+func ConnProfile(name string) (ConnParams, error) {
+	p, ok := connProfiles[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		names := make([]string, 0, len(connProfiles))
+		for n := range connProfiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return ConnParams{}, fmt.Errorf("unknown connection profile %q, expected one of %v", name, names)
+	}
+	return p, nil
+}
+
+// UpdateConnParams 请求更新指定连接的连接参数。参数由手机最终决定，生效结果通过连接参数上报记录。
+func (c *BLEController) UpdateConnParams(connID int, params ConnParams) error {
+	cmd, err := c.build(OpUpdateConn, connID, params)
+	if err != nil {
+		return err
+	}
+	return c.SendSingle(cmd)
+}
+
+// ApplyConnProfile 按预设名称更新指定连接的连接参数。
+func (c *BLEController) ApplyConnProfile(connID int, name string) error {
+	params, err := ConnProfile(name)
+	if err != nil {
+		return err
+	}
+	if err := c.UpdateConnParams(connID, params); err != nil {
+		return err
+	}
+	c.logger.Infof("⏱️ 已请求连接 %d 切换到 %s 连接参数", connID, name)
+	return nil
+}
+
+// SetAutoConnParams 设置是否在多包传输前后自动切换大数据量与空闲连接参数。
+func (c *BLEController) SetAutoConnParams(enabled bool) {
+	c.paramMu.Lock()
+	defer c.paramMu.Unlock()
+	c.autoConnParams = enabled
+}
+
+// beginBulkTransfer 多包传输开始前切换到大数据量连接参数，已处于该参数时只取消待执行的回退
+func (c *BLEController) beginBulkTransfer(connID int) {
+	c.paramMu.Lock()
+	if !c.autoConnParams {
+		c.paramMu.Unlock()
+		return
+	}
+	if timer, ok := c.idleTimers[connID]; ok {
+		timer.Stop()
+		delete(c.idleTimers, connID)
+	}
+	already := c.bulkConns[connID]
+	c.bulkConns[connID] = true
+	c.paramMu.Unlock()
+	if already {
+		return
+	}
+	if err := c.ApplyConnProfile(connID, ConnProfileBulk); err != nil {
+		c.logger.Debugf("切换连接 %d 到大数据量连接参数失败: %v", connID, err)
+		c.paramMu.Lock()
+		delete(c.bulkConns, connID)
+		c.paramMu.Unlock()
+	}
+}
+
+// endBulkTransfer 多包传输结束后延迟切回空闲连接参数
+func (c *BLEController) endBulkTransfer(connID int) {
+	c.paramMu.Lock()
+	defer c.paramMu.Unlock()
+	if !c.bulkConns[connID] {
+		return
+	}
+	if timer, ok := c.idleTimers[connID]; ok {
+		timer.Stop()
+	}
+	c.idleTimers[connID] = time.AfterFunc(bulkHoldTime, func() {
+		c.paramMu.Lock()
+		delete(c.idleTimers, connID)
+		delete(c.bulkConns, connID)
+		c.paramMu.Unlock()
+		if err := c.ApplyConnProfile(connID, ConnProfileIdle); err != nil {
+			c.logger.Debugf("切换连接 %d 到空闲连接参数失败: %v", connID, err)
+		}
+	})
+}
+
+// clearConnParams 连接断开后清理自动切换状态
+func (c *BLEController) clearConnParams(connID int) {
+	c.paramMu.Lock()
+	defer c.paramMu.Unlock()
+	if timer, ok := c.idleTimers[connID]; ok {
+		timer.Stop()
+		delete(c.idleTimers, connID)
+	}
+	delete(c.bulkConns, connID)
+}
+
+// onConnParamsUpdated 记录手机最终接受的连接参数
+func (c *BLEController) onConnParamsUpdated(urc URC) {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	conn, ok := c.connections[urc.ConnID]
+	if !ok {
+		c.logger.Warnf("收到未知连接的连接参数上报: conn=%d", urc.ConnID)
+		return
+	}
+	conn.IntervalMs = float64(urc.Interval) * 1.25
+	conn.Latency = urc.Latency
+	conn.TimeoutMs = urc.Timeout * 10
+	c.logger.Infof("⏱️ 连接参数已更新: conn=%d, interval=%.2fms, latency=%d, timeout=%dms", urc.ConnID, conn.IntervalMs, conn.Latency, conn.TimeoutMs)
+}
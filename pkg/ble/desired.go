@@ -0,0 +1,305 @@
+package ble
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GATTService 期望的 GATT 服务及其特征值
+type GATTService struct {
+	UUID            string              `json:"uuid"`
+	Characteristics []string            `json:"characteristics"`
+	Properties      map[string][]string `json:"properties,omitempty"` // 特征值 UUID -> 属性（read / write / writeNoResponse / notify / indicate），未列出的特征值使用模块默认属性
+}
+
+// charPropertyNames 期望配置中的特征值属性名称
+var charPropertyNames = map[string]CharProperty{
+	"read":            CharPropRead,
+	"write":           CharPropWrite,
+	"writeNoResponse": CharPropWriteNoResponse,
+	"notify":          CharPropNotify,
+	"indicate":        CharPropIndicate,
+}
+
+// CharProperties 返回特征值配置的属性位，未配置属性时返回 0
+func (s GATTService) CharProperties(uuid string) (CharProperty, error) {
+	var props CharProperty
+	for char, names := range s.Properties {
+		if !strings.EqualFold(char, uuid) {
+			continue
+		}
+		for _, name := range names {
+			p, ok := charPropertyNames[name]
+			if !ok {
+				return 0, fmt.Errorf("unknown property %q for characteristic %s", name, uuid)
+			}
+			props |= p
+		}
+	}
+	return props, nil
+}
+
+// DesiredConfig 设备期望的模块配置，模块复位后会丢失，由 Reconciler 负责恢复。
+type DesiredConfig struct {
+	Name      string        `json:"name"`
+	TxPower   *int8         `json:"txPower,omitempty"`   // 为空时不管理发射功率
+	AdvParams *AdvParams    `json:"advParams,omitempty"` // 为空时不管理广播间隔
+	Services  []GATTService `json:"services"`
+	Baud      int64         `json:"baud,omitempty"` // 模块串口波特率，无法回读，仅用于打开串口
+}
+
+// DefaultDesiredConfig 返回与 InitializeAsPeripheral 一致的默认配置
+func DefaultDesiredConfig() DesiredConfig {
+	return DesiredConfig{
+		Name:      DefaultDeviceName,
+		AdvParams: &AdvParams{MinInterval: DefaultAdvIntervalMs, MaxInterval: DefaultAdvIntervalMs},
+		Services: []GATTService{
+			{UUID: DefaultServiceUUID, Characteristics: []string{DefaultCharacteristicUUID}},
+		},
+	}
+}
+
+// Validate 校验期望配置，各字段的取值范围与对应 AT 命令一致
+func (d DesiredConfig) Validate() error {
+	if _, err := SetDeviceName(d.Name); err != nil {
+		return err
+	}
+	if d.TxPower != nil {
+		if _, err := SetTxPower(*d.TxPower); err != nil {
+			return err
+		}
+	}
+	if d.AdvParams != nil {
+		if _, err := SetAdvertisingParams(d.AdvParams.MinInterval, d.AdvParams.MaxInterval); err != nil {
+			return err
+		}
+	}
+	if len(d.Services) == 0 {
+		return errors.New("at least one GATT service is required")
+	}
+	for _, svc := range d.Services {
+		if _, err := AddService(svc.UUID); err != nil {
+			return err
+		}
+		for _, char := range svc.Characteristics {
+			props, err := svc.CharProperties(char)
+			if err != nil {
+				return err
+			}
+			if _, err := AddCharacteristic(char, props); err != nil {
+				return err
+			}
+		}
+	}
+	if d.Baud != 0 {
+		if _, err := SetBaud(d.Baud); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddCharacteristicTo 向指定服务添加特征值并补充属性，服务不存在时新建。
+// 特征值已存在且已具备这些属性时返回 false。
+func (d *DesiredConfig) AddCharacteristicTo(service, uuid string, props ...string) bool {
+	idx := -1
+	for i, svc := range d.Services {
+		if strings.EqualFold(svc.UUID, service) {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		d.Services = append(d.Services, GATTService{UUID: service})
+		idx = len(d.Services) - 1
+	}
+	svc := &d.Services[idx]
+
+	changed := true
+	for _, char := range svc.Characteristics {
+		if strings.EqualFold(char, uuid) {
+			uuid, changed = char, false
+			break
+		}
+	}
+	if changed {
+		svc.Characteristics = append(svc.Characteristics, uuid)
+	}
+	for _, p := range props {
+		if containsFold(svc.Properties[uuid], p) {
+			continue
+		}
+		if svc.Properties == nil {
+			svc.Properties = make(map[string][]string)
+		}
+		svc.Properties[uuid] = append(svc.Properties[uuid], p)
+		changed = true
+	}
+	return changed
+}
+
+// containsFold 列表中是否有忽略大小写后相同的字符串
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Clone 深拷贝期望配置
+func (d DesiredConfig) Clone() DesiredConfig {
+	out := d
+	if d.TxPower != nil {
+		v := *d.TxPower
+		out.TxPower = &v
+	}
+	if d.AdvParams != nil {
+		v := *d.AdvParams
+		out.AdvParams = &v
+	}
+	out.Services = make([]GATTService, len(d.Services))
+	for i, svc := range d.Services {
+		out.Services[i] = GATTService{UUID: svc.UUID, Characteristics: append([]string(nil), svc.Characteristics...)}
+		if svc.Properties != nil {
+			out.Services[i].Properties = make(map[string][]string, len(svc.Properties))
+			for char, props := range svc.Properties {
+				out.Services[i].Properties[char] = append([]string(nil), props...)
+			}
+		}
+	}
+	return out
+}
+
+// LoadDesiredConfig 从 JSON 文件读取期望配置，文件不存在时返回 false
+func LoadDesiredConfig(path string) (DesiredConfig, bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return DesiredConfig{}, false, nil
+	}
+	if err != nil {
+		return DesiredConfig{}, false, err
+	}
+	var cfg DesiredConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return DesiredConfig{}, false, fmt.Errorf("invalid desired config %s: %v", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return DesiredConfig{}, false, fmt.Errorf("invalid desired config %s: %v", path, err)
+	}
+	return cfg, true, nil
+}
+
+// SaveDesiredConfig 将期望配置写入 JSON 文件
+func SaveDesiredConfig(path string, cfg DesiredConfig) error {
+	return writeJSONFile(path, cfg)
+}
+
+// writeJSONFile 将数据写入 JSON 文件，先写临时文件再重命名，避免掉电时留下半个文件
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// ModuleConfig 从模块回读的配置，无法回读的字段为空
+type ModuleConfig struct {
+	Name      string
+	TxPower   *int8
+	AdvParams *AdvParams
+	Role      *int // BLE 角色，0 表示协议栈尚未初始化
+}
+
+// DesiredInitCommands 生成按期望配置初始化为外围设备的命令序列，withReset 为 true 时先复位模块。
+func (c *BLEController) DesiredInitCommands(cfg DesiredConfig, withReset bool) ([]string, error) {
+	var ops []opArgs
+	if withReset {
+		ops = append(ops, opOf(OpReset))
+	}
+	ops = append(ops, opOf(OpInit, c.initRole()))
+	if cfg.AdvParams != nil {
+		ops = append(ops, opOf(OpSetAdvParam, cfg.AdvParams.MinInterval, cfg.AdvParams.MaxInterval))
+	}
+	for _, svc := range cfg.Services {
+		ops = append(ops, opOf(OpAddService, svc.UUID))
+		for _, char := range svc.Characteristics {
+			props, err := svc.CharProperties(char)
+			if err != nil {
+				return nil, err
+			}
+			ops = append(ops, opOf(OpAddCharacteristic, char, props))
+		}
+	}
+	ops = append(ops, opOf(OpFinishGATTServer), opOf(OpSetName, cfg.Name))
+	if cfg.TxPower != nil {
+		ops = append(ops, opOf(OpSetTxPower, *cfg.TxPower))
+	}
+	ops = append(ops, opOf(OpStartAdvertising))
+	return c.buildAll(ops...)
+}
+
+// ReadModuleConfig 回读模块当前的名称、发射功率、广播间隔与 BLE 角色，固件不支持回读的字段保持为空。
+func (c *BLEController) ReadModuleConfig() (ModuleConfig, error) {
+	var mc ModuleConfig
+	if raw, ok, err := c.queryInfo(OpQueryName); err != nil {
+		return mc, err
+	} else if ok {
+		if mc.Name, err = ParseNameResponse(raw); err != nil {
+			return mc, err
+		}
+	}
+	if raw, ok, err := c.queryInfo(OpQueryTxPower); err != nil {
+		return mc, err
+	} else if ok {
+		v, err := ParseTxPowerResponse(raw)
+		if err != nil {
+			return mc, err
+		}
+		mc.TxPower = &v
+	}
+	if raw, ok, err := c.queryInfo(OpQueryAdvParam); err != nil {
+		return mc, err
+	} else if ok {
+		v, err := ParseAdvParamsResponse(raw)
+		if err != nil {
+			return mc, err
+		}
+		mc.AdvParams = &v
+	}
+	if raw, ok, err := c.queryInfo(OpQueryRole); err != nil {
+		return mc, err
+	} else if ok {
+		v, err := ParseRoleResponse(raw)
+		if err != nil {
+			return mc, err
+		}
+		mc.Role = &v
+	}
+	return mc, nil
+}
+
+// queryInfo 执行一条查询并返回拼接后的结果行，方言或固件不支持该查询时返回 false
+func (c *BLEController) queryInfo(op Operation) (string, bool, error) {
+	raw, err := c.queryValue(op)
+	if isUnsupported(err) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return raw, true, nil
+}
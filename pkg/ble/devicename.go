@@ -0,0 +1,100 @@
+package ble
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MaxDeviceNameLen 设备名称的最大长度，广播包 31 字节去掉 Flags 与名称字段头后完整名称仍可放入广播包
+const MaxDeviceNameLen = 26
+
+// ValidateDeviceName 校验设备名称：不超过 MaxDeviceNameLen 字节，只能包含字母、数字、空格与 - _ .，
+// 逗号、引号等字符会破坏 AT 命令的参数分隔
+func ValidateDeviceName(name string) error {
+	if name == "" {
+		return fmt.Errorf("device name cannot be empty")
+	}
+	if len(name) > MaxDeviceNameLen {
+		return fmt.Errorf("device name %q too long: %d bytes, max %d", name, len(name), MaxDeviceNameLen)
+	}
+	for _, r := range name {
+		if !nameRune(r) {
+			return fmt.Errorf("device name %q contains invalid character %q, only letters, digits, space and - _ . are allowed", name, r)
+		}
+	}
+	return nil
+}
+
+// nameRune 字符是否可以出现在设备名称中
+func nameRune(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	}
+	return r == ' ' || r == '-' || r == '_' || r == '.'
+}
+
+// sanitizeNamePart 将占位符的值中不能出现在设备名称中的字符替换为 -
+func sanitizeNamePart(s string) string {
+	return strings.Map(func(r rune) rune {
+		if nameRune(r) {
+			return r
+		}
+		return '-'
+	}, s)
+}
+
+// NameVars 设备名称模板的占位符取值
+type NameVars struct {
+	MAC      string // 模块蓝牙地址
+	Device   string // EdgeX 设备名称
+	Hostname string // 网关主机名
+	Site     string // 站点标签
+}
+
+// namePlaceholder 模板中的占位符，{mac} 为地址末 4 位，{macN} 为地址末 N 位（N 为 1~12）
+var namePlaceholder = regexp.MustCompile(`\{([a-z]+)(\d*)\}`)
+
+// RenderDeviceName 按模板生成设备名称。支持的占位符：{mac}、{macN}、{device}、{hostname}、{site}，
+// 占位符的值中不允许出现的字符替换为 -，生成的名称需通过 ValidateDeviceName 校验。
+func RenderDeviceName(template string, vars NameVars) (string, error) {
+	var renderErr error
+	name := namePlaceholder.ReplaceAllStringFunc(template, func(m string) string {
+		sub := namePlaceholder.FindStringSubmatch(m)
+		key, digits := sub[1], sub[2]
+		if key != "mac" && digits != "" {
+			renderErr = fmt.Errorf("unknown placeholder %s in device name template", m)
+			return m
+		}
+		switch key {
+		case "mac":
+			n := 4
+			if digits != "" {
+				n, _ = strconv.Atoi(digits)
+			}
+			hex := strings.ToUpper(strings.ReplaceAll(vars.MAC, ":", ""))
+			if n < 1 || n > 12 || len(hex) < n {
+				renderErr = fmt.Errorf("placeholder %s requires 1 to 12 hex digits of the MAC address, got %q", m, vars.MAC)
+				return m
+			}
+			return hex[len(hex)-n:]
+		case "device":
+			return sanitizeNamePart(vars.Device)
+		case "hostname":
+			return sanitizeNamePart(vars.Hostname)
+		case "site":
+			return sanitizeNamePart(vars.Site)
+		}
+		renderErr = fmt.Errorf("unknown placeholder %s in device name template", m)
+		return m
+	})
+	if renderErr != nil {
+		return "", renderErr
+	}
+	if err := ValidateDeviceName(name); err != nil {
+		return "", fmt.Errorf("device name from template %q: %w", template, err)
+	}
+	return name, nil
+}
@@ -0,0 +1,213 @@
+package ble
+
+import (
+	"device-ble/internal/interfaces"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Operation 表示一种与具体模块无关的 BLE 操作，由 Dialect 转换为对应的 AT 命令
+type Operation string
+
+// BLE 操作定义，参数顺序见各常量说明
+const (
+	// 通用模块控制
+	OpReset       Operation = "reset"       // 无参数
+	OpVersion     Operation = "version"     // 无参数
+	OpQueryAddr   Operation = "queryAddr"   // 无参数
+	OpSetBaud     Operation = "setBaud"     // baud int64
+	OpSetTxPower  Operation = "setTxPower"  // txpower int8
+	OpInit        Operation = "init"        // role int
+	OpSetName     Operation = "setName"     // name string
+	OpQueryMTU    Operation = "queryMTU"    // connID int
+	OpDisconnect  Operation = "disconnect"  // connID int
+	OpUpdateConn  Operation = "updateConn"  // connID int, params ConnParams
+	OpQueryRSSI   Operation = "queryRSSI"   // connID int
+	OpSetAdvParam Operation = "setAdvParam" // min int, max int（单位 ms）
+	OpSleep       Operation = "sleep"       // 无参数，模块应答后进入休眠，串口收到数据时唤醒
+
+	// 配置回读
+	OpQueryName     Operation = "queryName"     // 无参数
+	OpQueryTxPower  Operation = "queryTxPower"  // 无参数
+	OpQueryAdvParam Operation = "queryAdvParam" // 无参数
+	OpQueryBaud     Operation = "queryBaud"     // 无参数
+	OpQueryRole     Operation = "queryRole"     // 无参数
+
+	// 广播控制
+	OpStartAdvertising    Operation = "startAdvertising"    // 无参数
+	OpStopAdvertising     Operation = "stopAdvertising"     // 无参数
+	OpSetAdvertisingData  Operation = "setAdvertisingData"  // data []byte
+	OpSetScanResponseData Operation = "setScanResponseData" // data []byte
+
+	// GATT 服务端
+	OpAddService        Operation = "addService"        // uuid string
+	OpAddCharacteristic Operation = "addCharacteristic" // uuid string, props CharProperty（可选，缺省时使用模块默认属性）
+	OpFinishGATTServer  Operation = "finishGATTServer"  // 无参数
+	OpNotify            Operation = "notify"            // connID int, handle string, value string
+	OpIndicate          Operation = "indicate"          // connID int, handle string, value string，对端需回复确认
+
+	// 安全与绑定
+	OpSetSecurityMode       Operation = "setSecurityMode"       // mode SecurityMode
+	OpSetStaticPasskey      Operation = "setStaticPasskey"      // passkey string
+	OpReplyPasskey          Operation = "replyPasskey"          // connID int, passkey string
+	OpSetBonding            Operation = "setBonding"            // enabled bool
+	OpSetEncryptionRequired Operation = "setEncryptionRequired" // required bool
+	OpQueryBondedPeers      Operation = "queryBondedPeers"      // 无参数
+	OpDeleteBond            Operation = "deleteBond"            // addr string
+	OpClearBonds            Operation = "clearBonds"            // 无参数
+
+	// 连接过滤名单（Filter Accept List）
+	OpClearFilterList Operation = "clearFilterList" // 无参数
+	OpAddFilterAddr   Operation = "addFilterAddr"   // addr string
+	OpSetFilterPolicy Operation = "setFilterPolicy" // enabled bool，开启后只接受名单内设备的连接
+
+	// 固件升级
+	OpFirmwareBegin  Operation = "firmwareBegin"  // size int, crc uint32，开始接收新固件
+	OpFirmwareData   Operation = "firmwareData"   // offset int, data []byte
+	OpFirmwareStatus Operation = "firmwareStatus" // 无参数，查询已接收的固件长度，用于断点续传
+	OpFirmwareApply  Operation = "firmwareApply"  // 无参数，模块校验新固件后重启
+
+	// 扫描（需以中心或多角色初始化）
+	OpSetScanParam Operation = "setScanParam" // active bool, interval int, window int（单位 ms）
+	OpStartScan    Operation = "startScan"    // 无参数，扫描结果以主动上报输出
+	OpStopScan     Operation = "stopScan"     // 无参数
+
+	// 可读特征值
+	OpSetCharValue Operation = "setCharValue" // uuid string, value string，中心设备读取特征值时模块直接返回该值
+)
+
+const (
+	// DialectQuectel Quectel HCM111Z 模块的方言名称，也是默认方言，只允许能力表中已确认的命令
+	DialectQuectel = "quectel"
+	// DialectQuectelExtended 在 Quectel 方言基础上放开尚未经实机确认的命令，供核实这些命令时使用
+	DialectQuectelExtended = "quectel-extended"
+)
+
+// Dialect 描述一种 BLE 模块的 AT 命令方言：命令构建、响应分类与主动上报解析。
+type Dialect interface {
+	// Name 返回方言名称，对应设备协议属性中的 dialect
+	Name() string
+	// Build 生成指定操作的 AT 命令，模块不支持该操作时返回 ErrUnsupportedOperation
+	Build(op Operation, args ...interface{}) (string, error)
+	// Classify、SplitCommands 与 IsError 解析串口读取到的一行数据，交给串口队列使用
+	interfaces.LineParser
+	// ParseURC 解析模块主动上报，无法识别时返回 false
+	ParseURC(line string) (URC, bool)
+	// InfoPrefix 返回查询类操作结果行的前缀，如已绑定设备列表
+	InfoPrefix(op Operation) string
+	// NotifyFrame 返回向指定连接与特征值发送 Notify 时，数据前后的命令部分
+	NotifyFrame(connID int, handle string) Frame
+	// IndicateFrame 返回向指定连接与特征值发送 Indication 时，数据前后的命令部分，模块不支持 Indication 时返回 false
+	IndicateFrame(connID int, handle string) (Frame, bool)
+	// WakeSequence 返回唤醒休眠模块时在命令前发送的前导数据与发送后等待模块就绪的时间，模块不支持休眠时前导为空
+	WakeSequence() (preamble []byte, settle time.Duration)
+	// Capabilities 返回指定固件版本的能力，版本不在能力表中时返回 false
+	Capabilities(v FirmwareVersion) (Capabilities, bool)
+	// Identify 识别一条 AT 命令对应的操作，用于维护模块状态，无法识别时返回 false
+	Identify(cmd string) (Operation, bool)
+}
+
+// Frame 表示一条数据发送命令中位于数据前后的部分，用于计算分包大小
+type Frame struct {
+	Prefix string
+	Suffix string
+}
+
+// ErrUnsupportedOperation 方言不支持该操作
+type ErrUnsupportedOperation struct {
+	Dialect string
+	Op      Operation
+}
+
+func (e ErrUnsupportedOperation) Error() string {
+	return fmt.Sprintf("operation %q is not supported by dialect %q", e.Op, e.Dialect)
+}
+
+var (
+	dialectsMu sync.RWMutex
+	dialects   = map[string]Dialect{
+		DialectQuectel:         quectelDialect{},
+		DialectQuectelExtended: quectelExtendedDialect{},
+	}
+)
+
+// RegisterDialect 注册一种新的 AT 方言，同名方言会被覆盖。
+func RegisterDialect(d Dialect) {
+	dialectsMu.Lock()
+	defer dialectsMu.Unlock()
+	dialects[strings.ToLower(d.Name())] = d
+}
+
+// LookupDialect 按名称查找 AT 方言，名称为空时返回默认的 Quectel 方言。
+func LookupDialect(name string) (Dialect, error) {
+	if name == "" {
+		name = DialectQuectel
+	}
+	dialectsMu.RLock()
+	defer dialectsMu.RUnlock()
+	d, ok := dialects[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown BLE dialect %q", name)
+	}
+	return d, nil
+}
+
+// --- 方言实现使用的参数解析辅助函数 ---
+
+// argInt 读取第 i 个整型参数
+func argInt(op Operation, args []interface{}, i int) (int, error) {
+	if i >= len(args) {
+		return 0, fmt.Errorf("%s: missing argument %d", op, i)
+	}
+	switch v := args[i].(type) {
+	case int:
+		return v, nil
+	case int8:
+		return int(v), nil
+	case int64:
+		return int(v), nil
+	case SecurityMode:
+		return int(v), nil
+	case CharProperty:
+		return int(v), nil
+	}
+	return 0, fmt.Errorf("%s: argument %d must be an integer, got %T", op, i, args[i])
+}
+
+// argString 读取第 i 个字符串参数
+func argString(op Operation, args []interface{}, i int) (string, error) {
+	if i >= len(args) {
+		return "", fmt.Errorf("%s: missing argument %d", op, i)
+	}
+	v, ok := args[i].(string)
+	if !ok {
+		return "", fmt.Errorf("%s: argument %d must be a string, got %T", op, i, args[i])
+	}
+	return v, nil
+}
+
+// argBytes 读取第 i 个字节数组参数
+func argBytes(op Operation, args []interface{}, i int) ([]byte, error) {
+	if i >= len(args) {
+		return nil, fmt.Errorf("%s: missing argument %d", op, i)
+	}
+	v, ok := args[i].([]byte)
+	if !ok {
+		return nil, fmt.Errorf("%s: argument %d must be []byte, got %T", op, i, args[i])
+	}
+	return v, nil
+}
+
+// argBool 读取第 i 个布尔参数
+func argBool(op Operation, args []interface{}, i int) (bool, error) {
+	if i >= len(args) {
+		return false, fmt.Errorf("%s: missing argument %d", op, i)
+	}
+	v, ok := args[i].(bool)
+	if !ok {
+		return false, fmt.Errorf("%s: argument %d must be a bool, got %T", op, i, args[i])
+	}
+	return v, nil
+}
@@ -0,0 +1,646 @@
+package ble
+
+import (
+	"device-ble/internal/interfaces"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// quectelDialect Quectel HCM111Z 模块的 AT 方言
+type quectelDialect struct{}
+
+// quectelBuilder 根据参数生成一条 Quectel AT 命令
+type quectelBuilder func(op Operation, args []interface{}) (string, error)
+
+// fixed 包装无参数的命令
+func fixed(cmd func() string) quectelBuilder {
+	return func(Operation, []interface{}) (string, error) { return cmd(), nil }
+}
+
+// quectelBuilders 各操作对应的 Quectel AT 命令
+var quectelBuilders = map[Operation]quectelBuilder{
+	OpReset:            fixed(Restart),
+	OpVersion:          fixed(GetVersion),
+	OpQueryAddr:        fixed(QueryAddress),
+	OpQueryName:        fixed(QueryDeviceName),
+	OpQueryTxPower:     fixed(QueryTxPower),
+	OpQueryAdvParam:    fixed(QueryAdvertisingParams),
+	OpQueryBaud:        fixed(QueryBaud),
+	OpQueryRole:        fixed(QueryRole),
+	OpStartAdvertising: fixed(StartAdvertising),
+	OpStopAdvertising:  fixed(StopAdvertising),
+	OpFinishGATTServer: fixed(FinishGATTServer),
+	OpQueryBondedPeers: fixed(QueryBondedPeers),
+	OpClearBonds:       fixed(ClearBonds),
+	OpClearFilterList:  fixed(ClearFilterList),
+	OpSleep:            fixed(Sleep),
+	OpFirmwareStatus:   fixed(QueryFirmwareUpgrade),
+	OpFirmwareApply:    fixed(ApplyFirmwareUpgrade),
+	OpStartScan:        fixed(StartScan),
+	OpStopScan:         fixed(StopScan),
+	OpSetCharValue: func(op Operation, args []interface{}) (string, error) {
+		uuid, err := argString(op, args, 0)
+		if err != nil {
+			return "", err
+		}
+		value, err := argString(op, args, 1)
+		if err != nil {
+			return "", err
+		}
+		return SetCharacteristicValue(uuid, value)
+	},
+	OpSetScanParam: func(op Operation, args []interface{}) (string, error) {
+		active, err := argBool(op, args, 0)
+		if err != nil {
+			return "", err
+		}
+		interval, err := argInt(op, args, 1)
+		if err != nil {
+			return "", err
+		}
+		window, err := argInt(op, args, 2)
+		if err != nil {
+			return "", err
+		}
+		return SetScanParams(active, interval, window)
+	},
+	OpFirmwareBegin: func(op Operation, args []interface{}) (string, error) {
+		size, err := argInt(op, args, 0)
+		if err != nil {
+			return "", err
+		}
+		if len(args) < 2 {
+			return "", fmt.Errorf("%s: missing argument 1", op)
+		}
+		crc, ok := args[1].(uint32)
+		if !ok {
+			return "", fmt.Errorf("%s: argument 1 must be uint32, got %T", op, args[1])
+		}
+		return BeginFirmwareUpgrade(size, crc)
+	},
+	OpFirmwareData: func(op Operation, args []interface{}) (string, error) {
+		offset, err := argInt(op, args, 0)
+		if err != nil {
+			return "", err
+		}
+		data, err := argBytes(op, args, 1)
+		if err != nil {
+			return "", err
+		}
+		return WriteFirmwareChunk(offset, data)
+	},
+	OpSetBaud: func(op Operation, args []interface{}) (string, error) {
+		baud, err := argInt(op, args, 0)
+		if err != nil {
+			return "", err
+		}
+		return SetBaud(int64(baud))
+	},
+	OpSetTxPower: func(op Operation, args []interface{}) (string, error) {
+		txpower, err := argInt(op, args, 0)
+		if err != nil {
+			return "", err
+		}
+		if txpower < math.MinInt8 || txpower > math.MaxInt8 {
+			return "", fmt.Errorf("txpower setting value out of range [-16,10]")
+		}
+		return SetTxPower(int8(txpower))
+	},
+	OpInit: func(op Operation, args []interface{}) (string, error) {
+		role, err := argInt(op, args, 0)
+		if err != nil {
+			return "", err
+		}
+		return Init(role)
+	},
+	OpSetName: func(op Operation, args []interface{}) (string, error) {
+		name, err := argString(op, args, 0)
+		if err != nil {
+			return "", err
+		}
+		return SetDeviceName(name)
+	},
+	OpQueryMTU: func(op Operation, args []interface{}) (string, error) {
+		connID, err := argInt(op, args, 0)
+		if err != nil {
+			return "", err
+		}
+		return QueryMTU(connID)
+	},
+	OpQueryRSSI: func(op Operation, args []interface{}) (string, error) {
+		connID, err := argInt(op, args, 0)
+		if err != nil {
+			return "", err
+		}
+		return QueryRSSI(connID)
+	},
+	OpDisconnect: func(op Operation, args []interface{}) (string, error) {
+		connID, err := argInt(op, args, 0)
+		if err != nil {
+			return "", err
+		}
+		return Disconnect(connID)
+	},
+	OpUpdateConn: func(op Operation, args []interface{}) (string, error) {
+		connID, err := argInt(op, args, 0)
+		if err != nil {
+			return "", err
+		}
+		if len(args) < 2 {
+			return "", fmt.Errorf("%s: missing argument 1", op)
+		}
+		params, ok := args[1].(ConnParams)
+		if !ok {
+			return "", fmt.Errorf("%s: argument 1 must be ConnParams, got %T", op, args[1])
+		}
+		return UpdateConnParams(connID, params)
+	},
+	OpSetAdvParam: func(op Operation, args []interface{}) (string, error) {
+		min, err := argInt(op, args, 0)
+		if err != nil {
+			return "", err
+		}
+		max, err := argInt(op, args, 1)
+		if err != nil {
+			return "", err
+		}
+		return SetAdvertisingParams(min, max)
+	},
+	OpSetAdvertisingData: func(op Operation, args []interface{}) (string, error) {
+		data, err := argBytes(op, args, 0)
+		if err != nil {
+			return "", err
+		}
+		return SetAdvertisingData(data)
+	},
+	OpSetScanResponseData: func(op Operation, args []interface{}) (string, error) {
+		data, err := argBytes(op, args, 0)
+		if err != nil {
+			return "", err
+		}
+		return SetScanResponseData(data)
+	},
+	OpAddService: func(op Operation, args []interface{}) (string, error) {
+		uuid, err := argString(op, args, 0)
+		if err != nil {
+			return "", err
+		}
+		return AddService(uuid)
+	},
+	OpAddCharacteristic: func(op Operation, args []interface{}) (string, error) {
+		uuid, err := argString(op, args, 0)
+		if err != nil {
+			return "", err
+		}
+		var props int
+		if len(args) > 1 {
+			if props, err = argInt(op, args, 1); err != nil {
+				return "", err
+			}
+		}
+		return AddCharacteristic(uuid, CharProperty(props))
+	},
+	OpNotify: func(op Operation, args []interface{}) (string, error) {
+		connID, err := argInt(op, args, 0)
+		if err != nil {
+			return "", err
+		}
+		handle, err := argString(op, args, 1)
+		if err != nil {
+			return "", err
+		}
+		value, err := argString(op, args, 2)
+		if err != nil {
+			return "", err
+		}
+		return SendNotifyTo(connID, handle, value)
+	},
+	OpIndicate: func(op Operation, args []interface{}) (string, error) {
+		connID, err := argInt(op, args, 0)
+		if err != nil {
+			return "", err
+		}
+		handle, err := argString(op, args, 1)
+		if err != nil {
+			return "", err
+		}
+		value, err := argString(op, args, 2)
+		if err != nil {
+			return "", err
+		}
+		return SendIndicateTo(connID, handle, value)
+	},
+	OpSetSecurityMode: func(op Operation, args []interface{}) (string, error) {
+		mode, err := argInt(op, args, 0)
+		if err != nil {
+			return "", err
+		}
+		return SetSecurityMode(SecurityMode(mode))
+	},
+	OpSetStaticPasskey: func(op Operation, args []interface{}) (string, error) {
+		passkey, err := argString(op, args, 0)
+		if err != nil {
+			return "", err
+		}
+		return SetStaticPasskey(passkey)
+	},
+	OpReplyPasskey: func(op Operation, args []interface{}) (string, error) {
+		connID, err := argInt(op, args, 0)
+		if err != nil {
+			return "", err
+		}
+		passkey, err := argString(op, args, 1)
+		if err != nil {
+			return "", err
+		}
+		return ReplyPasskey(connID, passkey)
+	},
+	OpSetBonding: func(op Operation, args []interface{}) (string, error) {
+		enabled, err := argBool(op, args, 0)
+		if err != nil {
+			return "", err
+		}
+		return SetBonding(enabled), nil
+	},
+	OpSetEncryptionRequired: func(op Operation, args []interface{}) (string, error) {
+		required, err := argBool(op, args, 0)
+		if err != nil {
+			return "", err
+		}
+		return SetEncryptionRequired(required), nil
+	},
+	OpDeleteBond: func(op Operation, args []interface{}) (string, error) {
+		addr, err := argString(op, args, 0)
+		if err != nil {
+			return "", err
+		}
+		return DeleteBond(addr)
+	},
+	OpAddFilterAddr: func(op Operation, args []interface{}) (string, error) {
+		addr, err := argString(op, args, 0)
+		if err != nil {
+			return "", err
+		}
+		return AddFilterAddress(addr)
+	},
+	OpSetFilterPolicy: func(op Operation, args []interface{}) (string, error) {
+		enabled, err := argBool(op, args, 0)
+		if err != nil {
+			return "", err
+		}
+		return SetFilterPolicy(enabled), nil
+	},
+}
+
+// quectelCapabilities 按固件版本区间划分的能力表。
+// 目前没有按固件版本说明命令支持情况的 HCM111Z 文档，表中只有一个不限版本的区间，
+// 命令取自本驱动最初在实机上使用的命令集（复位、版本、地址、波特率、发射功率、
+// 外围设备初始化、设备名称、广播参数与启停、GATT 服务定义与连接 0 上的 Notify），
+// MTU 取最初分包使用的 247。其余命令未经实机确认，
+// 在该方言下以 ErrUnsupportedByFirmware 拒绝而不发往模块，需要时使用 quectel-extended 方言。
+// bleCommand.go 中这些命令的生成函数与下方的主动上报前缀统一标注「未经实机确认」。
+// 取得文档后按版本区间补充，并注明各区间的出处。
+var quectelCapabilities = []capabilityRange{
+	{
+		Caps: Capabilities{
+			Roles:  []int{RolePeripheral},
+			MaxMTU: 247,
+			Commands: map[Operation]bool{
+				OpReset:             true,
+				OpVersion:           true,
+				OpQueryAddr:         true,
+				OpSetBaud:           true,
+				OpSetTxPower:        true,
+				OpInit:              true,
+				OpSetName:           true,
+				OpSetAdvParam:       true,
+				OpStartAdvertising:  true,
+				OpStopAdvertising:   true,
+				OpAddService:        true,
+				OpAddCharacteristic: true,
+				OpFinishGATTServer:  true,
+				OpNotify:            true,
+			},
+		},
+	},
+}
+
+// quectelCommandPrefixes 影响模块状态的命令前缀，较长的前缀排在前面
+var quectelCommandPrefixes = []struct {
+	prefix string
+	op     Operation
+}{
+	{"AT+QRST", OpReset},
+	{"AT+QSLEEP", OpSleep},
+	{"AT+QBLEINIT=", OpInit},
+	{"AT+QBLEGATTSSRVDONE", OpFinishGATTServer},
+	{"AT+QBLEGATTSSRV=", OpAddService},
+	{"AT+QBLEGATTSCHARVAL=", OpSetCharValue},
+	{"AT+QBLEGATTSCHAR=", OpAddCharacteristic},
+	{"AT+QBLEGATTSNTFY=", OpNotify},
+	{"AT+QBLEGATTSIND=", OpIndicate},
+	{"AT+QBLEADVSTART", OpStartAdvertising},
+	{"AT+QBLEADVSTOP", OpStopAdvertising},
+	{"AT+QBLEADVPARAM=", OpSetAdvParam},
+	{"AT+QBLEADVDATA=", OpSetAdvertisingData},
+	{"AT+QBLESCANRSPDATA=", OpSetScanResponseData},
+	{"AT+QBLEDISCONN=", OpDisconnect},
+	{"AT+QBLECONNPARAM=", OpUpdateConn},
+}
+
+// Identify 识别 Quectel AT 命令对应的操作
+func (quectelDialect) Identify(cmd string) (Operation, bool) {
+	for _, p := range quectelCommandPrefixes {
+		if strings.HasPrefix(cmd, p.prefix) {
+			return p.op, true
+		}
+	}
+	return "", false
+}
+
+// Capabilities 返回指定固件版本的能力
+func (quectelDialect) Capabilities(v FirmwareVersion) (Capabilities, bool) {
+	return lookupCapabilities(quectelCapabilities, v)
+}
+
+// Name 返回方言名称
+func (quectelDialect) Name() string {
+	return DialectQuectel
+}
+
+// quectelExtendedDialect 与 Quectel 方言使用相同的命令与上报格式，但不做能力检查，
+// 尚未经实机确认的命令（安全、过滤名单、扫描、休眠、固件升级等）会直接发往模块，
+// 模块不支持时由模块返回 ERROR。
+type quectelExtendedDialect struct {
+	quectelDialect
+}
+
+// Capabilities 不提供能力表，任何固件版本都不做能力检查
+func (quectelExtendedDialect) Capabilities(FirmwareVersion) (Capabilities, bool) {
+	return Capabilities{}, false
+}
+
+// Name 返回方言名称
+func (quectelExtendedDialect) Name() string {
+	return DialectQuectelExtended
+}
+
+// Build 生成 Quectel AT 命令
+func (quectelDialect) Build(op Operation, args ...interface{}) (string, error) {
+	builder, ok := quectelBuilders[op]
+	if !ok {
+		return "", ErrUnsupportedOperation{Dialect: DialectQuectel, Op: op}
+	}
+	return builder(op, args)
+}
+
+// Classify 按 Quectel 模块的输出规则对一行数据分类
+func (quectelDialect) Classify(line string) interfaces.LineKind {
+	switch {
+	case strings.Contains(line, quectelCommandMarker):
+		return interfaces.LineCommand
+	case IsFinalLine(line),
+		strings.HasPrefix(line, infoKeyVersion), // 查询版本与地址时模块以信息行结束，不再输出 OK
+		strings.HasPrefix(line, infoKeyAddr):
+		return interfaces.LineFinal
+	}
+	return interfaces.LineData
+}
+
+// SplitCommands 按 +COMMAND: 前缀拆分一行中的各条运维命令
+func (quectelDialect) SplitCommands(line string) []string {
+	var cmds []string
+	for _, part := range strings.Split(line, quectelCommandMarker) {
+		if part != "" {
+			cmds = append(cmds, part)
+		}
+	}
+	return cmds
+}
+
+// IsError 最终响应是否为 ERROR 或 +CME ERROR 等错误结果
+func (quectelDialect) IsError(line string) bool {
+	code, _, ok := parseResultLine(strings.TrimSpace(line))
+	return ok && code == ResultError
+}
+
+// InfoPrefix 返回查询类操作结果行的前缀
+func (quectelDialect) InfoPrefix(op Operation) string {
+	switch op {
+	case OpQueryBondedPeers:
+		return infoPrefixBondList
+	case OpQueryMTU:
+		return urcPrefixMTU
+	case OpVersion:
+		return infoPrefixVersion
+	case OpQueryName:
+		return infoKeyName + ":"
+	case OpQueryTxPower:
+		return infoKeyTxPower + ":"
+	case OpQueryAdvParam:
+		return infoKeyAdvParam + ":"
+	case OpQueryBaud:
+		return infoKeyBaud + ":"
+	case OpQueryRole:
+		return infoKeyRole + ":"
+	case OpQueryRSSI:
+		return infoKeyRSSI + ":"
+	case OpFirmwareStatus:
+		return infoKeyFirmware + ":"
+	}
+	return ""
+}
+
+// NotifyFrame 返回 AT+QBLEGATTSNTFY=<conn>,<handle>,<data>\r\n 中数据前后的部分
+func (quectelDialect) NotifyFrame(connID int, handle string) Frame {
+	return Frame{
+		Prefix: "AT+QBLEGATTSNTFY=" + strconv.Itoa(connID) + "," + handle + ",",
+		Suffix: "\r\n",
+	}
+}
+
+// IndicateFrame 返回 AT+QBLEGATTSIND=<conn>,<handle>,<data>\r\n 中数据前后的部分
+func (quectelDialect) IndicateFrame(connID int, handle string) (Frame, bool) {
+	return Frame{
+		Prefix: "AT+QBLEGATTSIND=" + strconv.Itoa(connID) + "," + handle + ",",
+		Suffix: "\r\n",
+	}, true
+}
+
+// quectelWakePreamble 唤醒前导：一个空行，模块的 UART 接收引脚检测到电平变化即退出休眠，
+// 空行本身被 AT 解析器忽略，不会产生应答
+var quectelWakePreamble = []byte("\r\n")
+
+// quectelWakeSettle 模块从休眠唤醒到可以接收 AT 命令所需的时间
+const quectelWakeSettle = 50 * time.Millisecond
+
+// WakeSequence 返回唤醒休眠模块的前导数据与等待时间
+func (quectelDialect) WakeSequence() ([]byte, time.Duration) {
+	return quectelWakePreamble, quectelWakeSettle
+}
+
+// Quectel HCM111Z 主动上报前缀，标注「未经实机确认」的格式来自方言设计，模块实际输出可能不同
+const (
+	urcPrefixConnected    = "+QBLECONN:"       // +QBLECONN: <conn_idx>,<peer_addr>；未经实机确认
+	urcPrefixDisconnected = "+QBLEDISCONN:"    // +QBLEDISCONN: <conn_idx>,<reason>；未经实机确认
+	urcPrefixMTU          = "+QBLEMTU:"        // +QBLEMTU: <conn_idx>,<mtu>，MTU 协商完成或查询结果；未经实机确认
+	urcPrefixPasskeyReq   = "+QBLEPASSKEYREQ:" // +QBLEPASSKEYREQ: <conn_idx>；未经实机确认
+	urcPrefixPasskey      = "+QBLEPASSKEY:"    // +QBLEPASSKEY: <conn_idx>,<passkey>；未经实机确认
+	urcPrefixEncryption   = "+QBLEENC:"        // +QBLEENC: <conn_idx>,<0|1>；未经实机确认
+	urcPrefixIndConfirm   = "+QBLEINDCFM:"     // +QBLEINDCFM: <conn_idx>,<status>，status 为 0 表示对端已确认；未经实机确认
+	urcPrefixConnParam    = "+QBLECONNPARAM:"  // +QBLECONNPARAM: <conn_idx>,<interval>,<latency>,<timeout>，单位同 ConnParams；未经实机确认
+	urcPrefixWrite        = "+QBLEGATTSWR:"    // +QBLEGATTSWR: <conn_idx>,<handle>,<data>，data 原样输出，可能包含逗号；未经实机确认
+	urcPrefixScan         = "+QBLESCAN:"       // +QBLESCAN: <addr_type>,<addr>,<rssi>,<adv_data>，adv_data 为十六进制，含扫描响应；未经实机确认
+
+	infoPrefixBondList = "+QBLEBONDLIST:"     // +QBLEBONDLIST: <idx>,<addr>，每个绑定设备一行；未经实机确认
+	infoPrefixVersion  = infoKeyVersion + ":" // +QVERSION: <version>
+
+	bootBanner = "freqchip" // 模块上电或复位后输出的芯片信息
+
+	quectelCommandMarker = "+COMMAND:" // 中心设备写入的运维命令前缀，一次写入可能包含多条命令
+)
+
+// ParseURC 解析 Quectel 模块主动上报。
+func (quectelDialect) ParseURC(line string) (URC, bool) {
+	line = strings.TrimSpace(line)
+	switch {
+	case strings.Contains(line, bootBanner):
+		return URC{Type: URCModuleReset, Raw: line}, true
+
+	case strings.HasPrefix(line, urcPrefixConnected):
+		fields := splitURCFields(line, urcPrefixConnected)
+		if len(fields) < 2 {
+			return URC{}, false
+		}
+		connID, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return URC{}, false
+		}
+		return URC{Type: URCConnected, ConnID: connID, Addr: strings.ToUpper(fields[1]), Raw: line}, true
+
+	case strings.HasPrefix(line, urcPrefixDisconnected):
+		fields := splitURCFields(line, urcPrefixDisconnected)
+		if len(fields) < 1 {
+			return URC{}, false
+		}
+		connID, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return URC{}, false
+		}
+		urc := URC{Type: URCDisconnected, ConnID: connID, Raw: line}
+		if len(fields) > 1 {
+			urc.Reason = disconnectReason(fields[1])
+		}
+		return urc, true
+
+	case strings.HasPrefix(line, urcPrefixMTU):
+		fields := splitURCFields(line, urcPrefixMTU)
+		if len(fields) < 2 {
+			return URC{}, false
+		}
+		connID, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return URC{}, false
+		}
+		mtu, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return URC{}, false
+		}
+		return URC{Type: URCMTUChanged, ConnID: connID, MTU: mtu, Raw: line}, true
+
+	case strings.HasPrefix(line, urcPrefixPasskeyReq):
+		fields := splitURCFields(line, urcPrefixPasskeyReq)
+		if len(fields) < 1 {
+			return URC{}, false
+		}
+		connID, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return URC{}, false
+		}
+		return URC{Type: URCPasskeyRequest, ConnID: connID, Raw: line}, true
+
+	case strings.HasPrefix(line, urcPrefixPasskey):
+		fields := splitURCFields(line, urcPrefixPasskey)
+		if len(fields) < 2 {
+			return URC{}, false
+		}
+		connID, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return URC{}, false
+		}
+		return URC{Type: URCPasskeyDisplay, ConnID: connID, Passkey: fields[1], Raw: line}, true
+
+	case strings.HasPrefix(line, urcPrefixEncryption):
+		fields := splitURCFields(line, urcPrefixEncryption)
+		if len(fields) < 2 {
+			return URC{}, false
+		}
+		connID, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return URC{}, false
+		}
+		return URC{Type: URCEncryptionChanged, ConnID: connID, Encrypted: fields[1] == "1", Raw: line}, true
+
+	case strings.HasPrefix(line, urcPrefixIndConfirm):
+		fields := splitURCFields(line, urcPrefixIndConfirm)
+		if len(fields) < 1 {
+			return URC{}, false
+		}
+		connID, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return URC{}, false
+		}
+		urc := URC{Type: URCIndicationConfirmed, ConnID: connID, Raw: line}
+		if len(fields) > 1 {
+			if urc.Status, err = strconv.Atoi(fields[1]); err != nil {
+				return URC{}, false
+			}
+		}
+		return urc, true
+
+	case strings.HasPrefix(line, urcPrefixConnParam):
+		fields := splitURCFields(line, urcPrefixConnParam)
+		if len(fields) < 4 {
+			return URC{}, false
+		}
+		values := make([]int, 4)
+		for i := range values {
+			v, err := strconv.Atoi(fields[i])
+			if err != nil {
+				return URC{}, false
+			}
+			values[i] = v
+		}
+		return URC{Type: URCConnParamsUpdated, ConnID: values[0], Interval: values[1], Latency: values[2], Timeout: values[3], Raw: line}, true
+
+	case strings.HasPrefix(line, urcPrefixWrite):
+		parts := strings.SplitN(strings.TrimPrefix(line, urcPrefixWrite), ",", 3)
+		if len(parts) < 3 {
+			return URC{}, false
+		}
+		connID, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+		if err != nil {
+			return URC{}, false
+		}
+		return URC{Type: URCDataWritten, ConnID: connID, Data: parts[2], Raw: line}, true
+
+	case strings.HasPrefix(line, urcPrefixScan):
+		fields := splitURCFields(line, urcPrefixScan)
+		if len(fields) < 3 {
+			return URC{}, false
+		}
+		rssi, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return URC{}, false
+		}
+		urc := URC{Type: URCAdvReport, Addr: strings.ToUpper(fields[1]), RSSI: rssi, Raw: line}
+		if len(fields) > 3 {
+			urc.Data = fields[3]
+		}
+		return urc, true
+	}
+	return URC{}, false
+}
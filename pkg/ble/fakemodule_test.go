@@ -0,0 +1,245 @@
+package ble
+
+import (
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fakeReadTimeout 模拟串口读取超时，与真实串口的 ReadTimeout 行为一致
+const fakeReadTimeout = 50 * time.Millisecond
+
+// FakeModule 在内存中模拟 Quectel HCM111Z 模块的串口，实现 interfaces.SerialPortInterface，
+// 可直接交给 uart.NewSerialQueue，供测试在没有硬件的情况下驱动初始化与固件升级流程。
+// 固件升级按 AT+QFOTA 系列命令模拟，其余命令一律应答 OK。
+type FakeModule struct {
+	mu      sync.Mutex
+	version string      // 当前固件版本，+QVERSION 的应答内容
+	addr    string      // 模块蓝牙地址
+	name    string      // 广播名称
+	txPower string      // 发射功率（dBm）
+	adv     string      // 广播间隔，<min>,<max>
+	baud    string      // 串口波特率
+	role    string      // BLE 角色，0 表示未初始化
+	out     chan string // 待模块输出的行
+	closed  bool
+
+	// 固件升级
+	upgradeTo string       // 升级成功后报告的新版本，为空时沿用当前版本
+	fotaSize  int          // 正在接收的固件长度
+	fotaCRC   uint32       // 正在接收的固件校验值
+	fotaData  []byte       // 已接收的固件数据
+	failAt    map[int]bool // 在这些偏移处模拟一次写入失败，用于验证续传
+	dropAt    map[int]bool // 在这些偏移处写入成功但丢弃应答，用于验证应答丢失后的同步
+	history   []string     // 收到的命令，便于检查交互过程
+}
+
+// NewFakeModule 创建运行指定固件版本的模拟模块。
+func NewFakeModule(version string) *FakeModule {
+	return &FakeModule{
+		version: version,
+		addr:    "AA:BB:CC:DD:EE:FF",
+		name:    DefaultDeviceName,
+		txPower: "0",
+		adv:     fmt.Sprintf("%d,%d", DefaultAdvIntervalMs, DefaultAdvIntervalMs),
+		baud:    "115200",
+		role:    "0",
+		out:     make(chan string, 64),
+		failAt:  make(map[int]bool),
+		dropAt:  make(map[int]bool),
+	}
+}
+
+// SetUpgradeVersion 设置固件升级成功后模块报告的新版本。
+func (m *FakeModule) SetUpgradeVersion(version string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.upgradeTo = version
+}
+
+// FailChunkAt 使偏移 offset 处的下一次固件数据写入返回 ERROR。
+func (m *FakeModule) FailChunkAt(offset int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failAt[offset] = true
+}
+
+// DropResponseAt 使偏移 offset 处的下一次固件数据写入成功但不输出应答。
+func (m *FakeModule) DropResponseAt(offset int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dropAt[offset] = true
+}
+
+// Version 返回模块当前的固件版本。
+func (m *FakeModule) Version() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.version
+}
+
+// History 返回模块收到的全部命令。
+func (m *FakeModule) History() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.history...)
+}
+
+// Emit 模拟模块主动输出一行，如连接上报。
+func (m *FakeModule) Emit(line string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.emit(line)
+}
+
+// Write 接收一条或多条 AT 命令并生成应答
+func (m *FakeModule) Write(data []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return 0, io.ErrClosedPipe
+	}
+	for _, cmd := range strings.Split(string(data), "\r\n") {
+		if cmd = strings.TrimSpace(cmd); cmd != "" {
+			m.history = append(m.history, cmd)
+			m.handle(cmd)
+		}
+	}
+	return len(data), nil
+}
+
+// ReadLine 读取模块输出的一行，没有输出时超时返回 io.EOF
+func (m *FakeModule) ReadLine() (string, error) {
+	select {
+	case line, ok := <-m.out:
+		if !ok {
+			return "", io.EOF
+		}
+		return line + "\r\n", nil
+	case <-time.After(fakeReadTimeout):
+		return "", io.EOF
+	}
+}
+
+// Close 关闭模拟串口
+func (m *FakeModule) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.closed {
+		m.closed = true
+		close(m.out)
+	}
+	return nil
+}
+
+// emit 输出一行，调用方持有 m.mu
+func (m *FakeModule) emit(lines ...string) {
+	if m.closed {
+		return
+	}
+	for _, line := range lines {
+		m.out <- line
+	}
+}
+
+// handle 按命令生成应答，调用方持有 m.mu
+func (m *FakeModule) handle(cmd string) {
+	name, arg, _ := strings.Cut(cmd, "=")
+	switch name {
+	case "AT+QVERSION":
+		m.emit(infoPrefixVersion + " " + m.version)
+	case "AT+QBLEADDR?":
+		m.emit(infoKeyAddr + ": " + m.addr)
+	case "AT+QBLENAME?":
+		m.emit(infoKeyName+": "+m.name, "OK")
+	case "AT+QBLENAME":
+		m.name = arg
+		m.emit("OK")
+	case "AT+QTXPOWER?":
+		m.emit(infoKeyTxPower+": "+m.txPower, "OK")
+	case "AT+QTXPOWER":
+		m.txPower = arg
+		m.emit("OK")
+	case "AT+QBLEADVPARAM?":
+		m.emit(infoKeyAdvParam+": "+m.adv, "OK")
+	case "AT+QBLEADVPARAM":
+		m.adv = arg
+		m.emit("OK")
+	case "AT+QSETBAUD?":
+		m.emit(infoKeyBaud+": "+m.baud, "OK")
+	case "AT+QSETBAUD":
+		m.baud = arg
+		m.emit("OK")
+	case "AT+QBLEINIT?":
+		m.emit(infoKeyRole+": "+m.role, "OK")
+	case "AT+QBLEINIT":
+		m.role = arg
+		m.emit("OK")
+	case "AT+QRST":
+		m.role = "0"
+		m.emit("OK", bootBanner)
+	case "AT+QFOTA":
+		m.fotaBegin(arg)
+	case "AT+QFOTA?":
+		m.emit(fmt.Sprintf("%s: %d,%08X,%d", infoKeyFirmware, m.fotaSize, m.fotaCRC, len(m.fotaData)), "OK")
+	case "AT+QFOTADATA":
+		m.fotaWrite(arg)
+	case "AT+QFOTAEND":
+		m.fotaApply()
+	default:
+		m.emit("OK")
+	}
+}
+
+// fotaBegin 开始接收新固件，清空已接收的数据
+func (m *FakeModule) fotaBegin(arg string) {
+	sizeStr, crcStr, _ := strings.Cut(arg, ",")
+	size, err := strconv.Atoi(sizeStr)
+	crc, cerr := strconv.ParseUint(crcStr, 16, 32)
+	if err != nil || cerr != nil || size <= 0 {
+		m.emit("ERROR")
+		return
+	}
+	m.fotaSize, m.fotaCRC, m.fotaData = size, uint32(crc), nil
+	m.emit("OK")
+}
+
+// fotaWrite 写入一段固件数据，偏移必须等于已接收的长度
+func (m *FakeModule) fotaWrite(arg string) {
+	offStr, hexStr, _ := strings.Cut(arg, ",")
+	offset, err := strconv.Atoi(offStr)
+	data, herr := hex.DecodeString(hexStr)
+	if err != nil || herr != nil || m.fotaSize == 0 || offset != len(m.fotaData) || offset+len(data) > m.fotaSize {
+		m.emit("ERROR")
+		return
+	}
+	if m.failAt[offset] {
+		delete(m.failAt, offset)
+		m.emit("ERROR")
+		return
+	}
+	m.fotaData = append(m.fotaData, data...)
+	if m.dropAt[offset] {
+		delete(m.dropAt, offset)
+		return
+	}
+	m.emit("OK")
+}
+
+// fotaApply 校验已接收的固件，通过后切换版本并模拟重启
+func (m *FakeModule) fotaApply() {
+	if m.fotaSize == 0 || len(m.fotaData) != m.fotaSize || crc32.ChecksumIEEE(m.fotaData) != m.fotaCRC {
+		m.emit("ERROR")
+		return
+	}
+	if m.upgradeTo != "" {
+		m.version = m.upgradeTo
+	}
+	m.fotaSize, m.fotaCRC, m.fotaData = 0, 0, nil
+	m.emit("OK", bootBanner)
+}
@@ -0,0 +1,90 @@
+package ble
+
+import "fmt"
+
+// DetectFirmware 查询并解析模块固件版本，按方言的能力表确定当前固件支持的能力。
+// 版本不在能力表中时保留版本信息，但不限制可用操作。
+func (c *BLEController) DetectFirmware() (FirmwareVersion, error) {
+	cmd, err := c.dialect.Build(OpVersion)
+	if err != nil {
+		return FirmwareVersion{}, err
+	}
+	resp, err := c.SendSingleWithResponse(cmd)
+	if err != nil {
+		return FirmwareVersion{}, err
+	}
+	v, err := ParseFirmwareVersion(resp, c.dialect.InfoPrefix(OpVersion))
+	if err != nil {
+		return v, err
+	}
+
+	caps, ok := c.dialect.Capabilities(v)
+	c.fwMu.Lock()
+	c.firmware = &v
+	c.caps = nil
+	if ok {
+		c.caps = &caps
+	}
+	c.fwMu.Unlock()
+
+	if ok {
+		c.logger.Infof("📦 模块固件: model=%s, version=%s, maxMTU=%d, maxConnections=%d", v.Model, v, caps.MaxMTU, caps.MaxConnections)
+	} else {
+		c.logger.Infof("📦 模块固件: model=%s, version=%s，不在 %s 方言的能力表中，不做能力检查", v.Model, v, c.dialect.Name())
+	}
+	return v, nil
+}
+
+// Firmware 返回已识别的固件版本，未识别时返回 false。
+func (c *BLEController) Firmware() (FirmwareVersion, bool) {
+	c.fwMu.RLock()
+	defer c.fwMu.RUnlock()
+	if c.firmware == nil {
+		return FirmwareVersion{}, false
+	}
+	return *c.firmware, true
+}
+
+// Capabilities 返回当前固件的能力，固件未识别或不在能力表中时返回 false。
+func (c *BLEController) Capabilities() (Capabilities, bool) {
+	c.fwMu.RLock()
+	defer c.fwMu.RUnlock()
+	if c.caps == nil {
+		return Capabilities{}, false
+	}
+	return *c.caps, true
+}
+
+// checkCapability 检查当前固件是否支持该操作，避免发送必然返回 ERROR 的命令
+func (c *BLEController) checkCapability(op Operation, args []interface{}) error {
+	c.fwMu.RLock()
+	caps, fw := c.caps, c.firmware
+	c.fwMu.RUnlock()
+	if caps == nil {
+		return nil
+	}
+	// 固件版本识别之前使用方言为任意版本提供的能力
+	version := FirmwareVersion{Raw: "not detected"}
+	if fw != nil {
+		version = *fw
+	}
+	if !caps.Supports(op) {
+		return ErrUnsupportedByFirmware{Firmware: version, Op: op}
+	}
+	if op == OpInit {
+		if role, err := argInt(op, args, 0); err == nil && !caps.SupportsRole(role) {
+			return ErrUnsupportedByFirmware{Firmware: version, Op: op, Detail: fmt.Sprintf("role %d not in %v", role, caps.Roles)}
+		}
+	}
+	return nil
+}
+
+// maxMTU 返回当前固件支持的最大 MTU，未知时返回 0
+func (c *BLEController) maxMTU() int {
+	c.fwMu.RLock()
+	defer c.fwMu.RUnlock()
+	if c.caps == nil {
+		return 0
+	}
+	return c.caps.MaxMTU
+}
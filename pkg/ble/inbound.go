@@ -0,0 +1,101 @@
+package ble
+
+import (
+	"device-ble/internal/interfaces"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ConnUnknown 上行数据未携带连接索引（模块以透传方式输出）且无法唯一确定来源时使用
+const ConnUnknown = -1
+
+// ErrNoConnection 没有已连接的中心设备
+var ErrNoConnection = errors.New("no connected central")
+
+// SetInboundHandlers 注册携带连接索引的上行处理函数：command 处理运维命令，data 处理透明代理数据。
+// 模块以透传方式输出、不带连接索引的数据仍由串口队列的回调处理。
+func (c *BLEController) SetInboundHandlers(command, data func(connID int, payload string)) {
+	c.inMu.Lock()
+	defer c.inMu.Unlock()
+	c.commandHandler = command
+	c.dataHandler = data
+}
+
+// ResolveConn 为不带连接索引的上行数据确定来源：只有一个连接时返回该连接；尚未解析到过连接上报时
+// 连接列表不可信，按原有方式返回连接 0；其余情况返回 ConnUnknown。
+func (c *BLEController) ResolveConn() int {
+	c.connMu.RLock()
+	defer c.connMu.RUnlock()
+	if len(c.connections) == 0 && !c.connTracked {
+		return 0
+	}
+	if len(c.connections) != 1 {
+		return ConnUnknown
+	}
+	for id := range c.connections {
+		return id
+	}
+	return ConnUnknown
+}
+
+// onDataWritten 将中心设备写入的内容按运维命令或透明代理数据分发，处理函数在新的协程中执行
+func (c *BLEController) onDataWritten(urc URC) {
+	c.inMu.RLock()
+	command, data := c.commandHandler, c.dataHandler
+	c.inMu.RUnlock()
+	c.MarkActivity()
+
+	if c.dialect.Classify(urc.Data) != interfaces.LineCommand {
+		if data != nil {
+			go data(urc.ConnID, urc.Data)
+		}
+		return
+	}
+	for _, part := range c.dialect.SplitCommands(urc.Data) {
+		if command != nil {
+			go command(urc.ConnID, part)
+		}
+	}
+}
+
+// SendJSONTo 将数据分包发送给指定连接。
+func (c *BLEController) SendJSONTo(connID int, data interface{}) error {
+	_, err := c.SendJSONWithOptions(data, SendOptions{ConnID: connID})
+	return err
+}
+
+// SendStringTo 通过默认特征值向指定连接发送一条字符串 Notify，长度受该连接协商的 MTU 限制。
+func (c *BLEController) SendStringTo(connID int, value string) error {
+	frame := c.dialect.NotifyFrame(connID, DefaultCharacteristicUUID)
+	if max := MaxPayload(c.MTU(connID), frame) + HeaderSize; len(value) > max {
+		return fmt.Errorf("string too long: %d bytes, max %d for current MTU", len(value), max)
+	}
+	cmd, err := c.build(OpNotify, connID, DefaultCharacteristicUUID, value)
+	if err != nil {
+		return err
+	}
+	return c.SendSingle(cmd)
+}
+
+// broadcast 对每个已连接的中心设备执行一次发送，返回所有失败连接的汇总错误。
+// 尚未解析到过连接上报时连接列表不可信，按原有方式发送给连接 0。
+func (c *BLEController) broadcast(send func(connID int) error) error {
+	conns := c.Connections()
+	if len(conns) == 0 {
+		if !c.ConnectionTracked() {
+			return send(0)
+		}
+		return ErrNoConnection
+	}
+	var failed []string
+	for _, conn := range conns {
+		if err := send(conn.ConnID); err != nil {
+			failed = append(failed, fmt.Sprintf("conn %d: %v", conn.ConnID, err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("broadcast failed for %d of %d connections: %s", len(failed), len(conns), strings.Join(failed, "; "))
+	}
+	return nil
+}
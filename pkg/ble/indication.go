@@ -0,0 +1,203 @@
+package ble
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 数据发送方式
+const (
+	DeliveryNotify   = "notify"   // Notify，模块写入成功即视为发送完成，不保证送达
+	DeliveryIndicate = "indicate" // Indication，每个分包都等待对端确认
+)
+
+// 消息送达状态
+const (
+	DeliverySent      = "sent"      // 已全部写入模块，未经对端确认
+	DeliveryConfirmed = "confirmed" // 每个分包都已由对端确认
+	DeliveryFailed    = "failed"    // 发送中断，见 Error
+)
+
+// DefaultIndicationTimeout 等待对端确认单个 Indication 分包的默认时间
+const DefaultIndicationTimeout = 3 * time.Second
+
+// errIndicationCancelled 等待确认期间连接断开或模块复位
+var errIndicationCancelled = errors.New("connection closed before indication was confirmed")
+
+// SendOptions 数据发送选项
+type SendOptions struct {
+	ConnID         int           // 目标连接索引
+	Indicate       bool          // 优先使用 Indication，方言或固件不支持时退回 Notify
+	ConfirmTimeout time.Duration // 单个分包等待确认的时间，<= 0 时使用 DefaultIndicationTimeout
+}
+
+// DeliveryReport 一条消息的送达结果
+type DeliveryReport struct {
+	ID        string `json:"id"`              // 消息标识
+	ConnID    int    `json:"connId"`          // 目标连接索引
+	Mode      string `json:"mode"`            // notify / indicate
+	Packets   int    `json:"packets"`         // 总分包数
+	Sent      int    `json:"sent"`            // 模块已接受的分包数
+	Confirmed int    `json:"confirmed"`       // 对端已确认的分包数（仅 indicate）
+	Status    string `json:"status"`          // sent / confirmed / failed
+	Error     string `json:"error,omitempty"` // 失败原因
+}
+
+// SendJSONWithOptions 将数据序列化为 JSON，按连接协商的 MTU 分包后发送，并返回送达结果。
+// 使用 Indication 时每个分包在收到对端确认后才发送下一个。
+func (c *BLEController) SendJSONWithOptions(data interface{}, opts SendOptions) (DeliveryReport, error) {
+	report := DeliveryReport{ID: uuid.New().String(), ConnID: opts.ConnID, Mode: DeliveryNotify}
+	fail := func(err error) (DeliveryReport, error) {
+		report.Status = DeliveryFailed
+		report.Error = err.Error()
+		c.logger.Errorf("⛔️ 消息 %s 发送失败 (%s, %d/%d): %v", report.ID, report.Mode, report.Sent, report.Packets, err)
+		return report, err
+	}
+
+	frame := c.dialect.NotifyFrame(opts.ConnID, DefaultCharacteristicUUID)
+	if opts.Indicate {
+		if f, ok := c.indicateFrame(opts.ConnID); ok {
+			frame, report.Mode = f, DeliveryIndicate
+		} else {
+			c.logger.Warnf("模块不支持 Indication，消息 %s 改用 Notify 发送", report.ID)
+		}
+	}
+	op := OpNotify
+	if report.Mode == DeliveryIndicate {
+		op = OpIndicate
+	}
+	if err := c.checkState(op); err != nil {
+		return fail(err)
+	}
+
+	_, commands, err := encodeJSONPackets(frame, c.MTU(opts.ConnID), data)
+	if err != nil {
+		return fail(err)
+	}
+	report.Packets = len(commands)
+	c.MarkActivity()
+	// 多包传输期间使用大数据量连接参数
+	if report.Packets > 1 {
+		c.beginBulkTransfer(opts.ConnID)
+		defer c.endBulkTransfer(opts.ConnID)
+	}
+
+	timeout := opts.ConfirmTimeout
+	if timeout <= 0 {
+		timeout = DefaultIndicationTimeout
+	}
+	if report.Mode == DeliveryIndicate {
+		c.indSendMu.Lock()
+		defer c.indSendMu.Unlock()
+	}
+	for _, cmd := range commands {
+		if report.Mode == DeliveryNotify {
+			if err := c.sendPacket(cmd); err != nil {
+				return fail(err)
+			}
+			report.Sent++
+			continue
+		}
+		if err := c.indicatePacket(opts.ConnID, cmd, timeout, &report); err != nil {
+			return fail(err)
+		}
+	}
+
+	report.Status = DeliverySent
+	if report.Mode == DeliveryIndicate {
+		report.Status = DeliveryConfirmed
+	}
+	c.logger.Infof("✅️ 消息 %s 发送完成 (%s): %d 个分包, status=%s", report.ID, report.Mode, report.Packets, report.Status)
+	return report, nil
+}
+
+// indicateFrame 返回 Indication 的命令组成，方言或当前固件不支持时返回 false
+func (c *BLEController) indicateFrame(connID int) (Frame, bool) {
+	if err := c.checkCapability(OpIndicate, nil); err != nil {
+		return Frame{}, false
+	}
+	return c.dialect.IndicateFrame(connID, DefaultCharacteristicUUID)
+}
+
+// sendPacket 发送一个分包并检查模块应答
+func (c *BLEController) sendPacket(cmd []byte) error {
+	response, err := c.Queue.SendCommand(cmd, 300*time.Millisecond, 1*time.Millisecond, 100*time.Millisecond)
+	if err != nil {
+		return err
+	}
+	r := ParseResponse(response)
+	if rerr := r.Err(); rerr != nil {
+		return rerr
+	}
+	if !r.Succeeded() {
+		return fmt.Errorf("unexpected response: %q", response)
+	}
+	return nil
+}
+
+// indicatePacket 发送一个 Indication 分包并等待对端确认
+func (c *BLEController) indicatePacket(connID int, cmd []byte, timeout time.Duration, report *DeliveryReport) error {
+	// 确认上报可能早于模块应答到达，先登记再发送
+	confirm := c.expectIndication(connID)
+	defer c.cancelIndication(connID)
+
+	if err := c.sendPacket(cmd); err != nil {
+		return err
+	}
+	report.Sent++
+
+	select {
+	case urc, ok := <-confirm:
+		if !ok {
+			return errIndicationCancelled
+		}
+		if urc.Status != 0 {
+			return fmt.Errorf("indication rejected by peer, status %d", urc.Status)
+		}
+		report.Confirmed++
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("indication not confirmed within %v", timeout)
+	}
+}
+
+// expectIndication 登记等待指定连接的 Indication 确认。ATT 规定同一连接同时只能有一个未确认的 Indication。
+func (c *BLEController) expectIndication(connID int) <-chan URC {
+	c.indMu.Lock()
+	defer c.indMu.Unlock()
+	if ch, ok := c.indWaiters[connID]; ok {
+		close(ch)
+	}
+	ch := make(chan URC, 1)
+	c.indWaiters[connID] = ch
+	return ch
+}
+
+// cancelIndication 取消指定连接上等待中的 Indication 确认
+func (c *BLEController) cancelIndication(connID int) {
+	c.indMu.Lock()
+	defer c.indMu.Unlock()
+	if ch, ok := c.indWaiters[connID]; ok {
+		close(ch)
+		delete(c.indWaiters, connID)
+	}
+}
+
+// onIndicationConfirmed 将对端的确认交给等待中的发送方
+func (c *BLEController) onIndicationConfirmed(urc URC) {
+	c.indMu.Lock()
+	ch, ok := c.indWaiters[urc.ConnID]
+	if ok {
+		delete(c.indWaiters, urc.ConnID)
+	}
+	c.indMu.Unlock()
+	if !ok {
+		c.logger.Debugf("收到未等待的 Indication 确认: conn=%d, status=%d", urc.ConnID, urc.Status)
+		return
+	}
+	ch <- urc
+	close(ch)
+}
@@ -0,0 +1,234 @@
+package ble
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// InitPolicy 初始化序列的重试与恢复策略
+type InitPolicy struct {
+	MaxAttempts   int           // 单条命令遇到临时错误时的最大尝试次数
+	RetryDelay    time.Duration // 临时错误重试间隔，按尝试次数递增
+	MaxRecoveries int           // 遇到硬错误后复位模块并重新执行整个序列的最大次数
+}
+
+// DefaultInitPolicy 默认初始化策略
+var DefaultInitPolicy = InitPolicy{
+	MaxAttempts:   3,
+	RetryDelay:    200 * time.Millisecond,
+	MaxRecoveries: 1,
+}
+
+// StepResult 初始化序列中单条命令的执行结果
+type StepResult struct {
+	Command  string        `json:"command"`
+	Op       Operation     `json:"op,omitempty"`
+	Attempts int           `json:"attempts"`
+	Response string        `json:"response,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// InitReport 一次初始化的完整过程，包含每次恢复前后执行过的所有步骤
+type InitReport struct {
+	Steps      []StepResult `json:"steps"`
+	Recoveries int          `json:"recoveries"`
+	Succeeded  bool         `json:"succeeded"`
+}
+
+// InitError 初始化失败的汇总错误
+type InitError struct {
+	Report InitReport
+	Failed StepResult // 最后一次失败的步骤
+	Err    error      // 最后一次失败的原因
+}
+
+func (e *InitError) Error() string {
+	failed := 0
+	for _, step := range e.Report.Steps {
+		if step.Error != "" {
+			failed++
+		}
+	}
+	return fmt.Sprintf("BLE init failed at %q after %d recoveries (%d of %d steps failed): %v",
+		strings.TrimSpace(e.Failed.Command), e.Report.Recoveries, failed, len(e.Report.Steps), e.Err)
+}
+
+func (e *InitError) Unwrap() error {
+	return e.Err
+}
+
+// isUnsupported 判断错误是否因方言或固件不支持该操作
+func isUnsupported(err error) bool {
+	var unsupportedOp ErrUnsupportedOperation
+	var unsupportedFw ErrUnsupportedByFirmware
+	return errors.As(err, &unsupportedOp) || errors.As(err, &unsupportedFw)
+}
+
+// isTransient 判断错误是否可以通过重试恢复：串口超时、队列满等属于临时错误，
+// 模块明确拒绝、状态不允许或固件不支持属于硬错误，重试没有意义。
+func isTransient(err error) bool {
+	var respErr *ResponseError
+	var stateErr ErrInvalidState
+	return !errors.As(err, &respErr) && !errors.As(err, &stateErr) && !isUnsupported(err)
+}
+
+// SetInitPolicy 设置初始化序列的重试与恢复策略。
+func (c *BLEController) SetInitPolicy(policy InitPolicy) {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	if policy.MaxRecoveries < 0 {
+		policy.MaxRecoveries = 0
+	}
+	c.initMu.Lock()
+	defer c.initMu.Unlock()
+	c.initPolicy = policy
+}
+
+// SetInitSetup 设置每次初始化在开始广播之前执行的配置，包括启动初始化、模块复位后的重建与运行时重新初始化，
+// 用于下发链路安全等随模块复位丢失、又必须在中心设备可以连接之前生效的配置。返回错误时不开始广播。
+func (c *BLEController) SetInitSetup(setup func() error) {
+	c.initMu.Lock()
+	defer c.initMu.Unlock()
+	c.initSetup = setup
+}
+
+// LastInitReport 返回最近一次初始化序列的执行结果。
+func (c *BLEController) LastInitReport() InitReport {
+	c.initMu.Lock()
+	defer c.initMu.Unlock()
+	return c.initReport
+}
+
+// runSequence 依次发送初始化命令：临时错误按策略重试，硬错误时复位模块并从头重新执行，
+// 恢复次数用尽后返回 *InitError。setup 不为空时作为序列中的一步，在最后的开始广播命令之前执行，
+// 序列不以开始广播结束时在最后执行，同样计入初始化结果并随序列一起恢复。
+func (c *BLEController) runSequence(cmds []string, setup func() error) error {
+	c.initMu.Lock()
+	policy := c.initPolicy
+	c.initMu.Unlock()
+
+	var report InitReport
+	defer func() {
+		c.initMu.Lock()
+		c.initReport = report
+		c.initMu.Unlock()
+	}()
+
+	for {
+		failed, err := c.runSteps(cmds, setup, policy, &report)
+		if err == nil {
+			report.Succeeded = true
+			c.logger.Infof("BLE设备已成功初始化为外围设备，模块状态: %s，共 %d 步，恢复 %d 次", c.State(), len(report.Steps), report.Recoveries)
+			return nil
+		}
+		// 方言或固件不支持的命令复位后依然不支持，不做恢复
+		if report.Recoveries >= policy.MaxRecoveries || isUnsupported(err) {
+			c.logger.Errorf("⛔️ BLE设备初始化失败，模块状态停留在 %s: %v", c.State(), err)
+			return &InitError{Report: report, Failed: failed, Err: err}
+		}
+		report.Recoveries++
+		c.logger.Warnf("🔁 初始化命令 %q 失败，复位模块后重新执行初始化序列（第 %d 次恢复）: %v", strings.TrimSpace(failed.Command), report.Recoveries, err)
+		if err := c.recoverModule(cmds, &report); err != nil {
+			return &InitError{Report: report, Failed: report.Steps[len(report.Steps)-1], Err: err}
+		}
+	}
+}
+
+// runSteps 执行一遍命令序列，返回第一个失败的步骤
+func (c *BLEController) runSteps(cmds []string, setup func() error, policy InitPolicy, report *InitReport) (StepResult, error) {
+	setupAt := -1
+	if setup != nil {
+		setupAt = len(cmds)
+		if len(cmds) > 0 {
+			if op, ok := c.dialect.Identify(cmds[len(cmds)-1]); ok && op == OpStartAdvertising {
+				setupAt = len(cmds) - 1
+			}
+		}
+	}
+	for i := 0; i <= len(cmds); i++ {
+		if i == setupAt {
+			step, err := runSetup(setup)
+			report.Steps = append(report.Steps, step)
+			if err != nil {
+				return step, err
+			}
+		}
+		if i == len(cmds) {
+			break
+		}
+		step, err := c.runStep(cmds[i], policy)
+		report.Steps = append(report.Steps, step)
+		if err != nil {
+			return step, err
+		}
+	}
+	return StepResult{}, nil
+}
+
+// setupStep 初始化结果中配置步骤的名称
+const setupStep = "setup"
+
+// runSetup 执行开始广播之前的配置步骤，配置由多条命令组成且自行处理错误，不做重试
+func runSetup(setup func() error) (StepResult, error) {
+	start := time.Now()
+	step := StepResult{Command: setupStep, Attempts: 1}
+	err := setup()
+	if err != nil {
+		step.Error = err.Error()
+	}
+	step.Duration = time.Since(start)
+	return step, err
+}
+
+// runStep 发送一条初始化命令，临时错误按策略重试
+func (c *BLEController) runStep(cmd string, policy InitPolicy) (StepResult, error) {
+	step := StepResult{Command: cmd}
+	step.Op, _ = c.dialect.Identify(cmd)
+	start := time.Now()
+
+	var err error
+	for step.Attempts = 1; ; step.Attempts++ {
+		var response string
+		response, err = c.transmit(cmd, 2*time.Second, 1*time.Second)
+		if err == nil {
+			step.Response = response
+			err = c.checkResponse(cmd, response)
+		} else {
+			c.logger.Errorf("❌发送%v, 出现错误 :%v, response:%v", cmd, err, response)
+		}
+		if err == nil || !isTransient(err) || step.Attempts >= policy.MaxAttempts {
+			break
+		}
+		c.logger.Warnf("初始化命令 %q 第 %d 次发送失败，%v 后重试: %v", strings.TrimSpace(cmd), step.Attempts, policy.RetryDelay*time.Duration(step.Attempts), err)
+		time.Sleep(policy.RetryDelay * time.Duration(step.Attempts))
+	}
+	if err != nil {
+		step.Error = err.Error()
+	}
+	step.Duration = time.Since(start)
+	return step, err
+}
+
+// recoverModule 复位模块并等待其重新启动；序列本身以复位开头时由重新执行的序列完成复位
+func (c *BLEController) recoverModule(cmds []string, report *InitReport) error {
+	if len(cmds) > 0 {
+		if op, ok := c.dialect.Identify(cmds[0]); ok && op == OpReset {
+			return nil
+		}
+	}
+	cmd, err := c.build(OpReset)
+	if err != nil {
+		return err
+	}
+	step, err := c.runStep(cmd, InitPolicy{MaxAttempts: 1})
+	report.Steps = append(report.Steps, step)
+	if err != nil {
+		return err
+	}
+	time.Sleep(DefaultResetSettleDelay)
+	return nil
+}
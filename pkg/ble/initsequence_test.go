@@ -0,0 +1,44 @@
+package ble
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestInitReportIncludesSetupAndAdvertising(t *testing.T) {
+	m := NewFakeModule("HCM111Z V1.4.0")
+	c := newFakeController(t, m, quectelDialect{})
+	if err := c.InitializeWithSetup(DefaultDesiredConfig(), func() error { return nil }); err != nil {
+		t.Fatalf("InitializeWithSetup: %v", err)
+	}
+	report := c.LastInitReport()
+	n := len(report.Steps)
+	if !report.Succeeded || n < 2 {
+		t.Fatalf("report = %+v", report)
+	}
+	if report.Steps[n-2].Command != setupStep {
+		t.Errorf("second to last step = %q, want %q", report.Steps[n-2].Command, setupStep)
+	}
+	if report.Steps[n-1].Op != OpStartAdvertising {
+		t.Errorf("last step = %q (%s), want start advertising", report.Steps[n-1].Command, report.Steps[n-1].Op)
+	}
+}
+
+func TestInitSetupFailureStopsBeforeAdvertising(t *testing.T) {
+	m := NewFakeModule("HCM111Z V1.4.0")
+	c := newFakeController(t, m, quectelDialect{})
+	c.SetInitPolicy(InitPolicy{MaxAttempts: 1})
+	setupErr := errors.New("security rejected")
+	err := c.InitializeWithSetup(DefaultDesiredConfig(), func() error { return setupErr })
+
+	var initErr *InitError
+	if !errors.As(err, &initErr) || !errors.Is(err, setupErr) {
+		t.Fatalf("InitializeWithSetup = %v, want InitError wrapping the setup error", err)
+	}
+	if initErr.Failed.Command != setupStep {
+		t.Errorf("failed step = %q, want %q", initErr.Failed.Command, setupStep)
+	}
+	if n := countCommands(m, "AT+QBLEADVSTART"); n != 0 {
+		t.Errorf("sent %d start advertising commands after a failed setup", n)
+	}
+}
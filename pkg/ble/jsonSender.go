@@ -5,20 +5,31 @@ import (
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
-	"strings"
 	"time"
-
-	"github.com/google/uuid"
 )
 
 const (
-	MTU        = 247    // 蓝牙模块 MTU 限制为 64 字节
-	Suffix     = "\r\n" // AT 指令后缀 (2字节)
-	HeaderSize = 4      // 分包头部：2 字节索引 + 2 字节总包数
+	MaxCommandLen = 247 // 蓝牙模块单条 AT 指令的最大长度
+	MinMTU        = 23  // BLE 规范规定的最小 ATT MTU
+	DefaultMTU    = 247 // 未获知协商结果时使用的 MTU，与引入分包前的固定包长一致
+	ATTHeaderSize = 3   // Notify 的 ATT 头部：1 字节操作码 + 2 字节句柄
+	HeaderSize    = 4   // 分包头部：2 字节索引 + 2 字节总包数
 )
 
-var Prefix string = "AT+QBLEGATTSNTFY=0,fff2,"                    // AT 指令前缀 (20字节）
-var MaxPayload int = MTU - len(Prefix) - len(Suffix) - HeaderSize // 实际载荷大小：247 - 20 - 2 - 4 = 221 字节
+// MaxPayload 根据连接协商的 MTU 与发送命令的组成计算单个分包的实际载荷大小。
+// Notify 的值不能超过 MTU - 3，同时整条 AT 指令不能超过模块限制，
+// 例如 MTU 为 247 时：min(247 - 3, 247 - 20 - 2) - 4 = 221 字节；MTU 为 23 时：23 - 3 - 4 = 16 字节。
+func MaxPayload(mtu int, frame Frame) int {
+	if mtu < MinMTU {
+		mtu = MinMTU
+	}
+	payload := mtu - ATTHeaderSize
+	if limit := MaxCommandLen - len(frame.Prefix) - len(frame.Suffix); payload > limit {
+		payload = limit
+	}
+	return payload - HeaderSize
+}
+
 // Packet 分包结构
 type Packet struct {
 	Index   uint16 // 分包索引
@@ -26,19 +37,19 @@ type Packet struct {
 	Payload []byte // 数据载荷
 }
 
-// splitIntoPackets 将数据分包
-func splitIntoPackets(data []byte) []Packet {
+// splitIntoPackets 按单包最大载荷将数据分包
+func splitIntoPackets(data []byte, maxPayload int) []Packet {
 	var packets []Packet
-	totalPackets := (len(data) + MaxPayload - 1) / MaxPayload // 向上取整
+	totalPackets := (len(data) + maxPayload - 1) / maxPayload // 向上取整
 
-	for i := 0; i < len(data); i += MaxPayload {
-		end := i + MaxPayload
+	for i := 0; i < len(data); i += maxPayload {
+		end := i + maxPayload
 		if end > len(data) {
 			end = len(data)
 		}
 
 		packet := Packet{
-			Index:   uint16(i / MaxPayload),
+			Index:   uint16(i / maxPayload),
 			Total:   uint16(totalPackets),
 			Payload: data[i:end],
 		}
@@ -48,35 +59,48 @@ func splitIntoPackets(data []byte) []Packet {
 	return packets
 }
 
-// SendJSONOverBLE 发送 JSON 数据的主要函数。
-func SendJSONOverBLE(sq interfaces.SerialQueueInterface, jsonData interface{}) error {
-	// 这里需要传入logger参数，建议后续重构接口
-	// 目前先用fmt.Println模拟日志，后续可传入logger
-	tag := uuid.New().String()
+// encodeJSONPackets 将数据序列化为 JSON 并分包，返回各分包及其完整的发送命令
+func encodeJSONPackets(frame Frame, mtu int, jsonData interface{}) ([]Packet, [][]byte, error) {
 	dataBytes, err := json.Marshal(jsonData)
 	if err != nil {
-		return fmt.Errorf("JSON序列化失败: %v", err)
+		return nil, nil, fmt.Errorf("JSON序列化失败: %v", err)
 	}
-	packets := splitIntoPackets(dataBytes)
+	packets := splitIntoPackets(dataBytes, MaxPayload(mtu, frame))
+	prefix, suffix := frame.Prefix, frame.Suffix
+	commands := make([][]byte, 0, len(packets))
 	for _, packet := range packets {
-		packetData := make([]byte, len(Prefix)+HeaderSize+len(packet.Payload)+len(Suffix))
-		copy(packetData, Prefix)
-		binary.BigEndian.PutUint16(packetData[len(Prefix):], packet.Index)
-		binary.BigEndian.PutUint16(packetData[len(Prefix)+2:], packet.Total)
-		copy(packetData[len(Prefix)+HeaderSize:], packet.Payload)
-		copy(packetData[len(Prefix)+HeaderSize+len(packet.Payload):], Suffix)
+		packetData := make([]byte, len(prefix)+HeaderSize+len(packet.Payload)+len(suffix))
+		copy(packetData, prefix)
+		binary.BigEndian.PutUint16(packetData[len(prefix):], packet.Index)
+		binary.BigEndian.PutUint16(packetData[len(prefix)+2:], packet.Total)
+		copy(packetData[len(prefix)+HeaderSize:], packet.Payload)
+		copy(packetData[len(prefix)+HeaderSize+len(packet.Payload):], suffix)
+		commands = append(commands, packetData)
+	}
+	return packets, commands, nil
+}
 
-		response, err := sq.SendCommand(packetData, 300*time.Millisecond, 1*time.Millisecond, 100*time.Millisecond)
-		if strings.Contains(response, "OK") {
-			fmt.Printf("⚡  数据包： %v  ⬇️ 子包：  %d/%d 发送成功, size：%d bytes\n", tag, packet.Index+1, packet.Total, len(packetData))
-		} else if strings.Contains(response, "ERROR") {
-			fmt.Printf("⛔️  数据包： %v  ⬇️ 子包：  %d/%d 发送失败\n", tag, packet.Index+1, packet.Total)
-			return err
-		} else {
-			fmt.Printf("❗❓  数据包： %v  ⬇️ 子包：  %d/%d 未知回显：%v, error：%v\n", tag, packet.Index+1, packet.Total, response, err)
-			return err
+// SendJSONOverBLE 以 Quectel 方言的 Notify 命令、按默认 MTU 将 JSON 数据分包发往连接 0，
+// 供直接持有串口队列的调用方使用，行为与引入连接管理之前一致。
+//
+// Deprecated: 不感知协商 MTU、多连接与模块状态，请使用 BLEController.SendJSON 或 BLEController.SendJSONTo(0, jsonData)。
+func SendJSONOverBLE(sq interfaces.SerialQueueInterface, jsonData interface{}) error {
+	_, commands, err := encodeJSONPackets(quectelDialect{}.NotifyFrame(0, DefaultCharacteristicUUID), DefaultMTU, jsonData)
+	if err != nil {
+		return err
+	}
+	for i, cmd := range commands {
+		response, err := sq.SendCommand(cmd, 300*time.Millisecond, 1*time.Millisecond, 100*time.Millisecond)
+		if err != nil {
+			return fmt.Errorf("packet %d/%d: %w", i+1, len(commands), err)
+		}
+		r := ParseResponse(response)
+		if rerr := r.Err(); rerr != nil {
+			return fmt.Errorf("packet %d/%d: %w", i+1, len(commands), rerr)
+		}
+		if !r.Succeeded() {
+			return fmt.Errorf("packet %d/%d: unexpected response: %q", i+1, len(commands), response)
 		}
 	}
-	fmt.Printf("✅️ All packets of Packet %v sent and verified.\n", tag)
 	return nil
 }
@@ -0,0 +1,19 @@
+package ble
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSendJSONOverBLESendsToConnZero(t *testing.T) {
+	m := NewFakeModule("HCM111Z V1.4.0")
+	c := newFakeController(t, m, quectelDialect{})
+
+	data := map[string]string{"payload": strings.Repeat("x", 300)}
+	if err := SendJSONOverBLE(c.Queue, data); err != nil {
+		t.Fatalf("SendJSONOverBLE: %v", err)
+	}
+	if n := countCommands(m, "AT+QBLEGATTSNTFY=0,"+DefaultCharacteristicUUID+","); n != 2 {
+		t.Errorf("sent %d notify packets, want 2", n)
+	}
+}
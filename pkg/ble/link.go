@@ -0,0 +1,126 @@
+package ble
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LinkQuality 一个连接最近一次查询到的链路质量
+type LinkQuality struct {
+	ConnID    int       `json:"connId"`
+	PeerAddr  string    `json:"peerAddr"`
+	RSSI      int       `json:"rssi"` // 信号强度（dBm）
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// QueryRSSI 查询指定连接的信号强度（dBm），并记录到连接信息中。
+func (c *BLEController) QueryRSSI(connID int) (int, error) {
+	cmd, err := c.build(OpQueryRSSI, connID)
+	if err != nil {
+		return 0, err
+	}
+	if err := c.checkState(OpQueryRSSI); err != nil {
+		return 0, err
+	}
+	lines, err := c.queryLines(cmd, c.dialect.InfoPrefix(OpQueryRSSI))
+	if err != nil {
+		return 0, err
+	}
+	if len(lines) == 0 {
+		return 0, fmt.Errorf("no %s result from module", OpQueryRSSI)
+	}
+	reported, rssi, err := ParseRSSIResponse(strings.Join(lines, "\n"))
+	if err != nil {
+		return 0, err
+	}
+	if reported != connID {
+		return 0, fmt.Errorf("rssi reported for connection %d, expected %d", reported, connID)
+	}
+
+	c.connMu.Lock()
+	if conn, ok := c.connections[connID]; ok {
+		conn.RSSI = rssi
+		conn.RSSIAt = time.Now()
+	}
+	c.connMu.Unlock()
+	return rssi, nil
+}
+
+// LinkQualities 返回各连接最近一次查询到的链路质量，尚未查询过的连接不包含在内。
+func (c *BLEController) LinkQualities() []LinkQuality {
+	var out []LinkQuality
+	for _, conn := range c.Connections() {
+		if conn.RSSIAt.IsZero() {
+			continue
+		}
+		out = append(out, LinkQuality{ConnID: conn.ConnID, PeerAddr: conn.PeerAddr, RSSI: conn.RSSI, UpdatedAt: conn.RSSIAt})
+	}
+	return out
+}
+
+// StartLinkMonitor 按 interval 周期查询每个连接的信号强度，每轮查询结束后将结果交给 handler。
+// 方言或固件不支持信号强度查询时监控自动停止。
+func (c *BLEController) StartLinkMonitor(interval time.Duration, handler func([]LinkQuality)) {
+	if interval <= 0 {
+		return
+	}
+	c.StopLinkMonitor()
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	c.linkMu.Lock()
+	c.linkStop, c.linkDone = stop, done
+	c.linkMu.Unlock()
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				samples, err := c.pollLinkQuality()
+				if isUnsupported(err) {
+					c.logger.Warnf("模块不支持信号强度查询，停止链路质量监控: %v", err)
+					return
+				}
+				if len(samples) > 0 && handler != nil {
+					handler(samples)
+				}
+			}
+		}
+	}()
+	c.logger.Infof("📡 链路质量监控已启动，查询间隔 %v", interval)
+}
+
+// StopLinkMonitor 停止链路质量监控
+func (c *BLEController) StopLinkMonitor() {
+	c.linkMu.Lock()
+	stop, done := c.linkStop, c.linkDone
+	c.linkStop, c.linkDone = nil, nil
+	c.linkMu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+// pollLinkQuality 查询一轮所有连接的信号强度，单个连接查询失败只记录日志
+func (c *BLEController) pollLinkQuality() ([]LinkQuality, error) {
+	var samples []LinkQuality
+	for _, conn := range c.Connections() {
+		rssi, err := c.QueryRSSI(conn.ConnID)
+		if isUnsupported(err) {
+			return nil, err
+		}
+		if err != nil {
+			c.logger.Debugf("查询连接 %d 的信号强度失败: %v", conn.ConnID, err)
+			continue
+		}
+		samples = append(samples, LinkQuality{ConnID: conn.ConnID, PeerAddr: conn.PeerAddr, RSSI: rssi, UpdatedAt: time.Now()})
+	}
+	return samples, nil
+}
@@ -0,0 +1,215 @@
+package ble
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultLowPowerAdvInterval 空闲时放慢后的默认广播间隔（ms）
+const DefaultLowPowerAdvInterval = 1000
+
+// idleCheckInterval 空闲检查的最长间隔
+const idleCheckInterval = 5 * time.Second
+
+// IdlePolicy 空闲功耗策略：长时间没有连接与数据往来时先放慢广播，再使模块休眠
+type IdlePolicy struct {
+	LowPowerAfter       time.Duration // 无活动多久后放慢广播，<= 0 时不放慢
+	LowPowerAdvInterval int           // 放慢后的广播间隔（ms），<= 0 时使用 DefaultLowPowerAdvInterval
+	SleepAfter          time.Duration // 无活动多久后使模块休眠，<= 0 时不休眠
+}
+
+// enabled 策略是否需要运行空闲检查
+func (p IdlePolicy) enabled() bool {
+	return p.LowPowerAfter > 0 || p.SleepAfter > 0
+}
+
+// PowerState 模块的功耗状态
+type PowerState struct {
+	Asleep       bool      `json:"asleep"`       // 模块处于休眠，下一条命令前自动唤醒
+	LowPower     bool      `json:"lowPower"`     // 已因空闲放慢广播
+	LastActivity time.Time `json:"lastActivity"` // 最近一次连接或数据往来的时间
+}
+
+// Sleep 使模块进入休眠。有中心设备连接时拒绝休眠，避免上行数据在模块唤醒前丢失。
+// 休眠后串口队列在下一条命令前自动发送唤醒前导。
+func (c *BLEController) Sleep() error {
+	if n := len(c.Connections()); n > 0 {
+		return fmt.Errorf("cannot sleep while %d central(s) connected", n)
+	}
+	if c.Queue.Asleep() {
+		return nil
+	}
+	cmd, err := c.build(OpSleep)
+	if err != nil {
+		return err
+	}
+	if err := c.SendSingle(cmd); err != nil {
+		return err
+	}
+	c.Queue.MarkAsleep()
+	c.logger.Infof("💤 模块已进入休眠")
+	return nil
+}
+
+// Wake 唤醒休眠的模块。串口队列会在命令前发送唤醒前导，这里以一次版本查询确认模块已可响应。
+func (c *BLEController) Wake() error {
+	if !c.Queue.Asleep() {
+		return nil
+	}
+	if _, err := c.QueryVersion(); err != nil {
+		return fmt.Errorf("wake module: %w", err)
+	}
+	c.logger.Infof("⏰ 模块已唤醒")
+	return nil
+}
+
+// PowerState 返回模块当前的功耗状态。
+func (c *BLEController) PowerState() PowerState {
+	c.powerMu.Lock()
+	defer c.powerMu.Unlock()
+	return PowerState{Asleep: c.Queue.Asleep(), LowPower: c.lowPower, LastActivity: c.lastActivity}
+}
+
+// LowPower 返回是否已因空闲放慢广播，期间配置校验不恢复广播间隔。
+func (c *BLEController) LowPower() bool {
+	c.powerMu.Lock()
+	defer c.powerMu.Unlock()
+	return c.lowPower
+}
+
+// SetActiveHandler 注册退出空闲放慢广播后的处理函数，用于按期望配置恢复广播间隔。
+func (c *BLEController) SetActiveHandler(handler func()) {
+	c.powerMu.Lock()
+	defer c.powerMu.Unlock()
+	c.activeHandler = handler
+}
+
+// MarkActivity 记录一次连接或数据往来，已放慢广播时恢复正常广播。
+// 可能在串口读取协程中调用，恢复操作在新的协程中执行。
+func (c *BLEController) MarkActivity() {
+	c.powerMu.Lock()
+	c.lastActivity = time.Now()
+	wasLow := c.lowPower
+	c.lowPower = false
+	handler := c.activeHandler
+	c.powerMu.Unlock()
+	if !wasLow {
+		return
+	}
+	c.logger.Infof("⚡ 检测到活动，退出低功耗广播")
+	if handler != nil {
+		go handler()
+	}
+}
+
+// StartIdleMonitor 按策略周期检查空闲时间，空闲时放慢广播或使模块休眠。
+func (c *BLEController) StartIdleMonitor(policy IdlePolicy) {
+	if !policy.enabled() {
+		return
+	}
+	if policy.LowPowerAdvInterval <= 0 {
+		policy.LowPowerAdvInterval = DefaultLowPowerAdvInterval
+	}
+	c.StopIdleMonitor()
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	c.powerMu.Lock()
+	c.idleStop, c.idleDone = stop, done
+	c.lastActivity = time.Now()
+	c.powerMu.Unlock()
+
+	interval := idleCheckInterval
+	for _, d := range []time.Duration{policy.LowPowerAfter, policy.SleepAfter} {
+		if d > 0 && d/4 < interval {
+			interval = d / 4
+		}
+	}
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				c.checkIdle(&policy)
+			}
+		}
+	}()
+	c.logger.Infof("🔋 空闲功耗策略已启动: 放慢广播=%v, 休眠=%v", policy.LowPowerAfter, policy.SleepAfter)
+}
+
+// StopIdleMonitor 停止空闲检查
+func (c *BLEController) StopIdleMonitor() {
+	c.powerMu.Lock()
+	stop, done := c.idleStop, c.idleDone
+	c.idleStop, c.idleDone = nil, nil
+	c.powerMu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+// checkIdle 执行一次空闲检查。有连接或正在升级固件时视为活动，空闲时间从最后一个连接断开开始计算。
+func (c *BLEController) checkIdle(policy *IdlePolicy) {
+	if c.hasConnections() || c.Upgrading() {
+		c.powerMu.Lock()
+		c.lastActivity = time.Now()
+		c.powerMu.Unlock()
+		return
+	}
+	state := c.PowerState()
+	idle := time.Since(state.LastActivity)
+
+	if policy.LowPowerAfter > 0 && idle >= policy.LowPowerAfter && !state.LowPower && c.State() == StateAdvertising {
+		if err := c.enterLowPower(policy.LowPowerAdvInterval); err != nil {
+			c.logger.Warnf("放慢空闲广播失败: %v", err)
+		}
+	}
+	// 扫描期间模块需要持续接收广播，不休眠
+	if policy.SleepAfter > 0 && idle >= policy.SleepAfter && !state.Asleep && !c.Scanning() {
+		err := c.Sleep()
+		switch {
+		case isUnsupported(err):
+			c.logger.Warnf("模块不支持休眠，空闲时不再尝试: %v", err)
+			policy.SleepAfter = 0
+		case err != nil:
+			c.logger.Warnf("空闲休眠失败: %v", err)
+		}
+	}
+}
+
+// enterLowPower 以较长的广播间隔重新开始广播，不修改期望配置
+func (c *BLEController) enterLowPower(interval int) error {
+	cmds, err := c.buildAll(
+		opOf(OpStopAdvertising),
+		opOf(OpSetAdvParam, interval, interval),
+		opOf(OpStartAdvertising),
+	)
+	if err != nil {
+		return err
+	}
+	// 先标记，避免配置校验在切换期间把广播间隔改回去
+	c.powerMu.Lock()
+	c.lowPower = true
+	c.powerMu.Unlock()
+	if err := c.SendMulti(cmds); err != nil {
+		c.powerMu.Lock()
+		c.lowPower = false
+		c.powerMu.Unlock()
+		return err
+	}
+	c.logger.Infof("🔋 长时间无活动，广播间隔放慢到 %dms", interval)
+	return nil
+}
+
+// clearPowerState 模块复位后广播参数与休眠状态均已恢复默认
+func (c *BLEController) clearPowerState() {
+	c.powerMu.Lock()
+	defer c.powerMu.Unlock()
+	c.lowPower = false
+	c.lastActivity = time.Now()
+}
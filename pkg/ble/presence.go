@@ -0,0 +1,263 @@
+package ble
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 信号平滑方式
+const (
+	SmoothingAverage = "average" // 滑动平均
+	SmoothingKalman  = "kalman"  // 一维卡尔曼滤波
+)
+
+// 在场事件
+const (
+	PresenceEnter = "enter" // 标签进入扫描范围
+	PresenceNear  = "near"  // 标签进入近距离区域
+	PresenceFar   = "far"   // 标签进入远距离区域
+	PresenceExit  = "exit"  // 标签离开扫描范围或超时未扫描到
+)
+
+// PresenceConfig 在场判定参数。平滑后的信号强度不低于 NearRSSI 判定为近距离，不低于 FarRSSI 判定为远距离；
+// 离开某一区域需要信号强度再低于阈值 Hysteresis，避免在阈值附近反复切换。
+type PresenceConfig struct {
+	Smoothing        string        // average / kalman
+	Window           int           // 滑动平均的样本数
+	ProcessNoise     float64       // 卡尔曼滤波过程噪声，越大越跟随新样本
+	MeasurementNoise float64       // 卡尔曼滤波测量噪声，越大越平滑
+	NearRSSI         int           // 近距离阈值（dBm）
+	FarRSSI          int           // 远距离阈值（dBm），低于该值视为不在场
+	Hysteresis       int           // 离开区域的回差（dB）
+	Timeout          time.Duration // 超过该时间未扫描到标签视为离开
+}
+
+// Validate 校验在场判定参数
+func (c PresenceConfig) Validate() error {
+	switch c.Smoothing {
+	case SmoothingAverage:
+		if c.Window < 1 {
+			return fmt.Errorf("moving average window must be positive, got %d", c.Window)
+		}
+	case SmoothingKalman:
+		if c.ProcessNoise <= 0 || c.MeasurementNoise <= 0 {
+			return fmt.Errorf("kalman noise must be positive, got process %v, measurement %v", c.ProcessNoise, c.MeasurementNoise)
+		}
+	default:
+		return fmt.Errorf("unknown smoothing %q, expected %s or %s", c.Smoothing, SmoothingAverage, SmoothingKalman)
+	}
+	if c.NearRSSI <= c.FarRSSI {
+		return fmt.Errorf("near threshold %d dBm must be above far threshold %d dBm", c.NearRSSI, c.FarRSSI)
+	}
+	if c.Hysteresis < 0 {
+		return fmt.Errorf("hysteresis must not be negative, got %d", c.Hysteresis)
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("presence timeout must be positive, got %v", c.Timeout)
+	}
+	return nil
+}
+
+// PresenceEvent 一个标签的在场状态变化
+type PresenceEvent struct {
+	MAC       string  `json:"mac"`
+	Event     string  `json:"event"`     // enter / near / far / exit
+	Zone      string  `json:"zone"`      // 变化后的区域 near / far，离开后为空
+	RSSI      float64 `json:"rssi"`      // 平滑后的信号强度（dBm）
+	Source    string  `json:"source"`    // 最近一次扫描到该标签的模块
+	Timestamp int64   `json:"timestamp"` // 变化时间（纳秒）
+}
+
+// TagPresence 一个标签当前的在场状态
+type TagPresence struct {
+	MAC       string    `json:"mac"`
+	Name      string    `json:"name,omitempty"`
+	Zone      string    `json:"zone"` // near / far，不在场时为空
+	RSSI      float64   `json:"rssi"` // 平滑后的信号强度（dBm）
+	Source    string    `json:"source"`
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+}
+
+// rssiFilter 信号强度平滑器
+type rssiFilter interface {
+	update(rssi float64) float64
+}
+
+// movingAverage 滑动平均
+type movingAverage struct {
+	samples []float64
+	size    int
+	sum     float64
+}
+
+func (f *movingAverage) update(rssi float64) float64 {
+	f.samples = append(f.samples, rssi)
+	f.sum += rssi
+	if len(f.samples) > f.size {
+		f.sum -= f.samples[0]
+		f.samples = f.samples[1:]
+	}
+	return f.sum / float64(len(f.samples))
+}
+
+// kalman 一维卡尔曼滤波，信号强度视为缓慢变化的常量
+type kalman struct {
+	q, r  float64 // 过程噪声与测量噪声
+	x, p  float64 // 估计值与估计误差
+	ready bool
+}
+
+func (f *kalman) update(rssi float64) float64 {
+	if !f.ready {
+		f.x, f.p, f.ready = rssi, f.r, true
+		return f.x
+	}
+	f.p += f.q
+	k := f.p / (f.p + f.r)
+	f.x += k * (rssi - f.x)
+	f.p *= 1 - k
+	return f.x
+}
+
+// tagState 一个标签的判定状态
+type tagState struct {
+	TagPresence
+	filter rssiFilter
+}
+
+// PresenceEngine 按标签平滑信号强度，以阈值与回差判定标签所在区域，产生进入、近距离、远距离与离开事件。
+// 多个模块扫描到同一标签时合并为同一标签的样本。
+type PresenceEngine struct {
+	mu   sync.Mutex
+	cfg  PresenceConfig
+	tags map[string]*tagState
+}
+
+// NewPresenceEngine 创建在场判定引擎
+func NewPresenceEngine(cfg PresenceConfig) (*PresenceEngine, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &PresenceEngine{cfg: cfg, tags: make(map[string]*tagState)}, nil
+}
+
+// newFilter 按配置创建平滑器
+func (e *PresenceEngine) newFilter() rssiFilter {
+	if e.cfg.Smoothing == SmoothingKalman {
+		return &kalman{q: e.cfg.ProcessNoise, r: e.cfg.MeasurementNoise}
+	}
+	return &movingAverage{size: e.cfg.Window}
+}
+
+// Observe 处理模块 source 上报的一批扫描结果，返回由此产生的在场事件
+func (e *PresenceEngine) Observe(source string, sightings []Sighting) []PresenceEvent {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var events []PresenceEvent
+	for _, s := range sightings {
+		at := time.Unix(0, s.Timestamp)
+		st, ok := e.tags[s.MAC]
+		if !ok {
+			st = &tagState{TagPresence: TagPresence{MAC: s.MAC, FirstSeen: at}, filter: e.newFilter()}
+			e.tags[s.MAC] = st
+		}
+		if s.Name != "" {
+			st.Name = s.Name
+		}
+		st.RSSI = st.filter.update(float64(s.RSSI))
+		st.Source, st.LastSeen = source, at
+		events = append(events, e.transition(st, e.classify(st.Zone, st.RSSI), at)...)
+	}
+	return events
+}
+
+// classify 按当前区域与平滑后的信号强度确定新区域，离开当前区域需要越过阈值加回差
+func (e *PresenceEngine) classify(zone string, rssi float64) string {
+	near, far, h := float64(e.cfg.NearRSSI), float64(e.cfg.FarRSSI), float64(e.cfg.Hysteresis)
+	switch zone {
+	case PresenceNear:
+		if rssi >= near-h {
+			return PresenceNear
+		}
+		if rssi >= far-h {
+			return PresenceFar
+		}
+	case PresenceFar:
+		if rssi >= near {
+			return PresenceNear
+		}
+		if rssi >= far-h {
+			return PresenceFar
+		}
+	default:
+		if rssi >= near {
+			return PresenceNear
+		}
+		if rssi >= far {
+			return PresenceFar
+		}
+	}
+	return ""
+}
+
+// transition 切换标签所在区域，返回对应事件
+func (e *PresenceEngine) transition(st *tagState, zone string, at time.Time) []PresenceEvent {
+	if zone == st.Zone {
+		return nil
+	}
+	event := func(name string) PresenceEvent {
+		return PresenceEvent{MAC: st.MAC, Event: name, Zone: zone, RSSI: st.RSSI, Source: st.Source, Timestamp: at.UnixNano()}
+	}
+	var events []PresenceEvent
+	switch {
+	case st.Zone == "":
+		events = append(events, event(PresenceEnter), event(zone))
+	case zone == "":
+		events = append(events, event(PresenceExit))
+	default:
+		events = append(events, event(zone))
+	}
+	st.Zone = zone
+	return events
+}
+
+// Expire 将超时未扫描到的标签判定为离开并移除其状态
+func (e *PresenceEngine) Expire(now time.Time) []PresenceEvent {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var events []PresenceEvent
+	for mac, st := range e.tags {
+		if now.Sub(st.LastSeen) < e.cfg.Timeout {
+			continue
+		}
+		events = append(events, e.transition(st, "", now)...)
+		delete(e.tags, mac)
+	}
+	return events
+}
+
+// Tag 返回一个标签当前的在场状态
+func (e *PresenceEngine) Tag(mac string) (TagPresence, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	st, ok := e.tags[mac]
+	if !ok {
+		return TagPresence{MAC: mac}, false
+	}
+	return st.TagPresence, true
+}
+
+// Tags 按 MAC 地址顺序返回全部标签的在场状态
+func (e *PresenceEngine) Tags() []TagPresence {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	list := make([]TagPresence, 0, len(e.tags))
+	for _, st := range e.tags {
+		list = append(list, st.TagPresence)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].MAC < list[j].MAC })
+	return list
+}
@@ -0,0 +1,72 @@
+package ble
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// infoLineGrace 部分查询命令先回 OK 再上报结果行，收到 OK 后继续等待结果行的最长时间
+const infoLineGrace = 200 * time.Millisecond
+
+// lineCollector 在查询命令执行期间收集指定前缀的结果行
+type lineCollector struct {
+	prefix string
+	mu     sync.Mutex
+	lines  []string
+	got    chan struct{} // 收到第一行时关闭
+}
+
+// collectLine 将结果行交给正在等待的查询，返回 true 表示已被消费
+func (c *BLEController) collectLine(line string) bool {
+	c.collectMu.Lock()
+	defer c.collectMu.Unlock()
+	for _, col := range c.collectors {
+		if !strings.HasPrefix(line, col.prefix) {
+			continue
+		}
+		col.mu.Lock()
+		if len(col.lines) == 0 {
+			close(col.got)
+		}
+		col.lines = append(col.lines, line)
+		col.mu.Unlock()
+		return true
+	}
+	return false
+}
+
+// queryLines 发送查询命令，并收集以 prefix 开头的结果行（可能有多行，如已绑定设备列表）。
+func (c *BLEController) queryLines(cmd string, prefix string) ([]string, error) {
+	col := &lineCollector{prefix: prefix, got: make(chan struct{})}
+	c.collectMu.Lock()
+	c.collectors = append(c.collectors, col)
+	c.collectMu.Unlock()
+	defer c.removeCollector(col)
+
+	response, err := c.Queue.SendCommand([]byte(cmd), 2*time.Second, 1*time.Millisecond, 100*time.Millisecond)
+	if err != nil {
+		c.logger.Errorf("❌发送%v, 出现错误 :%v, response:%v", cmd, err, response)
+		return nil, err
+	}
+	select {
+	case <-col.got:
+	case <-time.After(infoLineGrace):
+	}
+
+	col.mu.Lock()
+	defer col.mu.Unlock()
+	return append([]string(nil), col.lines...), nil
+}
+
+// removeCollector 查询结束后移除收集器
+func (c *BLEController) removeCollector(col *lineCollector) {
+	c.collectMu.Lock()
+	defer c.collectMu.Unlock()
+	for i, existing := range c.collectors {
+		if existing == col {
+			c.collectors = append(c.collectors[:i], c.collectors[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package ble
+
+import (
+	"fmt"
+	"strings"
+)
+
+// QueryDeviceName 回读模块当前的广播名称。
+func (c *BLEController) QueryDeviceName() (string, error) {
+	raw, err := c.queryValue(OpQueryName)
+	if err != nil {
+		return "", err
+	}
+	return ParseNameResponse(raw)
+}
+
+// QueryTxPower 回读模块当前的发射功率（dBm）。
+func (c *BLEController) QueryTxPower() (int8, error) {
+	raw, err := c.queryValue(OpQueryTxPower)
+	if err != nil {
+		return 0, err
+	}
+	return ParseTxPowerResponse(raw)
+}
+
+// QueryAdvParams 回读模块当前的广播间隔。
+func (c *BLEController) QueryAdvParams() (AdvParams, error) {
+	raw, err := c.queryValue(OpQueryAdvParam)
+	if err != nil {
+		return AdvParams{}, err
+	}
+	return ParseAdvParamsResponse(raw)
+}
+
+// QueryBaud 回读模块当前的串口波特率。
+func (c *BLEController) QueryBaud() (int64, error) {
+	raw, err := c.queryValue(OpQueryBaud)
+	if err != nil {
+		return 0, err
+	}
+	return ParseBaudResponse(raw)
+}
+
+// QueryRole 回读模块当前的 BLE 角色，见 RoleCentral 等常量，0 表示尚未初始化。
+func (c *BLEController) QueryRole() (int, error) {
+	raw, err := c.queryValue(OpQueryRole)
+	if err != nil {
+		return 0, err
+	}
+	return ParseRoleResponse(raw)
+}
+
+// Advertising 返回模块是否正在广播。
+func (c *BLEController) Advertising() bool {
+	return c.State() == StateAdvertising
+}
+
+// queryValue 执行一条查询并返回拼接后的结果行，方言或固件不支持该查询时返回对应错误
+func (c *BLEController) queryValue(op Operation) (string, error) {
+	cmd, err := c.build(op)
+	if err != nil {
+		return "", err
+	}
+	lines, err := c.queryLines(cmd, c.dialect.InfoPrefix(op))
+	if err != nil {
+		return "", err
+	}
+	if len(lines) == 0 {
+		return "", fmt.Errorf("no %s result from module", op)
+	}
+	return strings.Join(lines, "\n"), nil
+}
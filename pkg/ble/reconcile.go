@@ -0,0 +1,239 @@
+package ble
+
+import (
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+)
+
+// DefaultResetSettleDelay 模块复位后等待其完成启动再恢复配置的时间
+const DefaultResetSettleDelay = 2 * time.Second
+
+// ReconcileReport 一次校验的结果
+type ReconcileReport struct {
+	Rebuilt    bool     // 是否按期望配置完整重建（模块意外复位后）
+	Applied    []string // 与期望不一致并已重新下发的字段
+	Unverified []string // 固件不支持回读、无法比较的字段
+}
+
+// Reconciler 维护设备的期望配置，在启动、模块复位后以及周期性地与模块回读值比较，并重新下发不一致的部分。
+type Reconciler struct {
+	c        *BLEController
+	logger   logger.LoggingClient
+	path     string        // 期望配置持久化文件，为空时不持久化
+	interval time.Duration // 周期校验间隔，<= 0 时不做周期校验
+	settle   time.Duration // 模块复位后等待启动完成的时间
+
+	mu          sync.Mutex
+	desired     DesiredConfig
+	pendingFull bool // 待执行的校验需要完整重建
+
+	runMu   sync.Mutex // 保证同一时间只有一次校验在执行
+	trigger chan struct{}
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewReconciler 创建配置校验器，path 为期望配置持久化文件，interval 为周期校验间隔。
+func NewReconciler(c *BLEController, desired DesiredConfig, path string, interval time.Duration) *Reconciler {
+	return &Reconciler{
+		c:        c,
+		logger:   c.logger,
+		path:     path,
+		interval: interval,
+		settle:   DefaultResetSettleDelay,
+		desired:  desired.Clone(),
+		trigger:  make(chan struct{}, 1),
+	}
+}
+
+// Desired 返回当前的期望配置
+func (r *Reconciler) Desired() DesiredConfig {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.desired.Clone()
+}
+
+// Update 修改并持久化期望配置，通常在写入命令成功下发到模块后调用。
+func (r *Reconciler) Update(fn func(cfg *DesiredConfig)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	next := r.desired.Clone()
+	fn(&next)
+	if err := next.Validate(); err != nil {
+		return err
+	}
+	if r.path != "" {
+		if err := SaveDesiredConfig(r.path, next); err != nil {
+			return err
+		}
+	}
+	r.desired = next
+	return nil
+}
+
+// Start 注册模块复位处理，启动周期校验，并立即执行一次启动校验。
+func (r *Reconciler) Start() {
+	r.stop = make(chan struct{})
+	r.c.SetResetHandler(r.onReset)
+	// 退出空闲低功耗广播后按期望配置恢复广播间隔
+	r.c.SetActiveHandler(func() { r.Trigger(false) })
+	r.wg.Add(1)
+	go r.loop()
+	r.Trigger(false)
+}
+
+// Stop 停止校验协程
+func (r *Reconciler) Stop() {
+	if r.stop == nil {
+		return
+	}
+	r.c.SetResetHandler(nil)
+	r.c.SetActiveHandler(nil)
+	close(r.stop)
+	r.wg.Wait()
+	r.stop = nil
+}
+
+// Trigger 请求执行一次校验，full 为 true 时按期望配置完整重建。
+// 已有待执行的校验时合并为一次，任意一次请求需要重建则重建。
+func (r *Reconciler) Trigger(full bool) {
+	r.mu.Lock()
+	r.pendingFull = r.pendingFull || full
+	r.mu.Unlock()
+	select {
+	case r.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// onReset 模块复位后等待启动完成再校验；意外复位时 GATT 服务等无法回读的配置也已丢失，需要完整重建。
+// 本服务主动复位时初始化命令会恢复全部配置，只需比较回读值；固件升级后模块以默认配置启动，同样需要重建。
+func (r *Reconciler) onReset(expected bool) {
+	full := !expected || r.c.Upgrading()
+	time.AfterFunc(r.settle, func() { r.Trigger(full) })
+}
+
+func (r *Reconciler) loop() {
+	defer r.wg.Done()
+	var tick <-chan time.Time
+	if r.interval > 0 {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-tick:
+			// 周期校验会唤醒模块，休眠期间跳过；固件升级期间模块不响应配置查询
+			if r.c.Queue.Asleep() || r.c.Upgrading() {
+				continue
+			}
+			r.Trigger(false)
+		case <-r.trigger:
+			r.mu.Lock()
+			full := r.pendingFull
+			r.pendingFull = false
+			r.mu.Unlock()
+			if _, err := r.Reconcile(full); err != nil {
+				r.logger.Errorf("❌ 模块配置校验失败: %v", err)
+			}
+			// 重建配置后模块会重新开始广播，按广播时间表再检查一次；复位前在扫描时恢复扫描，并恢复可读特征值的内容
+			r.c.triggerSchedule()
+			r.c.resumeScan()
+			r.c.restoreCharValues()
+		}
+	}
+}
+
+// Reconcile 执行一次校验。full 为 true 时不比较，直接按期望配置重建 GATT 服务并恢复全部配置；
+// 否则比较回读值，发现模块已丢失配置时同样完整重建。
+func (r *Reconciler) Reconcile(full bool) (ReconcileReport, error) {
+	r.runMu.Lock()
+	defer r.runMu.Unlock()
+	desired := r.Desired()
+
+	if full {
+		return r.rebuild(desired)
+	}
+
+	actual, err := r.c.ReadModuleConfig()
+	if err != nil {
+		return ReconcileReport{}, err
+	}
+	// GATT 服务无法回读。协议栈未初始化或名称与期望不一致时，说明模块在没有复位上报的情况下丢失了配置
+	// （如掉电重启时复位上报未被读到），GATT 服务也已一并丢失，改为完整重建
+	switch {
+	case actual.Role != nil && *actual.Role == 0:
+		r.logger.Warnf("模块 BLE 协议栈未初始化，GATT 服务已丢失")
+		return r.rebuild(desired)
+	case actual.Name != "" && actual.Name != desired.Name:
+		r.logger.Warnf("模块名称 %q 与期望 %q 不一致，GATT 服务可能已丢失", actual.Name, desired.Name)
+		return r.rebuild(desired)
+	}
+
+	var report ReconcileReport
+	var ops []opArgs
+	if actual.Name == "" {
+		report.Unverified = append(report.Unverified, "name")
+	}
+
+	if desired.TxPower != nil {
+		switch {
+		case actual.TxPower == nil:
+			report.Unverified = append(report.Unverified, "txPower")
+		case *actual.TxPower != *desired.TxPower:
+			r.logger.Warnf("模块发射功率 %d 与期望 %d 不一致", *actual.TxPower, *desired.TxPower)
+			ops = append(ops, opOf(OpSetTxPower, *desired.TxPower))
+			report.Applied = append(report.Applied, "txPower")
+		}
+	}
+
+	// 空闲时广播间隔被有意放慢，不视为不一致
+	if desired.AdvParams != nil && !r.c.LowPower() {
+		switch {
+		case actual.AdvParams == nil:
+			report.Unverified = append(report.Unverified, "advParams")
+		case *actual.AdvParams != *desired.AdvParams:
+			r.logger.Warnf("模块广播间隔 %+v 与期望 %+v 不一致", *actual.AdvParams, *desired.AdvParams)
+			// 广播进行中不能修改广播参数；未在广播时（如不在广播时间窗口内）只修改参数
+			setParam := opOf(OpSetAdvParam, desired.AdvParams.MinInterval, desired.AdvParams.MaxInterval)
+			if r.c.State() == StateAdvertising {
+				ops = append(ops, opOf(OpStopAdvertising), setParam, opOf(OpStartAdvertising))
+			} else {
+				ops = append(ops, setParam)
+			}
+			report.Applied = append(report.Applied, "advParams")
+		}
+	}
+
+	if len(ops) > 0 {
+		cmds, err := r.c.buildAll(ops...)
+		if err != nil {
+			return report, err
+		}
+		if err := r.c.SendMulti(cmds); err != nil {
+			return report, err
+		}
+		r.logger.Infof("🔧 已恢复模块配置: %v", report.Applied)
+	} else {
+		r.logger.Debugf("模块配置与期望一致，无法回读: %v", report.Unverified)
+	}
+	return report, nil
+}
+
+// rebuild 按期望配置重建 GATT 服务并恢复全部配置
+func (r *Reconciler) rebuild(desired DesiredConfig) (ReconcileReport, error) {
+	cmds, err := r.c.DesiredInitCommands(desired, false)
+	if err != nil {
+		return ReconcileReport{}, err
+	}
+	r.logger.Infof("🔧 按期望配置重建模块配置: name=%s", desired.Name)
+	if err := r.c.CustomInitializeBle(cmds); err != nil {
+		return ReconcileReport{}, err
+	}
+	return ReconcileReport{Rebuilt: true}, nil
+}
@@ -0,0 +1,75 @@
+package ble
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitFor 等待条件成立，超时时测试失败
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before timeout")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// lastIndex 返回模块收到的最后一条以 prefix 开头的命令的位置，没有时返回 -1
+func lastIndex(history []string, prefix string) int {
+	for i := len(history) - 1; i >= 0; i-- {
+		if strings.HasPrefix(history[i], prefix) {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestInitSetupReappliedAfterModuleReset(t *testing.T) {
+	m := NewFakeModule("HCM111Z V1.4.0")
+	c := newFakeController(t, m, quectelExtendedDialect{})
+	c.SetInitSetup(func() error {
+		if err := c.ApplySecurity(SecurityConfig{Mode: "just-works", Bonding: true}); err != nil {
+			return err
+		}
+		list, _ := c.AccessControl()
+		return c.ApplyAccessList(list)
+	})
+	desired := DefaultDesiredConfig()
+	if err := c.InitializeWithConfig(desired); err != nil {
+		t.Fatalf("InitializeWithConfig: %v", err)
+	}
+	if n := countCommands(m, "AT+QBLESECMODE="); n != 1 {
+		t.Fatalf("sent %d security mode commands during init, want 1", n)
+	}
+	if err := c.ApplyAccessList(AccessList{Mode: AccessModeAllowlist, Addresses: []string{"AA:BB:CC:DD:EE:01"}}); err != nil {
+		t.Fatalf("ApplyAccessList: %v", err)
+	}
+	advStarts := countCommands(m, "AT+QBLEADVSTART")
+
+	r := NewReconciler(c, desired, "", 0)
+	r.settle = 10 * time.Millisecond
+	r.Start()
+	t.Cleanup(r.Stop)
+
+	// 模块意外复位，配置校验按期望配置重建，重建后须在开始广播之前重新下发安全配置与连接过滤名单
+	m.Emit(bootBanner)
+	waitFor(t, 30*time.Second, func() bool { return countCommands(m, "AT+QBLEADVSTART") > advStarts })
+
+	history := m.History()
+	adv := lastIndex(history, "AT+QBLEADVSTART")
+	for _, prefix := range []string{"AT+QBLESECMODE=", "AT+QBLEWLPOLICY=1"} {
+		if n := countCommands(m, prefix); n != 2 {
+			t.Errorf("sent %q %d times, want 2", prefix, n)
+		}
+		if i := lastIndex(history, prefix); i < 0 || i > adv {
+			t.Errorf("%q at %d, advertising started at %d: it must be applied first", prefix, i, adv)
+		}
+	}
+	if _, filtered := c.AccessControl(); !filtered {
+		t.Error("filter list not enforced by the module after the rebuild")
+	}
+}
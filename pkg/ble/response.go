@@ -0,0 +1,322 @@
+package ble
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ResultCode AT 命令应答的最终结果
+type ResultCode int
+
+const (
+	ResultNone  ResultCode = iota // 没有最终结果行，如 Quectel 查询命令直接以信息行结束
+	ResultOK                      // OK
+	ResultError                   // ERROR、+CME ERROR: <n> 等
+)
+
+// String 返回结果码名称
+func (r ResultCode) String() string {
+	switch r {
+	case ResultOK:
+		return "OK"
+	case ResultError:
+		return "ERROR"
+	}
+	return "NONE"
+}
+
+// NoErrorCode 错误应答未携带错误码
+const NoErrorCode = -1
+
+// InfoLine 形如 +KEY: v1,v2 的信息行
+type InfoLine struct {
+	Key    string   // 含 + 号、不含冒号，如 +QBLEADDR
+	Fields []string // 逗号分隔的字段，已去除空白与引号
+}
+
+// Response 解析后的 AT 命令应答
+type Response struct {
+	Raw     string
+	Code    ResultCode
+	ErrCode int        // 模块错误码，无错误码时为 NoErrorCode
+	Info    []InfoLine // 信息行
+	Other   []string   // 既非结果行也非信息行的内容
+}
+
+// ResponseError 模块返回的错误应答
+type ResponseError struct {
+	Code int    // 模块错误码，无错误码时为 NoErrorCode
+	Raw  string // 原始应答
+}
+
+func (e *ResponseError) Error() string {
+	if e.Code == NoErrorCode {
+		return fmt.Sprintf("module returned ERROR: %q", e.Raw)
+	}
+	return fmt.Sprintf("module returned error code %d: %q", e.Code, e.Raw)
+}
+
+// errorPrefixes 带错误码的错误行前缀
+var errorPrefixes = []string{"+CME ERROR:", "+CMS ERROR:", "ERROR:"}
+
+// ParseResponse 将一次命令的应答（可能包含多行）解析为结构化结果。
+// 只有整行为 OK / ERROR 或以错误前缀开头时才视为结果行，信息行中出现的 OK 字样不会被误判。
+func ParseResponse(raw string) Response {
+	r := Response{Raw: raw, ErrCode: NoErrorCode}
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if code, errCode, ok := parseResultLine(line); ok {
+			r.Code, r.ErrCode = code, errCode
+			continue
+		}
+		if info, ok := parseInfoLine(line); ok {
+			r.Info = append(r.Info, info)
+			continue
+		}
+		r.Other = append(r.Other, line)
+	}
+	return r
+}
+
+// parseResultLine 解析结果行，返回结果码与错误码
+func parseResultLine(line string) (ResultCode, int, bool) {
+	switch line {
+	case "OK":
+		return ResultOK, NoErrorCode, true
+	case "ERROR":
+		return ResultError, NoErrorCode, true
+	}
+	for _, prefix := range errorPrefixes {
+		if !strings.HasPrefix(line, prefix) {
+			continue
+		}
+		code, err := strconv.Atoi(strings.TrimSpace(line[len(prefix):]))
+		if err != nil {
+			code = NoErrorCode
+		}
+		return ResultError, code, true
+	}
+	return ResultNone, NoErrorCode, false
+}
+
+// parseInfoLine 解析 +KEY: v1,v2 形式的信息行
+func parseInfoLine(line string) (InfoLine, bool) {
+	if !strings.HasPrefix(line, "+") {
+		return InfoLine{}, false
+	}
+	i := strings.Index(line, ":")
+	if i < 0 {
+		return InfoLine{Key: line}, true
+	}
+	info := InfoLine{Key: strings.TrimSpace(line[:i])}
+	if value := strings.TrimSpace(line[i+1:]); value != "" {
+		for _, f := range strings.Split(value, ",") {
+			info.Fields = append(info.Fields, strings.Trim(strings.TrimSpace(f), `"`))
+		}
+	}
+	return info, true
+}
+
+// IsFinalLine 判断一行是否为结果行（OK、ERROR 或带错误码的错误行）
+func IsFinalLine(line string) bool {
+	_, _, ok := parseResultLine(strings.TrimSpace(line))
+	return ok
+}
+
+// Err 应答为错误时返回 *ResponseError，否则返回 nil
+func (r Response) Err() error {
+	if r.Code != ResultError {
+		return nil
+	}
+	return &ResponseError{Code: r.ErrCode, Raw: strings.TrimSpace(r.Raw)}
+}
+
+// Succeeded 应答为 OK，或没有结果行但带有信息行
+func (r Response) Succeeded() bool {
+	return r.Code == ResultOK || (r.Code == ResultNone && len(r.Info) > 0)
+}
+
+// Field 返回指定信息行的字段，key 可带或不带结尾冒号
+func (r Response) Field(key string) ([]string, bool) {
+	key = strings.TrimSuffix(key, ":")
+	for _, info := range r.Info {
+		if info.Key == key {
+			return info.Fields, true
+		}
+	}
+	return nil, false
+}
+
+// --- 常用查询应答解析 ---
+
+// 查询应答的信息行键名，标注「未经实机确认」的格式来自方言设计，模块实际输出可能不同
+const (
+	infoKeyVersion  = "+QVERSION"
+	infoKeyName     = "+QBLENAME" // 未经实机确认
+	infoKeyAddr     = "+QBLEADDR"
+	infoKeyAdvParam = "+QBLEADVPARAM" // 未经实机确认
+	infoKeyTxPower  = "+QTXPOWER"     // 未经实机确认
+	infoKeyRSSI     = "+QBLERSSI"     // 未经实机确认
+	infoKeyFirmware = "+QFOTA"        // 未经实机确认
+	infoKeyBaud     = "+QSETBAUD"     // 未经实机确认
+	infoKeyRole     = "+QBLEINIT"     // 未经实机确认
+)
+
+// requireFields 取出信息行字段，应答为错误或字段数不足时返回错误
+func (r Response) requireFields(key string, n int) ([]string, error) {
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+	fields, ok := r.Field(key)
+	if !ok {
+		return nil, fmt.Errorf("missing %s in response %q", key, strings.TrimSpace(r.Raw))
+	}
+	if len(fields) < n {
+		return nil, fmt.Errorf("%s expects %d fields, got %d: %q", key, n, len(fields), strings.TrimSpace(r.Raw))
+	}
+	return fields, nil
+}
+
+// ParseVersionResponse 解析 +QVERSION: <version> 应答
+func ParseVersionResponse(raw string) (FirmwareVersion, error) {
+	fields, err := ParseResponse(raw).requireFields(infoKeyVersion, 1)
+	if err != nil {
+		return FirmwareVersion{}, err
+	}
+	return ParseFirmwareVersion(strings.Join(fields, ","), "")
+}
+
+// ParseNameResponse 解析 +QBLENAME: <name> 应答
+func ParseNameResponse(raw string) (string, error) {
+	fields, err := ParseResponse(raw).requireFields(infoKeyName, 1)
+	if err != nil {
+		return "", err
+	}
+	// 名称中可能包含逗号，按原样拼回
+	return strings.Join(fields, ","), nil
+}
+
+// ParseAddressResponse 解析 +QBLEADDR: <addr> 应答，返回大写的 MAC 地址
+func ParseAddressResponse(raw string) (string, error) {
+	fields, err := ParseResponse(raw).requireFields(infoKeyAddr, 1)
+	if err != nil {
+		return "", err
+	}
+	// 部分固件在地址后附带地址类型：+QBLEADDR: <addr>,<type>
+	addr := fields[0]
+	if !isMACAddress(addr) {
+		return "", fmt.Errorf("invalid BLE address %q", addr)
+	}
+	return strings.ToUpper(addr), nil
+}
+
+// AdvParams 广播间隔参数（ms）
+type AdvParams struct {
+	MinInterval int `json:"minInterval"`
+	MaxInterval int `json:"maxInterval"`
+}
+
+// ParseAdvParamsResponse 解析 +QBLEADVPARAM: <min>,<max> 应答
+func ParseAdvParamsResponse(raw string) (AdvParams, error) {
+	fields, err := ParseResponse(raw).requireFields(infoKeyAdvParam, 2)
+	if err != nil {
+		return AdvParams{}, err
+	}
+	min, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return AdvParams{}, fmt.Errorf("invalid advertising min interval %q", fields[0])
+	}
+	max, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return AdvParams{}, fmt.Errorf("invalid advertising max interval %q", fields[1])
+	}
+	return AdvParams{MinInterval: min, MaxInterval: max}, nil
+}
+
+// ParseTxPowerResponse 解析 +QTXPOWER: <dBm> 应答
+func ParseTxPowerResponse(raw string) (int8, error) {
+	fields, err := ParseResponse(raw).requireFields(infoKeyTxPower, 1)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseInt(fields[0], 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid tx power %q", fields[0])
+	}
+	return int8(v), nil
+}
+
+// ParseBaudResponse 解析 +QSETBAUD: <baud> 应答
+func ParseBaudResponse(raw string) (int64, error) {
+	fields, err := ParseResponse(raw).requireFields(infoKeyBaud, 1)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid baud rate %q", fields[0])
+	}
+	return v, nil
+}
+
+// ParseRoleResponse 解析 +QBLEINIT: <role> 应答，0 表示 BLE 协议栈尚未初始化
+func ParseRoleResponse(raw string) (int, error) {
+	fields, err := ParseResponse(raw).requireFields(infoKeyRole, 1)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid BLE role %q", fields[0])
+	}
+	return v, nil
+}
+
+// ParseRSSIResponse 解析 +QBLERSSI: <conn>,<rssi> 应答，返回连接索引与信号强度（dBm）
+func ParseRSSIResponse(raw string) (int, int, error) {
+	fields, err := ParseResponse(raw).requireFields(infoKeyRSSI, 2)
+	if err != nil {
+		return 0, 0, err
+	}
+	connID, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid connection index %q", fields[0])
+	}
+	rssi, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid rssi %q", fields[1])
+	}
+	return connID, rssi, nil
+}
+
+// FirmwareTransfer 模块上报的固件接收进度
+type FirmwareTransfer struct {
+	Size     int    // 正在接收的固件长度，0 表示没有进行中的升级
+	CRC      uint32 // 正在接收的固件校验值
+	Received int    // 已接收的字节数
+}
+
+// ParseFirmwareStatusResponse 解析 +QFOTA: <size>,<crc32>,<received> 应答，crc32 为 8 位十六进制
+func ParseFirmwareStatusResponse(raw string) (FirmwareTransfer, error) {
+	fields, err := ParseResponse(raw).requireFields(infoKeyFirmware, 3)
+	if err != nil {
+		return FirmwareTransfer{}, err
+	}
+	size, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return FirmwareTransfer{}, fmt.Errorf("invalid firmware size %q", fields[0])
+	}
+	crc, err := strconv.ParseUint(fields[1], 16, 32)
+	if err != nil {
+		return FirmwareTransfer{}, fmt.Errorf("invalid firmware crc %q", fields[1])
+	}
+	received, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return FirmwareTransfer{}, fmt.Errorf("invalid received length %q", fields[2])
+	}
+	return FirmwareTransfer{Size: size, CRC: uint32(crc), Received: received}, nil
+}
@@ -0,0 +1,275 @@
+package ble
+
+import (
+	"reflect"
+	"testing"
+)
+
+// 以下应答语料为手写的合成数据，按本驱动对 HCM111Z 输出格式的假设编写，不是实机串口记录；
+// 除 OK/ERROR、+QVERSION、+QBLEADDR 外的格式均未经实机确认。每行以 \r\n 结尾，与串口原样读取的内容一致。
+// 取得实机串口记录后应以其替换对应条目，并注明来源。
+
+func TestParseResponse(t *testing.T) {
+	tests := []struct {
+		name      string
+		raw       string
+		want      Response
+		succeeded bool
+	}{
+		{
+			name:      "ok",
+			raw:       "OK\r\n",
+			want:      Response{Code: ResultOK, ErrCode: NoErrorCode},
+			succeeded: true,
+		},
+		{
+			name: "error",
+			raw:  "ERROR\r\n",
+			want: Response{Code: ResultError, ErrCode: NoErrorCode},
+		},
+		{
+			name: "cme error with code",
+			raw:  "+CME ERROR: 3\r\n",
+			want: Response{Code: ResultError, ErrCode: 3},
+		},
+		{
+			name: "cms error with code",
+			raw:  "+CMS ERROR: 500\r\n",
+			want: Response{Code: ResultError, ErrCode: 500},
+		},
+		{
+			name: "error with code",
+			raw:  "ERROR: 58\r\n",
+			want: Response{Code: ResultError, ErrCode: 58},
+		},
+		{
+			name: "cme error without numeric code",
+			raw:  "+CME ERROR: operation not allowed\r\n",
+			want: Response{Code: ResultError, ErrCode: NoErrorCode},
+		},
+		{
+			name: "version info line without final result",
+			raw:  "+QVERSION: HCM111Z V1.4.0\r\n",
+			want: Response{
+				Code:    ResultNone,
+				ErrCode: NoErrorCode,
+				Info:    []InfoLine{{Key: "+QVERSION", Fields: []string{"HCM111Z V1.4.0"}}},
+			},
+			succeeded: true,
+		},
+		{
+			name: "ok before version info line",
+			raw:  "OK\r\n+QVERSION: HCM111Z V1.4.0\r\n",
+			want: Response{
+				Code:    ResultOK,
+				ErrCode: NoErrorCode,
+				Info:    []InfoLine{{Key: "+QVERSION", Fields: []string{"HCM111Z V1.4.0"}}},
+			},
+			succeeded: true,
+		},
+		{
+			name: "address keeps colons inside the value",
+			raw:  "+QBLEADDR: aa:bb:cc:dd:ee:ff\r\n",
+			want: Response{
+				Code:    ResultNone,
+				ErrCode: NoErrorCode,
+				Info:    []InfoLine{{Key: "+QBLEADDR", Fields: []string{"aa:bb:cc:dd:ee:ff"}}},
+			},
+			succeeded: true,
+		},
+		{
+			name: "quoted name then ok",
+			raw:  "+QBLENAME: \"QuecHCM111Z\"\r\nOK\r\n",
+			want: Response{
+				Code:    ResultOK,
+				ErrCode: NoErrorCode,
+				Info:    []InfoLine{{Key: "+QBLENAME", Fields: []string{"QuecHCM111Z"}}},
+			},
+			succeeded: true,
+		},
+		{
+			name: "ok inside an info line is not a result",
+			raw:  "+QBLENAME: OK-Gateway\r\n",
+			want: Response{
+				Code:    ResultNone,
+				ErrCode: NoErrorCode,
+				Info:    []InfoLine{{Key: "+QBLENAME", Fields: []string{"OK-Gateway"}}},
+			},
+			succeeded: true,
+		},
+		{
+			name: "multi field info line",
+			raw:  "+QBLEADVPARAM: 150, 150\r\nOK\r\n",
+			want: Response{
+				Code:    ResultOK,
+				ErrCode: NoErrorCode,
+				Info:    []InfoLine{{Key: "+QBLEADVPARAM", Fields: []string{"150", "150"}}},
+			},
+			succeeded: true,
+		},
+		{
+			name: "several info lines",
+			raw:  "+QBLEBONDLIST: 0,11:22:33:44:55:66\r\n+QBLEBONDLIST: 1,AA:BB:CC:DD:EE:01\r\nOK\r\n",
+			want: Response{
+				Code:    ResultOK,
+				ErrCode: NoErrorCode,
+				Info: []InfoLine{
+					{Key: "+QBLEBONDLIST", Fields: []string{"0", "11:22:33:44:55:66"}},
+					{Key: "+QBLEBONDLIST", Fields: []string{"1", "AA:BB:CC:DD:EE:01"}},
+				},
+			},
+			succeeded: true,
+		},
+		{
+			name: "urc interleaved with a result",
+			raw:  "+QBLECONN: 0,11:22:33:44:55:66\r\nOK\r\n",
+			want: Response{
+				Code:    ResultOK,
+				ErrCode: NoErrorCode,
+				Info:    []InfoLine{{Key: "+QBLECONN", Fields: []string{"0", "11:22:33:44:55:66"}}},
+			},
+			succeeded: true,
+		},
+		{
+			name: "boot banner is kept as other output",
+			raw:  "freqchip\r\nOK\r\n",
+			want: Response{
+				Code:    ResultOK,
+				ErrCode: NoErrorCode,
+				Other:   []string{"freqchip"},
+			},
+			succeeded: true,
+		},
+		{
+			name: "info key without value",
+			raw:  "+QBLEGATTSSRVDONE\r\nOK\r\n",
+			want: Response{
+				Code:    ResultOK,
+				ErrCode: NoErrorCode,
+				Info:    []InfoLine{{Key: "+QBLEGATTSSRVDONE"}},
+			},
+			succeeded: true,
+		},
+		{
+			name: "empty",
+			raw:  "\r\n",
+			want: Response{Code: ResultNone, ErrCode: NoErrorCode},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseResponse(tt.raw)
+			tt.want.Raw = tt.raw
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ParseResponse(%q) = %+v, want %+v", tt.raw, got, tt.want)
+			}
+			if got.Succeeded() != tt.succeeded {
+				t.Errorf("Succeeded() = %v, want %v", got.Succeeded(), tt.succeeded)
+			}
+			if isErr := got.Err() != nil; isErr != (tt.want.Code == ResultError) {
+				t.Errorf("Err() = %v, want error: %v", got.Err(), tt.want.Code == ResultError)
+			}
+		})
+	}
+}
+
+func TestParseQueryResponses(t *testing.T) {
+	if v, err := ParseVersionResponse("+QVERSION: HCM111Z V1.4.0\r\n"); err != nil || v.Major != 1 || v.Minor != 4 || v.Patch != 0 || v.Model != "HCM111Z" {
+		t.Errorf("ParseVersionResponse = %+v, %v", v, err)
+	}
+	if addr, err := ParseAddressResponse("+QBLEADDR: aa:bb:cc:dd:ee:ff,0\r\n"); err != nil || addr != "AA:BB:CC:DD:EE:FF" {
+		t.Errorf("ParseAddressResponse = %q, %v", addr, err)
+	}
+	if _, err := ParseAddressResponse("+QBLEADDR: not-an-address\r\n"); err == nil {
+		t.Error("ParseAddressResponse accepted an invalid address")
+	}
+	if name, err := ParseNameResponse("+QBLENAME: Gate,Way\r\nOK\r\n"); err != nil || name != "Gate,Way" {
+		t.Errorf("ParseNameResponse = %q, %v", name, err)
+	}
+	if p, err := ParseAdvParamsResponse("+QBLEADVPARAM: 150,300\r\nOK\r\n"); err != nil || p != (AdvParams{MinInterval: 150, MaxInterval: 300}) {
+		t.Errorf("ParseAdvParamsResponse = %+v, %v", p, err)
+	}
+	if p, err := ParseTxPowerResponse("+QTXPOWER: -4\r\nOK\r\n"); err != nil || p != -4 {
+		t.Errorf("ParseTxPowerResponse = %d, %v", p, err)
+	}
+	if conn, rssi, err := ParseRSSIResponse("+QBLERSSI: 1,-67\r\nOK\r\n"); err != nil || conn != 1 || rssi != -67 {
+		t.Errorf("ParseRSSIResponse = %d, %d, %v", conn, rssi, err)
+	}
+
+	_, err := ParseTxPowerResponse("+CME ERROR: 4\r\n")
+	respErr, ok := err.(*ResponseError)
+	if !ok || respErr.Code != 4 {
+		t.Errorf("ParseTxPowerResponse on error = %v, want *ResponseError with code 4", err)
+	}
+	if _, err := ParseTxPowerResponse("OK\r\n"); err == nil {
+		t.Error("ParseTxPowerResponse accepted a response without +QTXPOWER")
+	}
+}
+
+func TestQuectelParseURC(t *testing.T) {
+	tests := []struct {
+		line string
+		want URC
+		ok   bool
+	}{
+		{
+			line: "+QBLECONN: 0,11:22:33:44:55:aa",
+			want: URC{Type: URCConnected, ConnID: 0, Addr: "11:22:33:44:55:AA"},
+			ok:   true,
+		},
+		{
+			line: "+QBLEDISCONN: 1,0x13",
+			want: URC{Type: URCDisconnected, ConnID: 1, Reason: "对端主动断开"},
+			ok:   true,
+		},
+		{
+			line: "+QBLEMTU: 0,247",
+			want: URC{Type: URCMTUChanged, ConnID: 0, MTU: 247},
+			ok:   true,
+		},
+		{
+			line: "+QBLEPASSKEY: 0,123456",
+			want: URC{Type: URCPasskeyDisplay, ConnID: 0, Passkey: "123456"},
+			ok:   true,
+		},
+		{
+			line: "+QBLEPASSKEYREQ: 2",
+			want: URC{Type: URCPasskeyRequest, ConnID: 2},
+			ok:   true,
+		},
+		{
+			line: "+QBLEENC: 0,1",
+			want: URC{Type: URCEncryptionChanged, ConnID: 0, Encrypted: true},
+			ok:   true,
+		},
+		{
+			line: "+QBLEINDCFM: 0,0",
+			want: URC{Type: URCIndicationConfirmed, ConnID: 0, Status: 0},
+			ok:   true,
+		},
+		{
+			line: "freqchip",
+			want: URC{Type: URCModuleReset},
+			ok:   true,
+		},
+		{line: "+QBLECONN: x,11:22:33:44:55:66"},
+		{line: "+QBLEMTU: 0"},
+		{line: "OK"},
+		{line: "+QVERSION: HCM111Z V1.4.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, ok := quectelDialect{}.ParseURC(tt.line)
+			if ok != tt.ok {
+				t.Fatalf("ParseURC(%q) ok = %v, want %v", tt.line, ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			tt.want.Raw = tt.line
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseURC(%q) = %+v, want %+v", tt.line, got, tt.want)
+			}
+		})
+	}
+}
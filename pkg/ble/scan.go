@@ -0,0 +1,389 @@
+package ble
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 扫描默认参数
+const (
+	DefaultScanInterval      = 100 // 扫描间隔（ms）
+	DefaultScanWindow        = 50  // 扫描窗口（ms）
+	DefaultScanBatchSize     = 50
+	DefaultScanBatchInterval = time.Second
+)
+
+// ScanFilter 扫描结果过滤条件。名称前缀、服务 UUID 与厂商标识满足任意一项即上报，均为空时不按内容过滤；
+// MinRSSI 为信号强度下限，与内容条件同时生效，0 表示不限。
+type ScanFilter struct {
+	NamePrefixes    []string `json:"namePrefixes,omitempty"`
+	ServiceUUIDs    []string `json:"serviceUUIDs,omitempty"`
+	ManufacturerIDs []uint16 `json:"manufacturerIds,omitempty"`
+	MinRSSI         int      `json:"minRssi,omitempty"`
+}
+
+// compiledScanFilter 规范化后的过滤条件
+type compiledScanFilter struct {
+	prefixes []string
+	uuids    map[string]bool
+	mfrs     map[uint16]bool
+	minRSSI  int
+}
+
+// compile 校验并规范化过滤条件
+func (f ScanFilter) compile() (*compiledScanFilter, error) {
+	cf := &compiledScanFilter{uuids: make(map[string]bool), mfrs: make(map[uint16]bool), minRSSI: f.MinRSSI}
+	for _, p := range f.NamePrefixes {
+		if p != "" {
+			cf.prefixes = append(cf.prefixes, p)
+		}
+	}
+	for _, u := range f.ServiceUUIDs {
+		b, err := ParseUUID(u)
+		if err != nil {
+			return nil, err
+		}
+		cf.uuids[FormatUUID(b)] = true
+	}
+	for _, id := range f.ManufacturerIDs {
+		cf.mfrs[id] = true
+	}
+	return cf, nil
+}
+
+// Validate 校验过滤条件
+func (f ScanFilter) Validate() error {
+	_, err := f.compile()
+	return err
+}
+
+// match 判断一次扫描结果是否满足过滤条件
+func (cf *compiledScanFilter) match(s *Sighting) bool {
+	if cf.minRSSI != 0 && s.RSSI < cf.minRSSI {
+		return false
+	}
+	if len(cf.prefixes) == 0 && len(cf.uuids) == 0 && len(cf.mfrs) == 0 {
+		return true
+	}
+	for _, p := range cf.prefixes {
+		if strings.HasPrefix(s.Name, p) {
+			return true
+		}
+	}
+	for _, u := range s.ServiceUUIDs {
+		if cf.uuids[u] {
+			return true
+		}
+	}
+	return s.ManufacturerID != nil && cf.mfrs[*s.ManufacturerID]
+}
+
+// ScanOptions 扫描参数
+type ScanOptions struct {
+	Active        bool          // 主动扫描，可获取扫描响应包中的名称等信息
+	Interval      int           // 扫描间隔（ms），<= 0 时使用 DefaultScanInterval
+	Window        int           // 扫描窗口（ms），<= 0 时使用 DefaultScanWindow
+	Filter        ScanFilter    // 过滤条件
+	TagInterval   time.Duration // 同一标签两次上报的最小间隔，<= 0 时不限制
+	BatchSize     int           // 攒够多少条立即上报，<= 0 时使用 DefaultScanBatchSize
+	BatchInterval time.Duration // 最长攒批时间，<= 0 时使用 DefaultScanBatchInterval
+}
+
+// withDefaults 补全未配置的参数
+func (o ScanOptions) withDefaults() ScanOptions {
+	if o.Interval <= 0 {
+		o.Interval = DefaultScanInterval
+	}
+	if o.Window <= 0 {
+		o.Window = DefaultScanWindow
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = DefaultScanBatchSize
+	}
+	if o.BatchInterval <= 0 {
+		o.BatchInterval = DefaultScanBatchInterval
+	}
+	return o
+}
+
+// Sighting 一次扫描到的标签
+type Sighting struct {
+	MAC            string   `json:"mac"`
+	RSSI           int      `json:"rssi"`
+	Name           string   `json:"name,omitempty"`
+	ServiceUUIDs   []string `json:"serviceUUIDs,omitempty"`
+	ManufacturerID *uint16  `json:"manufacturerId,omitempty"`
+	AdvData        string   `json:"advData"`   // 原始广播数据（十六进制）
+	Timestamp      int64    `json:"timestamp"` // 扫描到的时间（纳秒）
+}
+
+// ScanStats 扫描运行状态与计数
+type ScanStats struct {
+	Scanning    bool       `json:"scanning"`
+	Reports     uint64     `json:"reports"`     // 模块上报的扫描结果数
+	Filtered    uint64     `json:"filtered"`    // 未通过过滤的结果数
+	RateLimited uint64     `json:"rateLimited"` // 因同一标签上报过于频繁而丢弃的结果数
+	Published   uint64     `json:"published"`   // 已交给上报处理函数的结果数
+	Tags        int        `json:"tags"`        // 限流窗口内上报过的标签数
+	Filter      ScanFilter `json:"filter"`
+}
+
+// parseSighting 从扫描上报中解析标签信息，广播数据格式错误时保留已解析的部分
+func parseSighting(urc URC, now time.Time) Sighting {
+	s := Sighting{MAC: urc.Addr, RSSI: urc.RSSI, AdvData: strings.ToUpper(urc.Data), Timestamp: now.UnixNano()}
+	data, err := hex.DecodeString(urc.Data)
+	if err != nil {
+		return s
+	}
+	structures, _ := ParseADStructures(data)
+	for _, ad := range structures {
+		switch ad.Type {
+		case ADTypeCompleteName:
+			s.Name = string(ad.Data)
+		case ADTypeShortName:
+			if s.Name == "" {
+				s.Name = string(ad.Data)
+			}
+		case ADTypeIncompleteUUID16, ADTypeCompleteUUID16:
+			for i := 0; i+2 <= len(ad.Data); i += 2 {
+				s.ServiceUUIDs = append(s.ServiceUUIDs, FormatUUID(ad.Data[i:i+2]))
+			}
+		case ADTypeIncompleteUUID128, ADTypeCompleteUUID128:
+			for i := 0; i+16 <= len(ad.Data); i += 16 {
+				s.ServiceUUIDs = append(s.ServiceUUIDs, FormatUUID(ad.Data[i:i+16]))
+			}
+		case ADTypeManufacturerSpecific:
+			if len(ad.Data) >= 2 && s.ManufacturerID == nil {
+				id := binary.LittleEndian.Uint16(ad.Data)
+				s.ManufacturerID = &id
+			}
+		}
+	}
+	return s
+}
+
+// SetMultiRole 设置初始化时是否以多角色初始化模块，使扫描与外围设备功能同时运行，需在初始化前调用。
+func (c *BLEController) SetMultiRole(enabled bool) {
+	c.scanMu.Lock()
+	defer c.scanMu.Unlock()
+	c.multiRole = enabled
+}
+
+// initRole 返回初始化模块使用的角色，固件不支持多角色时退回外围设备
+func (c *BLEController) initRole() int {
+	c.scanMu.Lock()
+	multi := c.multiRole
+	c.scanMu.Unlock()
+	if !multi {
+		return RolePeripheral
+	}
+	if caps, ok := c.Capabilities(); ok && !caps.SupportsRole(RoleMultiRole) {
+		c.logger.Warnf("固件不支持多角色，以外围设备初始化，扫描不可用")
+		return RolePeripheral
+	}
+	return RoleMultiRole
+}
+
+// StartScan 开始持续扫描，扫描结果经过滤与按标签限流后分批交给 handler。
+// handler 在独立的协程中调用。已在扫描时先停止，再按新参数重新开始。
+func (c *BLEController) StartScan(opts ScanOptions, handler func([]Sighting)) error {
+	opts = opts.withDefaults()
+	filter, err := opts.Filter.compile()
+	if err != nil {
+		return err
+	}
+	if err := c.StopScan(); err != nil {
+		c.logger.Warnf("重新开始扫描前停止扫描失败: %v", err)
+	}
+	if err := c.sendScanStart(opts); err != nil {
+		return err
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	flush := make(chan struct{}, 1)
+	c.scanMu.Lock()
+	c.scanning, c.scanLost = true, false
+	c.scanOpts, c.scanFilter, c.scanHandler = opts, filter, handler
+	c.scanBatch, c.scanSeen = nil, make(map[string]time.Time)
+	c.scanStats = ScanStats{Filter: opts.Filter}
+	c.scanStop, c.scanDone, c.scanFlush = stop, done, flush
+	c.scanMu.Unlock()
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(opts.BatchInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				c.flushScan()
+				return
+			case <-ticker.C:
+			case <-flush:
+			}
+			c.flushScan()
+		}
+	}()
+	c.logger.Infof("📡 开始扫描: active=%v, interval=%dms, window=%dms, batch=%d/%v, tagInterval=%v",
+		opts.Active, opts.Interval, opts.Window, opts.BatchSize, opts.BatchInterval, opts.TagInterval)
+	return nil
+}
+
+// sendScanStart 下发扫描参数并开始扫描
+func (c *BLEController) sendScanStart(opts ScanOptions) error {
+	cmds, err := c.buildAll(
+		opOf(OpSetScanParam, opts.Active, opts.Interval, opts.Window),
+		opOf(OpStartScan),
+	)
+	if err != nil {
+		return err
+	}
+	return c.SendMulti(cmds)
+}
+
+// StopScan 停止扫描，尚未上报的结果立即上报。
+func (c *BLEController) StopScan() error {
+	if !c.stopScanLoop() {
+		return nil
+	}
+	cmd, err := c.build(OpStopScan)
+	if err == nil {
+		err = c.SendSingle(cmd)
+	}
+	if err != nil {
+		return fmt.Errorf("stop scan: %w", err)
+	}
+	c.logger.Infof("📡 已停止扫描")
+	return nil
+}
+
+// stopScanLoop 停止上报协程并上报剩余结果，不向模块发送命令，返回此前是否在扫描
+func (c *BLEController) stopScanLoop() bool {
+	c.scanMu.Lock()
+	stop, done := c.scanStop, c.scanDone
+	running := c.scanning
+	c.scanning = false
+	c.scanStop, c.scanDone, c.scanFlush = nil, nil, nil
+	c.scanMu.Unlock()
+	if !running {
+		return false
+	}
+	close(stop)
+	<-done
+	return true
+}
+
+// SetScanFilter 修改正在进行的扫描的过滤条件，不需要重新开始扫描。
+func (c *BLEController) SetScanFilter(f ScanFilter) error {
+	filter, err := f.compile()
+	if err != nil {
+		return err
+	}
+	c.scanMu.Lock()
+	defer c.scanMu.Unlock()
+	if !c.scanning {
+		return fmt.Errorf("scan is not running")
+	}
+	c.scanOpts.Filter, c.scanFilter = f, filter
+	c.scanStats.Filter = f
+	c.logger.Infof("📡 扫描过滤条件已更新: %+v", f)
+	return nil
+}
+
+// Scanning 返回是否正在扫描
+func (c *BLEController) Scanning() bool {
+	c.scanMu.Lock()
+	defer c.scanMu.Unlock()
+	return c.scanning
+}
+
+// ScanStats 返回扫描运行状态与计数
+func (c *BLEController) ScanStats() ScanStats {
+	c.scanMu.Lock()
+	defer c.scanMu.Unlock()
+	stats := c.scanStats
+	stats.Scanning = c.scanning
+	stats.Tags = len(c.scanSeen)
+	return stats
+}
+
+// onAdvReport 处理一条扫描上报：过滤、按标签限流后加入待上报批次。运行在串口读取协程中。
+func (c *BLEController) onAdvReport(urc URC) {
+	now := time.Now()
+	s := parseSighting(urc, now)
+
+	c.scanMu.Lock()
+	defer c.scanMu.Unlock()
+	if !c.scanning {
+		return
+	}
+	c.scanStats.Reports++
+	if !c.scanFilter.match(&s) {
+		c.scanStats.Filtered++
+		return
+	}
+	if limit := c.scanOpts.TagInterval; limit > 0 {
+		if last, ok := c.scanSeen[s.MAC]; ok && now.Sub(last) < limit {
+			c.scanStats.RateLimited++
+			return
+		}
+	}
+	c.scanSeen[s.MAC] = now
+	c.scanBatch = append(c.scanBatch, s)
+	if len(c.scanBatch) >= c.scanOpts.BatchSize {
+		select {
+		case c.scanFlush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// flushScan 上报当前批次，并清理已超出限流窗口的标签
+func (c *BLEController) flushScan() {
+	c.scanMu.Lock()
+	batch := c.scanBatch
+	c.scanBatch = nil
+	handler := c.scanHandler
+	c.scanStats.Published += uint64(len(batch))
+	now := time.Now()
+	for mac, last := range c.scanSeen {
+		if now.Sub(last) >= c.scanOpts.TagInterval {
+			delete(c.scanSeen, mac)
+		}
+	}
+	c.scanMu.Unlock()
+	if len(batch) > 0 && handler != nil {
+		handler(batch)
+	}
+}
+
+// markScanLost 模块复位后扫描已停止，待配置恢复后重新开始
+func (c *BLEController) markScanLost() {
+	c.scanMu.Lock()
+	defer c.scanMu.Unlock()
+	if c.scanning {
+		c.scanLost = true
+	}
+}
+
+// resumeScan 模块复位且配置已恢复后按原参数重新开始扫描
+func (c *BLEController) resumeScan() {
+	c.scanMu.Lock()
+	lost, opts := c.scanning && c.scanLost, c.scanOpts
+	c.scanMu.Unlock()
+	if !lost {
+		return
+	}
+	if err := c.sendScanStart(opts); err != nil {
+		c.logger.Errorf("模块复位后恢复扫描失败: %v", err)
+		return
+	}
+	c.scanMu.Lock()
+	c.scanLost = false
+	c.scanMu.Unlock()
+	c.logger.Infof("📡 模块复位后已恢复扫描")
+}
@@ -0,0 +1,392 @@
+package ble
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scheduleCheckInterval 广播时间表的检查间隔，时间表精确到分钟
+const scheduleCheckInterval = 30 * time.Second
+
+// 广播手动控制模式
+const (
+	AdvOverrideAuto = "auto" // 按时间表广播
+	AdvOverrideOn   = "on"   // 忽略时间表，保持广播
+	AdvOverrideOff  = "off"  // 忽略时间表，停止广播
+)
+
+// 广播切换原因
+const (
+	AdvReasonSchedule = "schedule" // 时间表窗口开始或结束
+	AdvReasonOverride = "override" // 手动控制
+)
+
+// AdvertisingWindow 一个广播时间窗口，End 早于 Start 时表示跨越午夜，Days 指窗口开始的那一天
+type AdvertisingWindow struct {
+	Days  string `json:"days"`  // 星期，与 cron 的星期字段相同：* / 1-5 / 0,6 / mon-fri，0 与 7 均表示周日
+	Start string `json:"start"` // 开始时间 HH:MM
+	End   string `json:"end"`   // 结束时间 HH:MM
+}
+
+// AdvertisingSchedule 广播时间表，Windows 为空时不限制广播时间
+type AdvertisingSchedule struct {
+	Timezone string              `json:"timezone"` // IANA 时区，如 Asia/Shanghai，为空时使用本地时区
+	Windows  []AdvertisingWindow `json:"windows"`
+}
+
+// AdvertisingOverride 广播手动控制
+type AdvertisingOverride struct {
+	Mode  string    `json:"mode"`            // auto / on / off
+	Until time.Time `json:"until,omitempty"` // 到期后回到 auto，零值表示一直有效
+}
+
+// AdvertisingTransition 一次广播开始或停止
+type AdvertisingTransition struct {
+	Advertising bool   `json:"advertising"`     // 切换后是否在广播
+	Reason      string `json:"reason"`          // schedule / override
+	Timestamp   int64  `json:"timestamp"`       // 切换时间（纳秒）
+	Error       string `json:"error,omitempty"` // 切换失败的原因
+}
+
+// compiledWindow 解析后的时间窗口
+type compiledWindow struct {
+	days       [7]bool // 按 time.Weekday 索引
+	start, end int     // 一天中的分钟数
+}
+
+// compiledSchedule 解析后的时间表
+type compiledSchedule struct {
+	loc     *time.Location
+	windows []compiledWindow
+}
+
+// weekdayNames cron 星期字段中的英文缩写
+var weekdayNames = map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}
+
+// compile 校验并解析时间表
+func (s AdvertisingSchedule) compile() (*compiledSchedule, error) {
+	loc := time.Local
+	if s.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(s.Timezone); err != nil {
+			return nil, fmt.Errorf("invalid timezone %q: %v", s.Timezone, err)
+		}
+	}
+	cs := &compiledSchedule{loc: loc}
+	for i, w := range s.Windows {
+		cw, err := w.compile()
+		if err != nil {
+			return nil, fmt.Errorf("window %d: %w", i, err)
+		}
+		cs.windows = append(cs.windows, cw)
+	}
+	return cs, nil
+}
+
+// Validate 校验时间表
+func (s AdvertisingSchedule) Validate() error {
+	_, err := s.compile()
+	return err
+}
+
+// compile 解析时间窗口
+func (w AdvertisingWindow) compile() (compiledWindow, error) {
+	var cw compiledWindow
+	var err error
+	if cw.days, err = parseWeekdays(w.Days); err != nil {
+		return cw, err
+	}
+	if cw.start, err = parseClock(w.Start); err != nil {
+		return cw, err
+	}
+	if cw.end, err = parseClock(w.End); err != nil {
+		return cw, err
+	}
+	if cw.start == cw.end {
+		return cw, fmt.Errorf("window start and end are both %s", w.Start)
+	}
+	return cw, nil
+}
+
+// parseWeekdays 解析 cron 星期字段，支持 *、列表与区间
+func parseWeekdays(spec string) ([7]bool, error) {
+	var days [7]bool
+	spec = strings.ToLower(strings.TrimSpace(spec))
+	if spec == "" || spec == "*" {
+		for i := range days {
+			days[i] = true
+		}
+		return days, nil
+	}
+	for _, part := range strings.Split(spec, ",") {
+		from, to, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		lo, err := parseWeekday(from)
+		if err != nil {
+			return days, err
+		}
+		hi := lo
+		if isRange {
+			if hi, err = parseWeekday(to); err != nil {
+				return days, err
+			}
+		}
+		if hi < lo {
+			return days, fmt.Errorf("invalid weekday range %q", part)
+		}
+		for d := lo; d <= hi; d++ {
+			days[d%7] = true
+		}
+	}
+	return days, nil
+}
+
+// parseWeekday 解析单个星期，返回 0-7
+func parseWeekday(s string) (int, error) {
+	if d, ok := weekdayNames[s]; ok {
+		return d, nil
+	}
+	d, err := strconv.Atoi(s)
+	if err != nil || d < 0 || d > 7 {
+		return 0, fmt.Errorf("invalid weekday %q", s)
+	}
+	return d, nil
+}
+
+// parseClock 解析 HH:MM，返回一天中的分钟数
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// active 判断指定时刻是否在任一窗口内
+func (cs *compiledSchedule) active(now time.Time) bool {
+	now = now.In(cs.loc)
+	minute := now.Hour()*60 + now.Minute()
+	today := now.Weekday()
+	yesterday := (today + 6) % 7
+	for _, w := range cs.windows {
+		if w.start < w.end {
+			if w.days[today] && minute >= w.start && minute < w.end {
+				return true
+			}
+			continue
+		}
+		// 跨越午夜：开始当天的 start 之后，或次日的 end 之前
+		if (w.days[today] && minute >= w.start) || (w.days[yesterday] && minute < w.end) {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadAdvertisingSchedule 从 JSON 文件读取广播时间表，文件不存在时返回 false
+func LoadAdvertisingSchedule(path string) (AdvertisingSchedule, bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return AdvertisingSchedule{}, false, nil
+	}
+	if err != nil {
+		return AdvertisingSchedule{}, false, err
+	}
+	var s AdvertisingSchedule
+	if err := json.Unmarshal(data, &s); err != nil {
+		return AdvertisingSchedule{}, false, fmt.Errorf("invalid advertising schedule %s: %v", path, err)
+	}
+	if err := s.Validate(); err != nil {
+		return AdvertisingSchedule{}, false, fmt.Errorf("invalid advertising schedule %s: %v", path, err)
+	}
+	return s, true, nil
+}
+
+// SaveAdvertisingSchedule 将广播时间表写入 JSON 文件
+func SaveAdvertisingSchedule(path string, s AdvertisingSchedule) error {
+	return writeJSONFile(path, s)
+}
+
+// SetAdvertisingSchedule 更新广播时间表并立即按新时间表检查一次。
+func (c *BLEController) SetAdvertisingSchedule(s AdvertisingSchedule) error {
+	cs, err := s.compile()
+	if err != nil {
+		return err
+	}
+	c.schedMu.Lock()
+	c.schedule, c.compiled = s, cs
+	c.schedMu.Unlock()
+	c.logger.Infof("🗓️ 广播时间表已更新: %d 个窗口, 时区 %s", len(s.Windows), cs.loc)
+	c.triggerSchedule()
+	return nil
+}
+
+// SetAdvertisingOverride 手动控制广播，不依赖手机端 +COMMAND。duration > 0 时到期后回到时间表控制。
+func (c *BLEController) SetAdvertisingOverride(mode string, duration time.Duration) error {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	switch mode {
+	case AdvOverrideAuto, AdvOverrideOn, AdvOverrideOff:
+	default:
+		return fmt.Errorf("unsupported advertising override %q, expected %s / %s / %s", mode, AdvOverrideAuto, AdvOverrideOn, AdvOverrideOff)
+	}
+	o := AdvertisingOverride{Mode: mode}
+	if duration > 0 && mode != AdvOverrideAuto {
+		o.Until = time.Now().Add(duration)
+	}
+	c.schedMu.Lock()
+	c.override = o
+	c.schedMu.Unlock()
+	c.logger.Infof("🗓️ 广播手动控制: %s, 有效期 %v", mode, duration)
+	c.triggerSchedule()
+	return nil
+}
+
+// AdvertisingSchedule 返回当前的广播时间表与手动控制。
+func (c *BLEController) AdvertisingSchedule() (AdvertisingSchedule, AdvertisingOverride) {
+	c.schedMu.Lock()
+	defer c.schedMu.Unlock()
+	return c.schedule, c.currentOverride(time.Now())
+}
+
+// AdvertisingWanted 返回当前是否应当广播及原因，managed 为 false 表示既没有时间表也没有手动控制。
+func (c *BLEController) AdvertisingWanted() (want bool, reason string, managed bool) {
+	c.schedMu.Lock()
+	defer c.schedMu.Unlock()
+	return c.wantAdvertising(time.Now())
+}
+
+// wantAdvertising 按手动控制与时间表判断是否应当广播，调用方持有 schedMu
+func (c *BLEController) wantAdvertising(now time.Time) (bool, string, bool) {
+	switch o := c.currentOverride(now); o.Mode {
+	case AdvOverrideOn:
+		return true, AdvReasonOverride, true
+	case AdvOverrideOff:
+		return false, AdvReasonOverride, true
+	}
+	if c.compiled == nil || len(c.compiled.windows) == 0 {
+		return true, AdvReasonSchedule, false
+	}
+	return c.compiled.active(now), AdvReasonSchedule, true
+}
+
+// currentOverride 返回当前有效的手动控制，已到期时清除，调用方持有 schedMu
+func (c *BLEController) currentOverride(now time.Time) AdvertisingOverride {
+	if c.override.Mode == "" {
+		c.override.Mode = AdvOverrideAuto
+	}
+	if !c.override.Until.IsZero() && now.After(c.override.Until) {
+		c.logger.Infof("🗓️ 广播手动控制 %s 已到期，恢复按时间表广播", c.override.Mode)
+		c.override = AdvertisingOverride{Mode: AdvOverrideAuto}
+	}
+	return c.override
+}
+
+// advertisingAllowed 当前是否允许广播，供断开后自动重新广播等场景使用
+func (c *BLEController) advertisingAllowed() bool {
+	want, _, _ := c.AdvertisingWanted()
+	return want
+}
+
+// StartAdvertisingScheduler 启动广播时间表，在窗口边界与手动控制变化时开始或停止广播，每次切换交给 handler。
+func (c *BLEController) StartAdvertisingScheduler(handler func(AdvertisingTransition)) {
+	c.StopAdvertisingScheduler()
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	trigger := make(chan struct{}, 1)
+	c.schedMu.Lock()
+	c.schedStop, c.schedDone, c.schedTrigger = stop, done, trigger
+	c.schedMu.Unlock()
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(scheduleCheckInterval)
+		defer ticker.Stop()
+		c.applySchedule(handler)
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			case <-trigger:
+			}
+			c.applySchedule(handler)
+		}
+	}()
+}
+
+// StopAdvertisingScheduler 停止广播时间表
+func (c *BLEController) StopAdvertisingScheduler() {
+	c.schedMu.Lock()
+	stop, done := c.schedStop, c.schedDone
+	c.schedStop, c.schedDone, c.schedTrigger = nil, nil, nil
+	c.schedMu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+// triggerSchedule 请求立即检查一次时间表
+func (c *BLEController) triggerSchedule() {
+	c.schedMu.Lock()
+	trigger := c.schedTrigger
+	c.schedMu.Unlock()
+	if trigger == nil {
+		return
+	}
+	select {
+	case trigger <- struct{}{}:
+	default:
+	}
+}
+
+// applySchedule 比较期望与实际的广播状态并开始或停止广播。
+// 只在 GATT 就绪与广播两种状态间切换，已有连接或模块尚未初始化完成时不处理。
+func (c *BLEController) applySchedule(handler func(AdvertisingTransition)) {
+	want, reason, managed := c.AdvertisingWanted()
+	c.schedMu.Lock()
+	stoppedBySchedule := c.schedStopped
+	c.schedMu.Unlock()
+	// 不受时间表管理时不干预广播，只恢复此前由时间表或手动控制停止的广播
+	if !managed && !stoppedBySchedule {
+		return
+	}
+	var op Operation
+	switch state := c.State(); {
+	case want && state == StateGATTReady:
+		op = OpStartAdvertising
+	case !want && state == StateAdvertising:
+		op = OpStopAdvertising
+	default:
+		return
+	}
+
+	t := AdvertisingTransition{Advertising: want, Reason: reason, Timestamp: time.Now().UnixNano()}
+	cmd, err := c.build(op)
+	if err == nil {
+		err = c.SendSingle(cmd)
+	}
+	switch {
+	case err != nil:
+		t.Advertising = !want
+		t.Error = err.Error()
+		c.logger.Errorf("按%s切换广播失败: %v", reason, err)
+	case want:
+		c.logger.Infof("🗓️ 已开始广播 (%s)", reason)
+	default:
+		c.logger.Infof("🗓️ 已停止广播 (%s)", reason)
+	}
+	if err == nil {
+		c.schedMu.Lock()
+		c.schedStopped = !want
+		c.schedMu.Unlock()
+	}
+	if handler != nil {
+		handler(t)
+	}
+}
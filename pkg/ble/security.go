@@ -0,0 +1,223 @@
+package ble
+
+import (
+	"device-ble/internal/interfaces"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// SecurityMode 配对安全模式
+type SecurityMode int
+
+const (
+	SecurityModeNone           SecurityMode = iota // 不加密，任何设备都可访问
+	SecurityModeJustWorks                          // 无 MITM 保护的配对
+	SecurityModePasskeyDisplay                     // 网关生成并展示配对码，手机输入
+	SecurityModePasskeyEntry                       // 手机展示配对码，网关输入（使用配置的配对码回复）
+	SecurityModeStaticPasskey                      // 固定配对码，手机输入
+)
+
+// securityModeNames 安全模式名称，用于配置与写资源
+var securityModeNames = map[string]SecurityMode{
+	"none":            SecurityModeNone,
+	"just-works":      SecurityModeJustWorks,
+	"passkey-display": SecurityModePasskeyDisplay,
+	"passkey-entry":   SecurityModePasskeyEntry,
+	"static-passkey":  SecurityModeStaticPasskey,
+}
+
+// encryptionGrace 模块不支持拒绝未加密访问时，连接建立后等待加密完成的最长时间
+const encryptionGrace = 10 * time.Second
+
+var (
+	passkeyPattern = regexp.MustCompile(`^[0-9]{6}$`)
+	macPattern     = regexp.MustCompile(`^([0-9A-Fa-f]{2}:){5}[0-9A-Fa-f]{2}$`)
+)
+
+// ParseSecurityMode 将安全模式名称转换为 SecurityMode。
+func ParseSecurityMode(name string) (SecurityMode, error) {
+	mode, ok := securityModeNames[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return SecurityModeNone, fmt.Errorf("unsupported security mode %q", name)
+	}
+	return mode, nil
+}
+
+// NeedsPasskey 返回该模式是否需要预先配置的配对码
+func (m SecurityMode) NeedsPasskey() bool {
+	return m == SecurityModePasskeyEntry || m == SecurityModeStaticPasskey
+}
+
+// SecurityConfig 链路安全配置
+type SecurityConfig struct {
+	Mode              string `json:"mode"`              // none / just-works / passkey-display / passkey-entry / static-passkey
+	Bonding           bool   `json:"bonding"`           // 是否保存绑定信息
+	RequireEncryption bool   `json:"requireEncryption"` // 是否拒绝未加密的访问
+	Passkey           string `json:"passkey,omitempty"` // 6 位配对码，为空时由调用方从密钥存储中读取
+}
+
+// validatePasskey 校验配对码是否为 6 位数字
+func validatePasskey(passkey string) error {
+	if !passkeyPattern.MatchString(passkey) {
+		return fmt.Errorf("passkey must be 6 digits")
+	}
+	return nil
+}
+
+// isMACAddress 判断是否为 XX:XX:XX:XX:XX:XX 格式的地址
+func isMACAddress(addr string) bool {
+	return macPattern.MatchString(addr)
+}
+
+// boolToInt 将布尔值转换为 AT 命令参数
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// ApplySecurity 下发链路安全配置。模块不支持拒绝未加密访问时，改为由服务端断开超时未加密的连接。
+func (c *BLEController) ApplySecurity(cfg SecurityConfig) error {
+	mode, err := ParseSecurityMode(cfg.Mode)
+	if err != nil {
+		return err
+	}
+	if mode.NeedsPasskey() {
+		if err := validatePasskey(cfg.Passkey); err != nil {
+			return fmt.Errorf("security mode %s: %w", cfg.Mode, err)
+		}
+	}
+	if cfg.RequireEncryption && mode == SecurityModeNone {
+		return fmt.Errorf("requireEncryption conflicts with security mode none")
+	}
+
+	ops := []opArgs{opOf(OpSetSecurityMode, mode)}
+	if mode == SecurityModeStaticPasskey {
+		ops = append(ops, opOf(OpSetStaticPasskey, cfg.Passkey))
+	}
+	ops = append(ops, opOf(OpSetBonding, cfg.Bonding))
+	cmds, err := c.buildAll(ops...)
+	if err != nil {
+		return err
+	}
+	if err := c.SendMulti(cmds); err != nil {
+		return err
+	}
+
+	enforce := false
+	cmd, err := c.build(OpSetEncryptionRequired, cfg.RequireEncryption)
+	if err == nil {
+		err = c.SendSingle(cmd)
+	}
+	if err != nil {
+		if cfg.RequireEncryption {
+			c.logger.Warnf("模块不支持拒绝未加密访问，改为断开 %v 内未完成加密的连接", encryptionGrace)
+			enforce = true
+		}
+	}
+
+	c.secMu.Lock()
+	c.security = cfg
+	c.enforceEncryption = enforce
+	c.secMu.Unlock()
+	c.logger.Infof("🔒 链路安全配置已更新: mode=%s, bonding=%v, requireEncryption=%v", cfg.Mode, cfg.Bonding, cfg.RequireEncryption)
+	return nil
+}
+
+// BondedPeers 返回模块中已绑定的对端地址列表。
+func (c *BLEController) BondedPeers() ([]string, error) {
+	cmd, err := c.build(OpQueryBondedPeers)
+	if err != nil {
+		return nil, err
+	}
+	prefix := c.dialect.InfoPrefix(OpQueryBondedPeers)
+	lines, err := c.queryLines(cmd, prefix)
+	if err != nil {
+		return nil, err
+	}
+	peers := make([]string, 0, len(lines))
+	for _, line := range lines {
+		fields := splitURCFields(line, prefix)
+		if len(fields) == 0 {
+			continue
+		}
+		// <prefix> <idx>,<addr> 或 <prefix> <addr>，地址总在最后一个字段
+		addr := fields[len(fields)-1]
+		if isMACAddress(addr) {
+			peers = append(peers, strings.ToUpper(addr))
+		}
+	}
+	return peers, nil
+}
+
+// RemoveBond 删除指定已绑定设备，addr 为 "all" 时清空全部绑定信息。
+func (c *BLEController) RemoveBond(addr string) error {
+	var cmd string
+	var err error
+	if strings.EqualFold(addr, "all") {
+		cmd, err = c.build(OpClearBonds)
+	} else {
+		cmd, err = c.build(OpDeleteBond, addr)
+	}
+	if err != nil {
+		return err
+	}
+	return c.SendSingle(cmd)
+}
+
+// onPasskeyDisplay 模块生成了需要展示给用户的配对码，配对码属于敏感信息，只在调试日志中输出
+func (c *BLEController) onPasskeyDisplay(urc URC) {
+	c.logger.Debugf("🔑 配对码: conn=%d, passkey=%s", urc.ConnID, urc.Passkey)
+}
+
+// onPasskeyRequest 使用配置的配对码回复对端的配对码请求
+func (c *BLEController) onPasskeyRequest(urc URC) {
+	c.secMu.RLock()
+	passkey := c.security.Passkey
+	c.secMu.RUnlock()
+	cmd, err := c.build(OpReplyPasskey, urc.ConnID, passkey)
+	if err != nil {
+		c.logger.Errorf("连接 %d 请求配对码，但未配置有效配对码: %v", urc.ConnID, err)
+		return
+	}
+	go func() {
+		if err := c.SendSingle(cmd); err != nil {
+			c.logger.Errorf("回复连接 %d 的配对码失败: %v", urc.ConnID, err)
+		}
+	}()
+}
+
+// onEncryptionChanged 记录连接的加密状态
+func (c *BLEController) onEncryptionChanged(urc URC) {
+	c.connMu.Lock()
+	if conn, ok := c.connections[urc.ConnID]; ok {
+		conn.Encrypted = urc.Encrypted
+	}
+	c.connMu.Unlock()
+	c.logger.Infof("🔒 连接加密状态变化: conn=%d, encrypted=%v", urc.ConnID, urc.Encrypted)
+}
+
+// enforceEncryptionFor 在模块不支持拒绝未加密访问时，断开超时仍未加密的连接
+func (c *BLEController) enforceEncryptionFor(conn interfaces.BLEConnection) {
+	time.Sleep(encryptionGrace)
+	c.connMu.RLock()
+	current, ok := c.connections[conn.ConnID]
+	encrypted := ok && current.Encrypted
+	sameConn := ok && current.ConnectedAt.Equal(conn.ConnectedAt)
+	c.connMu.RUnlock()
+	if !sameConn || encrypted {
+		return
+	}
+	c.logger.Warnf("⛔️ 连接 %d (%s) 未在 %v 内完成加密，主动断开", conn.ConnID, conn.PeerAddr, encryptionGrace)
+	cmd, err := c.build(OpDisconnect, conn.ConnID)
+	if err != nil {
+		c.logger.Errorf("生成断开命令失败: %v", err)
+		return
+	}
+	if err := c.SendSingle(cmd); err != nil {
+		c.logger.Errorf("断开未加密连接失败: %v", err)
+	}
+}
@@ -0,0 +1,164 @@
+package ble
+
+import (
+	"fmt"
+	"time"
+)
+
+// ModuleState 模块生命周期状态
+type ModuleState string
+
+const (
+	StateUnknown     ModuleState = "unknown"     // 服务刚启动，尚未与模块同步状态
+	StateReset       ModuleState = "reset"       // 已复位，BLE 协议栈未初始化
+	StateInitialized ModuleState = "initialized" // 已初始化角色，可以定义 GATT 服务
+	StateGATTReady   ModuleState = "gattReady"   // GATT 服务已提交，未在广播
+	StateAdvertising ModuleState = "advertising" // 正在广播
+	StateConnected   ModuleState = "connected"   // 至少有一个中心设备已连接
+)
+
+// stateLevel 状态的先后顺序，用于判断是否已达到某个阶段
+var stateLevel = map[ModuleState]int{
+	StateReset:       1,
+	StateInitialized: 2,
+	StateGATTReady:   3,
+	StateAdvertising: 4,
+	StateConnected:   5,
+}
+
+// stateRule 操作允许执行的状态区间 [min, max]，max 为空表示不设上限
+type stateRule struct {
+	min, max ModuleState
+}
+
+// stateRules 受状态约束的操作，未列出的操作在任何状态下都可以执行
+var stateRules = map[Operation]stateRule{
+	OpInit:                {min: StateReset, max: StateReset},
+	OpAddService:          {min: StateInitialized, max: StateInitialized},
+	OpAddCharacteristic:   {min: StateInitialized, max: StateInitialized},
+	OpFinishGATTServer:    {min: StateInitialized, max: StateInitialized},
+	OpSetAdvParam:         {min: StateInitialized},
+	OpSetAdvertisingData:  {min: StateInitialized},
+	OpSetScanResponseData: {min: StateInitialized},
+	OpStartAdvertising:    {min: StateGATTReady},
+	OpStopAdvertising:     {min: StateGATTReady},
+	OpNotify:              {min: StateConnected},
+	OpIndicate:            {min: StateConnected},
+	OpDisconnect:          {min: StateConnected},
+	OpUpdateConn:          {min: StateConnected},
+	OpQueryRSSI:           {min: StateConnected},
+	OpSetScanParam:        {min: StateInitialized},
+	OpStartScan:           {min: StateInitialized},
+	OpStopScan:            {min: StateInitialized},
+	OpSetCharValue:        {min: StateGATTReady},
+}
+
+// ErrInvalidState 当前模块状态不允许执行该操作
+type ErrInvalidState struct {
+	Op       Operation
+	State    ModuleState
+	Required string
+}
+
+func (e ErrInvalidState) Error() string {
+	return fmt.Sprintf("operation %q is not allowed in module state %q, requires %s", e.Op, e.State, e.Required)
+}
+
+// State 返回模块当前的生命周期状态。
+func (c *BLEController) State() ModuleState {
+	c.stateMu.RLock()
+	defer c.stateMu.RUnlock()
+	return c.state
+}
+
+// StateSince 返回模块进入当前状态的时间。
+func (c *BLEController) StateSince() time.Time {
+	c.stateMu.RLock()
+	defer c.stateMu.RUnlock()
+	return c.stateSince
+}
+
+// setState 切换模块状态
+func (c *BLEController) setState(next ModuleState) {
+	c.stateMu.Lock()
+	prev := c.state
+	if prev != next {
+		c.state = next
+		c.stateSince = time.Now()
+	}
+	c.stateMu.Unlock()
+	if prev != next {
+		c.logger.Infof("📶 模块状态: %s -> %s", prev, next)
+	}
+}
+
+// checkState 检查当前状态是否允许执行该操作；状态未知时不做限制
+func (c *BLEController) checkState(op Operation) error {
+	rule, ok := stateRules[op]
+	if !ok {
+		return nil
+	}
+	state := c.State()
+	if state == StateUnknown {
+		return nil
+	}
+	// 模块不上报连接事件时状态不会进入 connected，Notify 与 Indication 退回发往连接 0，只要求 GATT 服务已提交
+	if (op == OpNotify || op == OpIndicate) && !c.ConnectionTracked() {
+		rule = stateRule{min: StateGATTReady}
+	}
+	level := stateLevel[state]
+	if level < stateLevel[rule.min] || (rule.max != "" && level > stateLevel[rule.max]) {
+		var required string
+		switch rule.max {
+		case rule.min:
+			required = fmt.Sprintf("state %q", rule.min)
+		case "":
+			required = fmt.Sprintf("state %q or later", rule.min)
+		default:
+			required = fmt.Sprintf("state between %q and %q", rule.min, rule.max)
+		}
+		return ErrInvalidState{Op: op, State: state, Required: required}
+	}
+	return nil
+}
+
+// guard 识别命令对应的操作并检查当前状态是否允许发送
+func (c *BLEController) guard(cmd string) error {
+	op, ok := c.dialect.Identify(cmd)
+	if !ok {
+		return nil
+	}
+	return c.checkState(op)
+}
+
+// advance 命令执行成功后推进模块状态
+func (c *BLEController) advance(cmd string) {
+	op, ok := c.dialect.Identify(cmd)
+	if !ok {
+		return
+	}
+	switch op {
+	case OpReset:
+		c.setState(StateReset)
+	case OpInit:
+		c.setState(StateInitialized)
+	case OpFinishGATTServer:
+		c.setState(StateGATTReady)
+	case OpStartAdvertising:
+		// 已有连接时模块继续保持连接状态
+		if !c.hasConnections() {
+			c.setState(StateAdvertising)
+		}
+	case OpStopAdvertising:
+		if c.State() == StateAdvertising {
+			c.setState(StateGATTReady)
+		}
+	}
+}
+
+// hasConnections 是否存在已连接的中心设备
+func (c *BLEController) hasConnections() bool {
+	c.connMu.RLock()
+	defer c.connMu.RUnlock()
+	return len(c.connections) > 0
+}
@@ -0,0 +1,30 @@
+package ble
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNotifyWithoutConnectionTracking(t *testing.T) {
+	m := NewFakeModule("HCM111Z V1.4.0")
+	c := newFakeController(t, m, quectelDialect{})
+
+	// GATT 服务提交之前不能发送
+	c.setState(StateInitialized)
+	var stateErr ErrInvalidState
+	if err := c.SendJSONTo(0, map[string]string{"status": "ok"}); !errors.As(err, &stateErr) {
+		t.Fatalf("SendJSONTo before GATT is ready = %v, want ErrInvalidState", err)
+	}
+
+	// 模块从未上报连接事件，广播中向连接 0 发送
+	c.setState(StateAdvertising)
+	if c.ConnectionTracked() {
+		t.Fatal("connections tracked without a connection URC")
+	}
+	if err := c.SendJSONTo(0, map[string]string{"status": "ok"}); err != nil {
+		t.Fatalf("SendJSONTo without connection tracking: %v", err)
+	}
+	if n := countCommands(m, "AT+QBLEGATTSNTFY=0,"); n != 1 {
+		t.Errorf("sent %d notify packets to conn 0, want 1", n)
+	}
+}
@@ -0,0 +1,313 @@
+package ble
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"time"
+)
+
+// 固件镜像文件格式（多字节字段均为小端）：
+//
+//	0   magic    4 字节 "QFW1"
+//	4   version  16 字节 ASCII 版本号，不足部分补 0
+//	20  size     uint32 固件负载长度
+//	24  crc32    uint32 固件负载的 CRC-32 (IEEE)
+//	28  payload  固件负载，原样下发给模块
+//
+// 该格式与 AT+QFOTA 系列命令未经 HCM111Z 升级协议文档确认，驱动默认关闭固件升级，
+// 取得模块厂商的升级协议后需按文档核对再开启。
+const (
+	firmwareMagic      = "QFW1"
+	firmwareHeaderSize = 28
+)
+
+// 固件升级阶段
+const (
+	UpgradeValidating   = "validating"   // 校验镜像
+	UpgradeTransferring = "transferring" // 分段传输
+	UpgradeApplying     = "applying"     // 模块校验并重启
+	UpgradeVerifying    = "verifying"    // 查询新版本
+	UpgradeCompleted    = "completed"    // 升级完成
+	UpgradeFailed       = "failed"       // 升级失败，见 Error
+)
+
+// 固件升级默认参数
+const (
+	DefaultUpgradeChunkSize  = 128              // 每条数据命令携带的字节数
+	DefaultUpgradeRetries    = 3                // 单段传输失败后的重试次数
+	DefaultUpgradeBootTime   = 30 * time.Second // 等待模块重启进入新固件的最长时间
+	firmwareChunkTimeout     = 2 * time.Second  // 单段数据等待模块写入 Flash 的时间
+	firmwareBeginTimeout     = 10 * time.Second // 模块擦除升级分区的时间
+	firmwareVerifyRetryDelay = 2 * time.Second  // 重启后查询版本的间隔
+)
+
+// ErrUpgradeInProgress 已有固件升级在进行
+var ErrUpgradeInProgress = errors.New("firmware upgrade already in progress")
+
+// FirmwareImage 解析后的固件镜像
+type FirmwareImage struct {
+	Version FirmwareVersion // 镜像头中的版本号
+	CRC     uint32          // 负载校验值
+	Payload []byte          // 固件负载
+}
+
+// ParseFirmwareImage 解析并校验固件镜像：魔数、负载长度与 CRC-32。
+func ParseFirmwareImage(data []byte) (*FirmwareImage, error) {
+	if len(data) < firmwareHeaderSize {
+		return nil, fmt.Errorf("firmware image too short: %d bytes", len(data))
+	}
+	if string(data[:4]) != firmwareMagic {
+		return nil, fmt.Errorf("invalid firmware image magic %q", data[:4])
+	}
+	version, err := ParseFirmwareVersion(string(bytes.TrimRight(data[4:20], "\x00")), "")
+	if err != nil {
+		return nil, fmt.Errorf("firmware image header: %w", err)
+	}
+	size := binary.LittleEndian.Uint32(data[20:24])
+	crc := binary.LittleEndian.Uint32(data[24:28])
+	payload := data[firmwareHeaderSize:]
+	if uint32(len(payload)) != size {
+		return nil, fmt.Errorf("firmware payload length %d does not match header size %d", len(payload), size)
+	}
+	if size == 0 {
+		return nil, errors.New("firmware image has empty payload")
+	}
+	if sum := crc32.ChecksumIEEE(payload); sum != crc {
+		return nil, fmt.Errorf("firmware checksum mismatch: header %08X, payload %08X", crc, sum)
+	}
+	return &FirmwareImage{Version: version, CRC: crc, Payload: payload}, nil
+}
+
+// LoadFirmwareImage 读取并校验固件镜像文件。
+func LoadFirmwareImage(path string) (*FirmwareImage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read firmware image: %w", err)
+	}
+	return ParseFirmwareImage(data)
+}
+
+// BuildFirmwareImage 按镜像文件格式封装固件负载，用于打包与本地模拟测试。
+func BuildFirmwareImage(version string, payload []byte) ([]byte, error) {
+	if len(version) > 16 {
+		return nil, fmt.Errorf("firmware version too long: %q", version)
+	}
+	buf := make([]byte, firmwareHeaderSize, firmwareHeaderSize+len(payload))
+	copy(buf, firmwareMagic)
+	copy(buf[4:20], version)
+	binary.LittleEndian.PutUint32(buf[20:24], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(buf[24:28], crc32.ChecksumIEEE(payload))
+	return append(buf, payload...), nil
+}
+
+// UpgradeOptions 固件升级选项，零值字段使用默认值
+type UpgradeOptions struct {
+	ChunkSize int           // 每条数据命令携带的字节数，不超过 MaxFirmwareChunk
+	Retries   int           // 单段传输失败后的重试次数
+	BootTime  time.Duration // 等待模块重启进入新固件的最长时间
+}
+
+// UpgradeProgress 固件升级进度
+type UpgradeProgress struct {
+	Stage    string `json:"stage"`           // 见 UpgradeValidating 等常量
+	Version  string `json:"version"`         // 目标版本
+	Previous string `json:"previous"`        // 升级前的版本
+	Sent     int    `json:"sent"`            // 模块已接收的字节数
+	Total    int    `json:"total"`           // 固件负载总长度
+	Percent  int    `json:"percent"`         // 传输进度百分比
+	Resumed  bool   `json:"resumed"`         // 是否从上次中断处继续传输
+	Error    string `json:"error,omitempty"` // 失败原因
+}
+
+// Upgrading 返回是否正在进行固件升级，期间配置校验与空闲休眠暂停。
+func (c *BLEController) Upgrading() bool {
+	c.upgradeMu.Lock()
+	defer c.upgradeMu.Unlock()
+	return c.upgrading
+}
+
+// UpgradeFirmware 通过串口升级模块固件：分段传输镜像负载，中断后按模块已接收的长度续传，
+// 模块重启后查询版本确认升级成功。每个阶段及传输进度变化时调用 progress。
+// 升级期间不能有中心设备连接。
+func (c *BLEController) UpgradeFirmware(img *FirmwareImage, opts UpgradeOptions, progress func(UpgradeProgress)) error {
+	c.upgradeMu.Lock()
+	if c.upgrading {
+		c.upgradeMu.Unlock()
+		return ErrUpgradeInProgress
+	}
+	c.upgrading = true
+	c.upgradeMu.Unlock()
+	defer func() {
+		c.upgradeMu.Lock()
+		c.upgrading = false
+		c.upgradeMu.Unlock()
+	}()
+
+	if opts.ChunkSize <= 0 || opts.ChunkSize > MaxFirmwareChunk {
+		opts.ChunkSize = DefaultUpgradeChunkSize
+	}
+	if opts.Retries <= 0 {
+		opts.Retries = DefaultUpgradeRetries
+	}
+	if opts.BootTime <= 0 {
+		opts.BootTime = DefaultUpgradeBootTime
+	}
+
+	p := UpgradeProgress{Stage: UpgradeValidating, Version: img.Version.String(), Total: len(img.Payload)}
+	if fw, ok := c.Firmware(); ok {
+		p.Previous = fw.String()
+	}
+	report := func(stage string) {
+		p.Stage = stage
+		if p.Total > 0 {
+			p.Percent = p.Sent * 100 / p.Total
+		}
+		if progress != nil {
+			progress(p)
+		}
+	}
+	fail := func(err error) error {
+		p.Error = err.Error()
+		report(UpgradeFailed)
+		c.logger.Errorf("⛔️ 固件升级失败 (%d/%d 字节): %v", p.Sent, p.Total, err)
+		return err
+	}
+	report(UpgradeValidating)
+
+	if len(img.Payload) == 0 {
+		return fail(errors.New("firmware image has no payload"))
+	}
+	if n := len(c.Connections()); n > 0 {
+		return fail(fmt.Errorf("cannot upgrade firmware while %d central(s) connected", n))
+	}
+	// 先生成全部固定命令，方言或固件不支持时在传输前失败
+	cmds, err := c.buildAll(opOf(OpFirmwareStatus), opOf(OpFirmwareBegin, len(img.Payload), img.CRC), opOf(OpFirmwareApply))
+	if err != nil {
+		return fail(err)
+	}
+	statusCmd, beginCmd, applyCmd := cmds[0], cmds[1], cmds[2]
+
+	// 模块保留着同一镜像的接收进度时从中断处续传
+	offset := 0
+	if t, err := c.firmwareTransfer(statusCmd); err == nil && t.Size == len(img.Payload) && t.CRC == img.CRC && t.Received > 0 && t.Received <= t.Size {
+		offset, p.Resumed = t.Received, true
+		c.logger.Infof("📦 从 %d/%d 字节处续传固件 %s", offset, t.Size, img.Version)
+	} else if err := c.sendFirmwareCommand(beginCmd, firmwareBeginTimeout); err != nil {
+		return fail(fmt.Errorf("begin upgrade: %w", err))
+	}
+	p.Sent = offset
+	report(UpgradeTransferring)
+
+	lastPercent := p.Percent
+	for offset < len(img.Payload) {
+		end := offset + opts.ChunkSize
+		if end > len(img.Payload) {
+			end = len(img.Payload)
+		}
+		next, err := c.writeFirmwareChunk(statusCmd, img, offset, end, opts.Retries)
+		if err != nil {
+			return fail(err)
+		}
+		offset, p.Sent = next, next
+		c.MarkActivity()
+		if percent := p.Sent * 100 / p.Total; percent != lastPercent {
+			lastPercent = percent
+			report(UpgradeTransferring)
+		}
+	}
+
+	// 模块校验通过后重启，复位上报按主动复位处理
+	report(UpgradeApplying)
+	c.markResetRequested()
+	if err := c.sendFirmwareCommand(applyCmd, firmwareBeginTimeout); err != nil {
+		return fail(fmt.Errorf("apply upgrade: %w", err))
+	}
+
+	report(UpgradeVerifying)
+	v, err := c.awaitFirmware(opts.BootTime)
+	if err != nil {
+		return fail(err)
+	}
+	if v.Compare(img.Version) != 0 {
+		return fail(fmt.Errorf("module reports version %s after upgrade, expected %s", v, img.Version))
+	}
+	report(UpgradeCompleted)
+	c.logger.Infof("✅ 固件升级完成: %s -> %s", p.Previous, v)
+	return nil
+}
+
+// writeFirmwareChunk 写入 [offset, end) 段数据，失败时查询模块已接收的长度并从该处重试，返回新的已接收长度
+func (c *BLEController) writeFirmwareChunk(statusCmd string, img *FirmwareImage, offset, end, retries int) (int, error) {
+	var lastErr error
+	for attempt := 0; attempt <= retries; attempt++ {
+		cmd, err := c.build(OpFirmwareData, offset, img.Payload[offset:end])
+		if err != nil {
+			return offset, err
+		}
+		if lastErr = c.sendFirmwareCommand(cmd, firmwareChunkTimeout); lastErr == nil {
+			return end, nil
+		}
+		c.logger.Warnf("固件数据写入失败 (offset=%d, 第 %d 次): %v", offset, attempt+1, lastErr)
+
+		// 应答丢失时模块可能已经写入，以模块上报的长度为准
+		t, err := c.firmwareTransfer(statusCmd)
+		if err != nil {
+			continue
+		}
+		if t.CRC != img.CRC || t.Size != len(img.Payload) || t.Received > len(img.Payload) {
+			return offset, fmt.Errorf("module lost upgrade session at offset %d", offset)
+		}
+		if t.Received >= end {
+			return t.Received, nil
+		}
+		offset = t.Received
+	}
+	return offset, fmt.Errorf("write firmware at offset %d: %w", offset, lastErr)
+}
+
+// firmwareTransfer 查询模块的固件接收进度
+func (c *BLEController) firmwareTransfer(statusCmd string) (FirmwareTransfer, error) {
+	lines, err := c.queryLines(statusCmd, c.dialect.InfoPrefix(OpFirmwareStatus))
+	if err != nil {
+		return FirmwareTransfer{}, err
+	}
+	if len(lines) == 0 {
+		return FirmwareTransfer{}, fmt.Errorf("no %s result from module", OpFirmwareStatus)
+	}
+	return ParseFirmwareStatusResponse(lines[len(lines)-1])
+}
+
+// sendFirmwareCommand 发送一条升级命令并检查应答，不逐条记录成功日志
+func (c *BLEController) sendFirmwareCommand(cmd string, timeout time.Duration) error {
+	response, err := c.Queue.SendCommand([]byte(cmd), timeout, 1*time.Millisecond, 100*time.Millisecond)
+	if err != nil {
+		return err
+	}
+	r := ParseResponse(response)
+	if rerr := r.Err(); rerr != nil {
+		return rerr
+	}
+	if !r.Succeeded() {
+		return fmt.Errorf("unexpected response: %q", response)
+	}
+	return nil
+}
+
+// awaitFirmware 等待模块重启后查询并识别新固件版本
+func (c *BLEController) awaitFirmware(bootTime time.Duration) (FirmwareVersion, error) {
+	deadline := time.Now().Add(bootTime)
+	for {
+		time.Sleep(firmwareVerifyRetryDelay)
+		v, err := c.DetectFirmware()
+		if err == nil {
+			return v, nil
+		}
+		if time.Now().After(deadline) {
+			return v, fmt.Errorf("module did not report a version within %v after upgrade: %w", bootTime, err)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package ble
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"device-ble/pkg/uart"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+)
+
+// newFakeController 创建使用指定方言、连接到模拟模块的控制器，测试结束时关闭
+func newFakeController(t *testing.T, m *FakeModule, d Dialect) *BLEController {
+	t.Helper()
+	lc := logger.NewMockClient()
+	c := NewBLEController(nil, uart.NewSerialQueue(m, lc, nil, nil, 5), lc, d)
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+// testFirmware 生成指定版本、长度的测试镜像
+func testFirmware(t *testing.T, version string, size int) *FirmwareImage {
+	t.Helper()
+	payload := bytes.Repeat([]byte{0xA5, 0x5A, 0x01}, size/3+1)[:size]
+	data, err := BuildFirmwareImage(version, payload)
+	if err != nil {
+		t.Fatalf("BuildFirmwareImage: %v", err)
+	}
+	img, err := ParseFirmwareImage(data)
+	if err != nil {
+		t.Fatalf("ParseFirmwareImage: %v", err)
+	}
+	return img
+}
+
+// countCommands 统计模块收到的以 prefix 开头的命令数
+func countCommands(m *FakeModule, prefix string) int {
+	n := 0
+	for _, cmd := range m.History() {
+		if strings.HasPrefix(cmd, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+var testUpgradeOptions = UpgradeOptions{ChunkSize: 128, BootTime: time.Second}
+
+func TestUpgradeFirmware(t *testing.T) {
+	m := NewFakeModule("HCM111Z V1.4.0")
+	m.SetUpgradeVersion("HCM111Z V1.5.0")
+	c := newFakeController(t, m, quectelExtendedDialect{})
+	img := testFirmware(t, "V1.5.0", 300)
+
+	var stages []string
+	var last UpgradeProgress
+	err := c.UpgradeFirmware(img, testUpgradeOptions, func(p UpgradeProgress) {
+		stages = append(stages, p.Stage)
+		last = p
+	})
+	if err != nil {
+		t.Fatalf("UpgradeFirmware: %v", err)
+	}
+	if m.Version() != "HCM111Z V1.5.0" {
+		t.Errorf("module version = %q, want HCM111Z V1.5.0", m.Version())
+	}
+	if last.Stage != UpgradeCompleted || last.Sent != 300 || last.Percent != 100 || last.Resumed {
+		t.Errorf("final progress = %+v", last)
+	}
+	if stages[0] != UpgradeValidating || stages[len(stages)-1] != UpgradeCompleted {
+		t.Errorf("stages = %v", stages)
+	}
+	if n := countCommands(m, "AT+QFOTADATA="); n != 3 {
+		t.Errorf("sent %d data chunks, want 3", n)
+	}
+	if c.Upgrading() {
+		t.Error("Upgrading() still true after completion")
+	}
+}
+
+func TestUpgradeFirmwareResumesAfterLostChunk(t *testing.T) {
+	m := NewFakeModule("HCM111Z V1.4.0")
+	m.SetUpgradeVersion("HCM111Z V1.5.0")
+	m.DropResponseAt(128) // 写入成功但应答丢失，应按模块上报的长度继续而不重发
+	m.FailChunkAt(256)    // 写入失败，应从同一偏移重试
+	c := newFakeController(t, m, quectelExtendedDialect{})
+	img := testFirmware(t, "V1.5.0", 300)
+
+	if err := c.UpgradeFirmware(img, testUpgradeOptions, nil); err != nil {
+		t.Fatalf("UpgradeFirmware: %v", err)
+	}
+	if n := countCommands(m, "AT+QFOTADATA=128,"); n != 1 {
+		t.Errorf("chunk at 128 sent %d times, want 1", n)
+	}
+	if n := countCommands(m, "AT+QFOTADATA=256,"); n != 2 {
+		t.Errorf("chunk at 256 sent %d times, want 2", n)
+	}
+	if m.Version() != "HCM111Z V1.5.0" {
+		t.Errorf("module version = %q, want HCM111Z V1.5.0", m.Version())
+	}
+}
+
+func TestUpgradeFirmwareChecksumMismatch(t *testing.T) {
+	data, err := BuildFirmwareImage("V1.5.0", []byte("firmware payload"))
+	if err != nil {
+		t.Fatalf("BuildFirmwareImage: %v", err)
+	}
+	data[len(data)-1] ^= 0xFF
+	if _, err := ParseFirmwareImage(data); err == nil {
+		t.Fatal("ParseFirmwareImage accepted a corrupted payload")
+	}
+
+	// 镜像头校验值与负载不符时，由模块在结束传输时拒绝
+	m := NewFakeModule("HCM111Z V1.4.0")
+	m.SetUpgradeVersion("HCM111Z V1.5.0")
+	c := newFakeController(t, m, quectelExtendedDialect{})
+	img := testFirmware(t, "V1.5.0", 64)
+	img.CRC ^= 1
+
+	var last UpgradeProgress
+	if err := c.UpgradeFirmware(img, testUpgradeOptions, func(p UpgradeProgress) { last = p }); err == nil {
+		t.Fatal("UpgradeFirmware succeeded with a mismatched checksum")
+	}
+	if last.Stage != UpgradeFailed || last.Error == "" {
+		t.Errorf("final progress = %+v", last)
+	}
+	if m.Version() != "HCM111Z V1.4.0" {
+		t.Errorf("module version changed to %q", m.Version())
+	}
+}
+
+func TestUpgradeFirmwareVersionMismatch(t *testing.T) {
+	m := NewFakeModule("HCM111Z V1.4.0")
+	m.SetUpgradeVersion("HCM111Z V1.4.1")
+	c := newFakeController(t, m, quectelExtendedDialect{})
+	img := testFirmware(t, "V1.5.0", 64)
+
+	err := c.UpgradeFirmware(img, testUpgradeOptions, nil)
+	if err == nil || !strings.Contains(err.Error(), "expected 1.5.0") {
+		t.Fatalf("UpgradeFirmware = %v, want version mismatch", err)
+	}
+}
+
+func TestUpgradeFirmwareEmptyPayload(t *testing.T) {
+	m := NewFakeModule("HCM111Z V1.4.0")
+	c := newFakeController(t, m, quectelExtendedDialect{})
+
+	var last UpgradeProgress
+	err := c.UpgradeFirmware(&FirmwareImage{}, testUpgradeOptions, func(p UpgradeProgress) { last = p })
+	if err == nil {
+		t.Fatal("UpgradeFirmware accepted an empty image")
+	}
+	if last.Stage != UpgradeFailed || last.Percent != 0 {
+		t.Errorf("final progress = %+v", last)
+	}
+	if n := countCommands(m, "AT+QFOTA"); n != 0 {
+		t.Errorf("sent %d upgrade commands for an empty image", n)
+	}
+}
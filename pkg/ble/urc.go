@@ -0,0 +1,77 @@
+package ble
+
+import (
+	"strconv"
+	"strings"
+)
+
+// URCType 表示模块主动上报（Unsolicited Result Code）的类型
+type URCType int
+
+const (
+	URCUnknown             URCType = iota // 未识别的上报
+	URCConnected                          // 中心设备已连接
+	URCDisconnected                       // 中心设备已断开
+	URCMTUChanged                         // ATT MTU 协商完成
+	URCPasskeyDisplay                     // 需要向用户展示配对码
+	URCPasskeyRequest                     // 对端要求输入配对码
+	URCEncryptionChanged                  // 链路加密状态变化
+	URCModuleReset                        // 模块复位或重新上电，易失配置已丢失
+	URCIndicationConfirmed                // 对端已确认 Indication
+	URCConnParamsUpdated                  // 连接参数已更新
+	URCDataWritten                        // 中心设备写入了特征值（运维命令或透明代理数据）
+	URCAdvReport                          // 扫描到广播
+)
+
+// URC 表示一条解析后的模块主动上报
+type URC struct {
+	Type      URCType
+	ConnID    int    // 连接索引
+	Addr      string // 对端地址（连接与扫描上报）
+	Reason    string // 断开原因（仅断开上报）
+	MTU       int    // 协商后的 MTU（仅 MTU 上报）
+	Passkey   string // 配对码（仅配对码展示上报）
+	Encrypted bool   // 链路是否已加密（仅加密状态上报）
+	Status    int    // Indication 确认结果，0 表示成功（仅 Indication 确认上报）
+	Interval  int    // 连接间隔，单位 1.25ms（仅连接参数上报）
+	Latency   int    // 从机延迟（仅连接参数上报）
+	Timeout   int    // 监督超时，单位 10ms（仅连接参数上报）
+	Data      string // 写入的内容（写入上报），或十六进制广播数据（扫描上报）
+	RSSI      int    // 信号强度 dBm（仅扫描上报）
+	Raw       string // 原始上报内容
+}
+
+// disconnectReasons HCI 断开原因码与描述的对应关系
+var disconnectReasons = map[int64]string{
+	0x08: "连接超时",
+	0x13: "对端主动断开",
+	0x16: "本端主动断开",
+	0x3b: "连接参数不可接受",
+	0x3d: "MIC校验失败",
+	0x3e: "连接建立失败",
+}
+
+// splitURCFields 去掉上报前缀并按逗号拆分参数
+func splitURCFields(line, prefix string) []string {
+	body := strings.TrimSpace(strings.TrimPrefix(line, prefix))
+	if body == "" {
+		return nil
+	}
+	fields := strings.Split(body, ",")
+	for i := range fields {
+		fields[i] = strings.Trim(strings.TrimSpace(fields[i]), "\"")
+	}
+	return fields
+}
+
+// disconnectReason 将 HCI 原因码（十进制或 0x 十六进制）转换为可读描述
+func disconnectReason(code string) string {
+	value, err := strconv.ParseInt(code, 0, 64)
+	if err != nil {
+		return code
+	}
+	if desc, ok := disconnectReasons[value]; ok {
+		return desc
+	}
+	return code
+}
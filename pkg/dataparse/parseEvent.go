@@ -0,0 +1,52 @@
+package dataparse
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/dtos"
+	"github.com/edgexfoundry/go-mod-messaging/v4/pkg/types"
+)
+
+// ParseEvent 解析 EdgeX 事件消息，返回事件及其全部读数
+func ParseEvent(envelope *types.MessageEnvelope) (*dtos.Event, error) {
+	var data []byte
+	switch payload := envelope.Payload.(type) {
+	case []byte:
+		data = payload
+	case map[string]interface{}:
+		var err error
+		if data, err = json.Marshal(payload); err != nil {
+			return nil, fmt.Errorf("failed to marshal payload: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("payload is not a map or byte slice")
+	}
+
+	// 与 AddEventRequest 结构一致，但不做字段校验，只取设备名称与读数
+	var req struct {
+		Event dtos.Event `json:"event"`
+	}
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal event: %v", err)
+	}
+	if req.Event.DeviceName == "" || len(req.Event.Readings) == 0 {
+		return nil, fmt.Errorf("event device name or readings missing")
+	}
+	return &req.Event, nil
+}
+
+// ReadingValue 返回读数值的文本形式：普通读数返回原值，二进制读数返回十六进制，Object 读数返回 JSON
+func ReadingValue(r dtos.BaseReading) (string, error) {
+	switch {
+	case r.BinaryValue != nil:
+		return fmt.Sprintf("%X", r.BinaryValue), nil
+	case r.ObjectValue != nil:
+		data, err := json.Marshal(r.ObjectValue)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+	return r.Value, nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
@@ -17,9 +18,17 @@ type SerialQueue struct {
 	pendingRequests []interfaces.SerialRequest     // 待处理请求，按顺序存储
 	commandCallback func(string)                   // 异步命令消息回调函数
 	upAgentCallback func(string)                   // 异步透明代理回调函数
+	urcHandler      func(string) bool              // 主动上报（URC）处理函数
+	parser          interfaces.LineParser          // 按 AT 方言解析输出
+	handlerMu       sync.RWMutex                   // 保护 urcHandler 与 parser
 	stopCh          chan struct{}                  // 停止信号通道
 	logger          logger.LoggingClient           // 日志记录器
 	readerCh        chan string                    // 串口读取数据的通用管道
+
+	powerMu      sync.Mutex    // 保护以下休眠状态
+	wakePreamble []byte        // 模块休眠时在命令前发送的唤醒前导，为空时不做唤醒处理
+	wakeSettle   time.Duration // 发送唤醒前导后等待模块就绪的时间
+	asleep       bool          // 模块是否处于休眠
 }
 
 // NewSerialQueue 创建新的串口队列管理器并启动后台处理协程。
@@ -30,6 +39,7 @@ func NewSerialQueue(port interfaces.SerialPortInterface, logger logger.LoggingCl
 	q := &SerialQueue{
 		serialPort:      port,
 		requestCh:       make(chan interfaces.SerialRequest, queueSize),
+		readerCh:        make(chan string, 100),
 		pendingRequests: make([]interfaces.SerialRequest, 0),
 		commandCallback: ccb,
 		upAgentCallback: uacb,
@@ -120,6 +130,7 @@ func (q *SerialQueue) processRequests() {
 			q.logger.Debugf("停止处理请求协程")
 			return
 		case req := <-q.requestCh:
+			q.wakeIfAsleep()
 			if err := q.writeCommand(req.Command); err != nil {
 				// 写入失败，直接发送错误响应，不加入 pendingRequests
 				resp := interfaces.SerialResponse{Data: "", Error: fmt.Errorf("写入命令失败: %v", err)}
@@ -151,7 +162,6 @@ func (q *SerialQueue) writeCommand(cmd []byte) error {
 // startReaderLoop 启动串口读取循环。
 // 持续读取串口数据，处理终止响应并匹配到 pendingRequests 的最早请求。
 func (q *SerialQueue) startReaderLoop() {
-	q.readerCh = make(chan string, 100)
 	go func() {
 		for {
 			select {
@@ -183,23 +193,27 @@ func (q *SerialQueue) startReaderLoop() {
 					continue
 				}
 				line = strings.Trim(line, "\r\n")
-				if line == "" || strings.Contains(line, "freqchip") { //跳过蓝牙回显非法的字符
+				if line == "" {
+					continue
+				}
+				q.onModuleOutput()
+				kind := q.classify(line)
+				if kind == interfaces.LineIgnore { //跳过蓝牙回显非法的字符
 					continue
 				}
 				q.logger.Debugf("收到串口数据: %s", line)
-				if strings.Contains(line, "+COMMAND:") { //处理终端运维命令控制回调
-					lines := strings.Split(line, "+COMMAND:")
-					for _, part := range lines {
-						if part == "" {
-							continue
-						}
+				if q.handleURC(line) { // 模块主动上报（连接、断开等），由上层处理
+					continue
+				}
+				if kind == interfaces.LineCommand { //处理终端运维命令控制回调
+					for _, part := range q.splitCommands(line) {
 						if q.commandCallback != nil {
 							go q.commandCallback(part)
 						}
 					}
 					continue
 				}
-				if q.isTerminal(line) {
+				if kind == interfaces.LineFinal {
 					if len(q.pendingRequests) > 0 {
 						req := q.pendingRequests[0]
 
@@ -210,7 +224,7 @@ func (q *SerialQueue) startReaderLoop() {
 						}
 
 						resp := interfaces.SerialResponse{Data: line}
-						if strings.Contains(line, "ERROR") {
+						if q.isError(line) {
 							resp.Error = fmt.Errorf("命令执行失败: %s", line)
 						}
 
@@ -237,12 +251,106 @@ func (q *SerialQueue) startReaderLoop() {
 	}()
 }
 
-// isTerminal 用于判断是不是请求的响应
-func (q *SerialQueue) isTerminal(line string) bool {
-	return strings.Contains(line, "OK") ||
-		strings.Contains(line, "ERROR") ||
-		strings.Contains(line, "+QVERSION") ||
-		strings.Contains(line, "+QBLEADDR")
+// SetWakePreamble 设置模块休眠后唤醒所用的前导数据，以及发送前导后等待模块就绪的时间。
+func (q *SerialQueue) SetWakePreamble(preamble []byte, settle time.Duration) {
+	q.powerMu.Lock()
+	defer q.powerMu.Unlock()
+	q.wakePreamble = append([]byte(nil), preamble...)
+	q.wakeSettle = settle
+}
+
+// MarkAsleep 标记模块已进入休眠，下一条命令写入前先发送唤醒前导。
+func (q *SerialQueue) MarkAsleep() {
+	q.powerMu.Lock()
+	defer q.powerMu.Unlock()
+	if len(q.wakePreamble) > 0 {
+		q.asleep = true
+	}
+}
+
+// Asleep 返回模块是否处于休眠
+func (q *SerialQueue) Asleep() bool {
+	q.powerMu.Lock()
+	defer q.powerMu.Unlock()
+	return q.asleep
+}
+
+// onModuleOutput 模块有输出说明已被唤醒（如中心设备连接），清除休眠标记
+func (q *SerialQueue) onModuleOutput() {
+	q.powerMu.Lock()
+	defer q.powerMu.Unlock()
+	if q.asleep {
+		q.asleep = false
+		q.logger.Debugf("模块有输出，已退出休眠")
+	}
+}
+
+// wakeIfAsleep 模块休眠时先发送唤醒前导并等待模块就绪，在请求处理协程中于写入命令前调用
+func (q *SerialQueue) wakeIfAsleep() {
+	q.powerMu.Lock()
+	asleep, preamble, settle := q.asleep, q.wakePreamble, q.wakeSettle
+	q.asleep = false
+	q.powerMu.Unlock()
+	if !asleep {
+		return
+	}
+	if _, err := q.serialPort.Write(preamble); err != nil {
+		q.logger.Errorf("发送唤醒前导失败: %v", err)
+	}
+	time.Sleep(settle)
+	q.logger.Debugf("已唤醒模块，等待 %v", settle)
+}
+
+// SetURCHandler 注册主动上报（URC）处理函数。
+// 处理函数在读取协程中同步调用，不能在其中同步发送串口命令，否则会阻塞读取循环。
+func (q *SerialQueue) SetURCHandler(handler func(line string) bool) {
+	q.handlerMu.Lock()
+	defer q.handlerMu.Unlock()
+	q.urcHandler = handler
+}
+
+// handleURC 将一行数据交给 URC 处理函数，返回 true 表示已被消费
+func (q *SerialQueue) handleURC(line string) bool {
+	q.handlerMu.RLock()
+	handler := q.urcHandler
+	q.handlerMu.RUnlock()
+	return handler != nil && handler(line)
+}
+
+// SetLineParser 注册按 AT 方言解析输出的函数，用于区分命令响应、运维命令与透明代理数据。
+func (q *SerialQueue) SetLineParser(parser interfaces.LineParser) {
+	q.handlerMu.Lock()
+	defer q.handlerMu.Unlock()
+	q.parser = parser
+}
+
+// lineParser 返回已注册的解析函数
+func (q *SerialQueue) lineParser() interfaces.LineParser {
+	q.handlerMu.RLock()
+	defer q.handlerMu.RUnlock()
+	return q.parser
+}
+
+// classify 对一行数据分类，未注册解析函数时全部按透明代理数据处理
+func (q *SerialQueue) classify(line string) interfaces.LineKind {
+	if p := q.lineParser(); p != nil {
+		return p.Classify(line)
+	}
+	return interfaces.LineData
+}
+
+// splitCommands 将一行运维命令拆分为各条命令
+func (q *SerialQueue) splitCommands(line string) []string {
+	if p := q.lineParser(); p != nil {
+		return p.SplitCommands(line)
+	}
+	return []string{line}
+}
+
+// isError 最终响应是否表示命令执行失败
+func (q *SerialQueue) isError(line string) bool {
+	p := q.lineParser()
+	return p != nil && p.IsError(line)
 }
 
 // Close 关闭串口队列管理器，停止后台协程并清理资源。